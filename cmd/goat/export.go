@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/studio-b12/goat/internal/version"
+	"github.com/studio-b12/goat/pkg/errs"
+	"github.com/studio-b12/goat/pkg/export"
+	"github.com/studio-b12/goat/pkg/goatfile"
+	"github.com/studio-b12/goat/pkg/har"
+	"github.com/zekrotja/rogu"
+	"github.com/zekrotja/rogu/log"
+)
+
+type ExportArgs struct {
+	Goatfile string `arg:"positional,required" help:"Goatfile to export the requests from"`
+
+	Arg     []string `arg:"-a,--args,separate" help:"Pass params as key value arguments (format: key=value)"`
+	Format  string   `arg:"-f,--format,required" help:"Output format (curl, har, httpie)"`
+	Params  []string `arg:"-p,--params,separate,env:GOATARG_PARAMS" help:"Params file location(s)"`
+	Profile []string `arg:"-P,--profile,separate,env:GOATARG_PROFILE" help:"Select a profile from your home config"`
+}
+
+func (ExportArgs) Description() string {
+	return "Export the requests of a Goatfile as curl or HTTPie commands or as HTTP Archive (HAR). " +
+		"Templates are resolved against the given params and profiles. Scripts are not executed, " +
+		"so requests depending on state set by scripts are reported as unresolvable. Requests " +
+		"which would be skipped due to their condition are left out."
+}
+
+func runExport(argv []string) {
+	var args ExportArgs
+	p := mustParseSubcommand("export", &args, argv)
+
+	// The exported requests are written to stdout,
+	// so all log output is written to stderr.
+	w := rogu.NewPrettyWriter(os.Stderr)
+	w.StyleTag = w.StyleTag.Width(20)
+	log.SetWriter(w)
+
+	format := strings.ToLower(args.Format)
+	switch format {
+	case "curl", "har", "httpie":
+	default:
+		p.Fail("unsupported output format: " + args.Format)
+		return
+	}
+
+	state, err := loadInitialState(args.Profile, args.Params, args.Arg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed loading initial state")
+		return
+	}
+
+	data, err := os.ReadFile(args.Goatfile)
+	if err != nil {
+		log.Fatal().Err(err).Field("file", args.Goatfile).Msg("Failed reading Goatfile")
+		return
+	}
+
+	gf, err := goatfile.Unmarshal(string(data), args.Goatfile)
+	if err != nil {
+		log.Fatal().Err(err).Field("file", args.Goatfile).Msg("Failed parsing Goatfile")
+		return
+	}
+
+	var (
+		reqs         []export.Request
+		unresolvable int
+		skipped      int
+	)
+	for _, req := range export.Requests(gf) {
+		res, err := export.Resolve(req, state)
+		if err != nil {
+			if errs.IsOfType[export.SkipError](err) {
+				log.Info().Err(err).Msg("Skipped request")
+				skipped++
+				continue
+			}
+			if errs.IsOfType[export.ResolveError](err) {
+				log.Warn().Err(err).Msg("Unresolvable request")
+				unresolvable++
+				continue
+			}
+			log.Fatal().Err(err).Msg("Failed resolving request")
+			return
+		}
+		reqs = append(reqs, res)
+	}
+
+	switch format {
+	case "curl":
+		err = export.WriteCurl(os.Stdout, reqs)
+	case "httpie":
+		err = export.WriteHTTPie(os.Stdout, reqs)
+	case "har":
+		err = export.WriteHAR(os.Stdout, reqs, har.Creator{Name: "goat", Version: version.Version})
+	}
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed writing exported requests")
+		return
+	}
+
+	if skipped > 0 {
+		log.Info().Field("n", skipped).Msg("Some requests were skipped due to their condition")
+	}
+
+	if unresolvable > 0 {
+		log.Error().Field("n", unresolvable).Msg("Some requests could not be resolved")
+		os.Exit(1)
+	}
+}
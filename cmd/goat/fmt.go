@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/studio-b12/goat/internal/diff"
+	"github.com/studio-b12/goat/pkg/goatfile"
+	"github.com/studio-b12/goat/pkg/goatfile/formatter"
+	"github.com/zekrotja/rogu/log"
+)
+
+type FmtArgs struct {
+	Goatfile []string `arg:"positional,required" help:"Goatfile(s) or directories to format"`
+
+	Check bool `arg:"--check" help:"Do not write the files; list all files which are not formatted and fail if there are any"`
+	Diff  bool `arg:"--diff" help:"Do not write the files; print the formatting changes as unified diff"`
+}
+
+func (FmtArgs) Description() string {
+	return "Format Goatfiles in canonical form."
+}
+
+func runFmt(argv []string) {
+	var args FmtArgs
+	mustParseSubcommand("fmt", &args, argv)
+
+	files, err := findGoatfiles(args.Goatfile)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed collecting Goatfiles")
+		return
+	}
+
+	var unformatted int
+
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			log.Fatal().Err(err).Field("file", file).Msg("Failed reading Goatfile")
+			return
+		}
+
+		formatted, err := formatter.Format(string(raw))
+		if err != nil {
+			log.Fatal().Err(err).Field("file", file).Msg("Failed parsing Goatfile")
+			return
+		}
+
+		if formatted == string(raw) {
+			continue
+		}
+
+		unformatted++
+
+		if args.Check || args.Diff {
+			if args.Diff {
+				fmt.Print(diff.Unified(file+".orig", file, string(raw), formatted))
+			} else {
+				fmt.Println(file)
+			}
+			continue
+		}
+
+		err = os.WriteFile(file, []byte(formatted), fs.ModePerm)
+		if err != nil {
+			log.Fatal().Err(err).Field("file", file).Msg("Failed writing Goatfile")
+			return
+		}
+	}
+
+	if args.Check && unformatted > 0 {
+		os.Exit(1)
+	}
+}
+
+// findGoatfiles returns the paths of all Goatfiles
+// in the given locations. Directories are searched
+// recursively. Other than on execution, files and
+// directories prefixed with an underscore are
+// included as well.
+func findGoatfiles(locations []string) ([]string, error) {
+	var files []string
+
+	for _, location := range locations {
+		err := filepath.WalkDir(location, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if path == location && !d.IsDir() {
+				files = append(files, path)
+				return nil
+			}
+			if d.IsDir() || filepath.Ext(d.Name()) != "."+goatfile.FileExtension {
+				return nil
+			}
+			files = append(files, path)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
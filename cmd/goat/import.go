@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/studio-b12/goat/pkg/goatfile"
+	"github.com/studio-b12/goat/pkg/goatfile/formatter"
+	"github.com/studio-b12/goat/pkg/goatfile/importer"
+	"github.com/zekrotja/rogu"
+	"github.com/zekrotja/rogu/log"
+)
+
+// importers maps the supported source formats
+// of 'goat import' to their importers.
+var importers = map[string]func(r io.Reader, opts importer.Options) ([]importer.File, error){
+	"postman": importer.Postman,
+	"openapi": importer.OpenAPI,
+}
+
+type ImportArgs struct {
+	Source string `arg:"positional,required" help:"Postman collection or OpenAPI specification file"`
+
+	From   string `arg:"-f,--from,required" help:"Format of the source file (postman, openapi)"`
+	Output string `arg:"-o,--output" help:"Output file or, with --split, output directory (default: stdout or current directory with --split)"`
+	Split  bool   `arg:"--split" help:"Generate a Goatfile for each top level folder or tag"`
+	Force  bool   `arg:"--force" help:"Overwrite existing files"`
+}
+
+func (ImportArgs) Description() string {
+	return "Generate Goatfiles from a Postman collection or an OpenAPI specification."
+}
+
+func runImport(argv []string) {
+	var args ImportArgs
+	p := mustParseSubcommand("import", &args, argv)
+
+	// The generated Goatfile might be written to stdout,
+	// so all log output is written to stderr.
+	w := rogu.NewPrettyWriter(os.Stderr)
+	w.StyleTag = w.StyleTag.Width(20)
+	log.SetWriter(w)
+
+	imp, ok := importers[strings.ToLower(args.From)]
+	if !ok {
+		p.Fail("unsupported source format: " + args.From)
+		return
+	}
+
+	f, err := os.Open(args.Source)
+	if err != nil {
+		log.Fatal().Err(err).Field("file", args.Source).Msg("Failed opening source file")
+		return
+	}
+	defer f.Close()
+
+	files, err := imp(f, importer.Options{Split: args.Split})
+	if err != nil {
+		log.Fatal().Err(err).Field("file", args.Source).Msg("Failed importing source file")
+		return
+	}
+
+	var (
+		names    []string
+		contents []string
+	)
+	for _, file := range files {
+		var sb strings.Builder
+		err = formatter.Print(&sb, file.Goatfile)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed printing Goatfile")
+			return
+		}
+
+		// Generated Goatfiles must always be valid, so
+		// this only fails if the importer is broken.
+		_, err = goatfile.Unmarshal(sb.String(), "")
+		if err != nil {
+			log.Fatal().Err(err).Field("file", file.Name).Msg("Generated Goatfile is invalid")
+			return
+		}
+
+		name := args.Output
+		if args.Split {
+			name = filepath.Join(args.Output, file.Name+"."+goatfile.FileExtension)
+		}
+
+		names = append(names, name)
+		contents = append(contents, sb.String())
+	}
+
+	if !args.Split && args.Output == "" {
+		os.Stdout.WriteString(contents[0])
+		return
+	}
+
+	if !args.Force {
+		for _, name := range names {
+			_, err = os.Stat(name)
+			if err == nil {
+				log.Fatal().Field("file", name).Msg("File already exists (use --force to overwrite)")
+				return
+			}
+			if !errors.Is(err, fs.ErrNotExist) {
+				log.Fatal().Err(err).Field("file", name).Msg("Failed checking output file")
+				return
+			}
+		}
+	}
+
+	for i, name := range names {
+		err = os.MkdirAll(filepath.Dir(name), os.ModePerm)
+		if err != nil {
+			log.Fatal().Err(err).Field("file", name).Msg("Failed creating output directory")
+			return
+		}
+
+		err = os.WriteFile(name, []byte(contents[i]), fs.ModePerm)
+		if err != nil {
+			log.Fatal().Err(err).Field("file", name).Msg("Failed writing Goatfile")
+			return
+		}
+
+		log.Info().Field("file", name).Msg("Goatfile created")
+	}
+}
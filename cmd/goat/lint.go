@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/studio-b12/goat/pkg/clr"
+	"github.com/studio-b12/goat/pkg/lint"
+	"github.com/studio-b12/goat/pkg/util"
+	"github.com/zekrotja/rogu/log"
+)
+
+type LintArgs struct {
+	Goatfile []string `arg:"positional,required" help:"Goatfile(s) or directories to check"`
+
+	Json    bool `arg:"--json,env:GOATARG_JSON" help:"Output the found problems as JSON"`
+	NoColor bool `arg:"--no-color,env:GOATARG_NOCOLOR" help:"Supress colored output"`
+}
+
+func (LintArgs) Description() string {
+	return "Check Goatfiles and all imported and executed Goatfiles for problems without executing them."
+}
+
+func runLint(argv []string) {
+	var args LintArgs
+	mustParseSubcommand("lint", &args, argv)
+
+	clr.SetEnable(!args.Json && !args.NoColor)
+
+	locations := make([]string, 0, len(args.Goatfile))
+	for _, location := range args.Goatfile {
+		locations = append(locations, filepath.ToSlash(location))
+	}
+
+	diags, err := lint.Lint(&util.RootFs{}, locations)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed collecting Goatfiles")
+		return
+	}
+
+	if args.Json {
+		if diags == nil {
+			diags = []lint.Diagnostic{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err = enc.Encode(diags); err != nil {
+			log.Fatal().Err(err).Msg("Failed encoding problems")
+			return
+		}
+	} else {
+		for _, d := range diags {
+			fmt.Println(clr.Print(
+				clr.Format(fmt.Sprintf("%s:%d:%d:", d.File, d.Line, d.Column), clr.FormatBold),
+				" ",
+				clr.Format(d.Message, clr.ColorFGRed)))
+		}
+		if len(diags) == 0 {
+			fmt.Println(clr.Print(clr.Format("No problems found", clr.ColorFGGreen)))
+		} else {
+			fmt.Println(clr.Print(clr.Format(fmt.Sprintf("%d problem(s) found", len(diags)), clr.ColorFGRed)))
+		}
+	}
+
+	if len(diags) > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+
+	"github.com/studio-b12/goat/internal/version"
+	"github.com/studio-b12/goat/pkg/lsp"
+	"github.com/zekrotja/rogu"
+	"github.com/zekrotja/rogu/log"
+)
+
+type LspArgs struct{}
+
+func (LspArgs) Description() string {
+	return "Start a language server for Goatfiles communicating via stdin and stdout."
+}
+
+func runLsp(argv []string) {
+	var args LspArgs
+	mustParseSubcommand("lsp", &args, argv)
+
+	// stdout is used for the communication with
+	// the client, so all log output is written
+	// to stderr.
+	w := rogu.NewPrettyWriter(os.Stderr)
+	w.NoColor = true
+	log.SetWriter(w)
+
+	err := lsp.NewServer(os.Stdin, os.Stdout, version.Version).Run()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Language server failed")
+		return
+	}
+}
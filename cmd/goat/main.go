@@ -3,7 +3,6 @@ package main
 import (
 	"bufio"
 	"context"
-	"crypto/tls"
 	"fmt"
 	"io/fs"
 	"net/http"
@@ -26,6 +25,8 @@ import (
 	"github.com/studio-b12/goat/pkg/config"
 	"github.com/studio-b12/goat/pkg/engine"
 	"github.com/studio-b12/goat/pkg/executor"
+	"github.com/studio-b12/goat/pkg/har"
+	"github.com/studio-b12/goat/pkg/report"
 	"github.com/studio-b12/goat/pkg/requester"
 	"github.com/zekrotja/rogu"
 	"github.com/zekrotja/rogu/level"
@@ -35,27 +36,63 @@ import (
 type Args struct {
 	Goatfile []string `arg:"positional" help:"Goatfile(s) location"`
 
-	Arg           []string      `arg:"-a,--args,separate" help:"Pass params as key value arguments into the execution (format: key=value)"`
-	Delay         time.Duration `arg:"-d,--delay,env:GOATARG_DELAY" help:"Delay requests by the given duration"`
-	Dry           bool          `arg:"--dry" help:"Only parse the goatfile(s) without executing any requests"`
-	Gradual       bool          `arg:"-g,--gradual" help:"Advance the requests maually"`
-	Json          bool          `arg:"--json,env:GOATARG_JSON" help:"Use JSON format instead of pretty console format for logging"`
-	LogLevel      level.Level   `arg:"-l,--loglevel,env:GOATARG_LOGLEVEL" default:"info" help:"Logging level"`
-	New           bool          `arg:"--new" help:"Create a new base Goatfile"`
-	NoAbort       bool          `arg:"--no-abort,env:GOATARG_NOABORT" help:"Do not abort batch execution on error"`
-	NoColor       bool          `arg:"--no-color,env:GOATARG_NOCOLOR" help:"Supress colored log output"`
-	Params        []string      `arg:"-p,--params,separate,env:GOATARG_PARAMS" help:"Params file location(s)"`
-	Profile       []string      `arg:"-P,--profile,separate,env:GOATARG_PROFILE" help:"Select a profile from your home config"`
-	ReducedErrors bool          `arg:"-R,--reduced-errors,env:GOATARG_REDUCEDERRORS" help:"Hide template errors in teardown steps"`
-	Secure        bool          `arg:"--secure,env:GOATARG_SECURE" help:"Validate TLS certificates"`
-	Silent        bool          `arg:"-s,--silent,env:GOATARG_SILENT" help:"Disables all logging output"`
-	Skip          []string      `arg:"--skip,separate,env:GOATARG_SKIP" help:"Section(s) to be skipped during execution"`
-	RetryFailed   bool          `arg:"--retry-failed,env:GOATARG_RETRYFAILED" help:"Retry files which have failed in the previous run"`
-	LogFile       string        `arg:"--log-file,env:GOATARG_LOGFILE" help:"Output JSON logs additionally to a logfile"`
+	Arg             []string      `arg:"-a,--args,separate" help:"Pass params as key value arguments into the execution (format: key=value)"`
+	CACert          string        `arg:"--cacert,env:GOATARG_CACERT" help:"PEM file with CA certificates to trust in addition to the system certificates"`
+	Cert            string        `arg:"--cert,env:GOATARG_CERT" help:"Client certificate presented to servers (PEM or PKCS#12 file)"`
+	CertPassword    string        `arg:"--cert-password,env:GOATARG_CERTPASSWORD" help:"Password of the PKCS#12 client certificate"`
+	ConnectTimeout  time.Duration `arg:"--connect-timeout,env:GOATARG_CONNECTTIMEOUT" help:"Maximum duration for establishing a connection (0 for no timeout)"`
+	Delay           time.Duration `arg:"-d,--delay,env:GOATARG_DELAY" help:"Delay requests by the given duration"`
+	Dry             bool          `arg:"--dry" help:"Only parse the goatfile(s) without executing any requests"`
+	Gradual         bool          `arg:"-g,--gradual" help:"Advance the requests maually"`
+	Har             string        `arg:"--har,env:GOATARG_HAR" help:"Record all requests and responses into the given HAR file"`
+	HarBodyLimit    int           `arg:"--har-body-limit,env:GOATARG_HARBODYLIMIT" default:"1048576" help:"Maximum number of bytes of each body recorded into the HAR file (0 for no limit)"`
+	HeaderTimeout   time.Duration `arg:"--header-timeout,env:GOATARG_HEADERTIMEOUT" help:"Maximum duration to wait for the response headers after a request has been sent (0 for no timeout)"`
+	HTTPVersion     string        `arg:"--http-version,env:GOATARG_HTTPVERSION" help:"Enforce the HTTP version of all requests (http1.1 or http2)"`
+	Json            bool          `arg:"--json,env:GOATARG_JSON" help:"Use JSON format instead of pretty console format for logging"`
+	Key             string        `arg:"--key,env:GOATARG_KEY" help:"PEM file with the private key of the client certificate"`
+	LogLevel        level.Level   `arg:"-l,--loglevel,env:GOATARG_LOGLEVEL" default:"info" help:"Logging level"`
+	New             bool          `arg:"--new" help:"Create a new base Goatfile"`
+	NoAbort         bool          `arg:"--no-abort,env:GOATARG_NOABORT" help:"Do not abort batch execution on error"`
+	NoColor         bool          `arg:"--no-color,env:GOATARG_NOCOLOR" help:"Supress colored log output"`
+	NoKeepAlive     bool          `arg:"--no-keepalive,env:GOATARG_NOKEEPALIVE" help:"Do not reuse connections between requests"`
+	Parallel        int           `arg:"--parallel,env:GOATARG_PARALLEL" help:"Execute up to the given number of batches in parallel"`
+	Params          []string      `arg:"-p,--params,separate,env:GOATARG_PARAMS" help:"Params file location(s)"`
+	Profile         []string      `arg:"-P,--profile,separate,env:GOATARG_PROFILE" help:"Select a profile from your home config"`
+	Proxy           string        `arg:"--proxy,env:GOATARG_PROXY" help:"Proxy URL used for all requests (schemes: http, https, socks5)"`
+	Report          []string      `arg:"--report,separate,env:GOATARG_REPORT" help:"Write a report of the execution results (format: format=path; formats: junit)"`
+	ReducedErrors   bool          `arg:"-R,--reduced-errors,env:GOATARG_REDUCEDERRORS" help:"Hide template errors in teardown steps"`
+	Resolve         []string      `arg:"--resolve,separate,env:GOATARG_RESOLVE" help:"Resolve a host and port to the given address (format: host:port:address)"`
+	Secure          bool          `arg:"--secure,env:GOATARG_SECURE" help:"Validate TLS certificates"`
+	Silent          bool          `arg:"-s,--silent,env:GOATARG_SILENT" help:"Disables all logging output"`
+	Skip            []string      `arg:"--skip,separate,env:GOATARG_SKIP" help:"Section(s) to be skipped during execution"`
+	Timeout         time.Duration `arg:"--timeout,env:GOATARG_TIMEOUT" help:"Maximum duration of a request including reading the response body (0 for no timeout)"`
+	TLSTimeout      time.Duration `arg:"--tls-timeout,env:GOATARG_TLSTIMEOUT" help:"Maximum duration of the TLS handshake (0 for no timeout)"`
+	UpdateSnapshots bool          `arg:"--update-snapshots,env:GOATARG_UPDATESNAPSHOTS" help:"Overwrite stored snapshots with the current values instead of comparing against them"`
+	RetryFailed     bool          `arg:"--retry-failed,env:GOATARG_RETRYFAILED" help:"Retry files which have failed in the previous run"`
+	Watch           bool          `arg:"-w,--watch" help:"Re-execute the affected Goatfiles when they or the files they depend on change"`
+	LogFile         string        `arg:"--log-file,env:GOATARG_LOGFILE" help:"Output JSON logs additionally to a logfile"`
+}
+
+// subcommands maps the names of the subcommands
+// to their entry points, which get passed the
+// arguments following the subcommand name.
+var subcommands = map[string]func(argv []string){
+	"export": runExport,
+	"fmt":    runFmt,
+	"import": runImport,
+	"lint":   runLint,
+	"lsp":    runLsp,
 }
 
 func main() {
 
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			run(os.Args[2:])
+			return
+		}
+	}
+
 	var args Args
 	argParser := arg.MustParse(&args)
 
@@ -113,42 +150,78 @@ func main() {
 		return
 	}
 
-	state := make(engine.State)
+	if args.Watch && args.Gradual {
+		argParser.Fail("--watch can not be combined with --gradual.")
+		return
+	}
 
-	err := config.LoadProfiles(args.Profile, state)
-	if err != nil {
-		log.Fatal().Err(err).Msg("Failed loading profiles")
+	ctx, cancel := signal.NotifyContext(context.Background(),
+		syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM, os.Interrupt, os.Kill)
+	defer cancel()
+
+	if args.Watch {
+		watchGoatfiles(ctx, &args, goatfiles)
 		return
 	}
 
-	cfgState, err := config.Parse[engine.State](args.Params, "GOAT_")
+	state, err := loadInitialState(args.Profile, args.Params, args.Arg)
 	if err != nil {
-		log.Fatal().Err(err).Msg("parameter parsing failed")
+		log.Fatal().Err(err).Msg("Failed loading initial state")
 		return
 	}
-	state.Merge(cfgState)
 
-	err = config.ParseKVArgs(args.Arg, state)
+	_, err = execute(ctx, &args, goatfiles, state)
 	if err != nil {
-		log.Fatal().Err(err).Msg("argument parsing failed")
+		logExecutionError(log.Fatal(), &args, err)
 		return
 	}
 
-	engineMaker := engine.NewGoja
-	req := requester.NewHttpWithCookies(func(client *http.Client) {
-		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: !args.Secure,
-		}}
+	log.Info().Msg(clr.Print(clr.Format("Execution finished successfully", clr.ColorFGGreen, clr.FormatBold)))
+}
+
+// execute executes the given Goatfiles with the given
+// initial state, logs the results and writes the HAR
+// file and reports specified in args.
+func execute(ctx context.Context, args *Args, goatfiles []string, state engine.State) (executor.Result, error) {
+	engineMaker := func() engine.Engine {
+		return engine.NewGojaWithOptions(engine.GojaOptions{
+			UpdateSnapshots: args.UpdateSnapshots,
+		})
+	}
+	var transport http.RoundTripper = requester.NewTransports(requester.TransportOptions{
+		Secure: args.Secure,
+		TLS: requester.TLSOptions{
+			CACert:             args.CACert,
+			ClientCert:         args.Cert,
+			ClientKey:          args.Key,
+			ClientCertPassword: args.CertPassword,
+		},
+		Proxy:                 args.Proxy,
+		HTTPVersion:           args.HTTPVersion,
+		DisableKeepAlives:     args.NoKeepAlive,
+		Resolve:               args.Resolve,
+		ConnectTimeout:        args.ConnectTimeout,
+		TLSHandshakeTimeout:   args.TLSTimeout,
+		ResponseHeaderTimeout: args.HeaderTimeout,
 	})
 
-	ctx, cancel := signal.NotifyContext(context.Background(),
-		syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM, os.Interrupt, os.Kill)
-	defer cancel()
+	var harRecorder *requester.HARRecorder
+	if args.Har != "" {
+		harRecorder = requester.NewHARRecorder(transport,
+			har.Creator{Name: "goat", Version: version.Version}, args.HarBodyLimit)
+		transport = harRecorder
+	}
+
+	req := requester.NewHttpWithCookies(func(client *http.Client) {
+		client.Transport = transport
+		client.Timeout = args.Timeout
+	})
 
 	exec := executor.New(ctx, engineMaker, req)
 	exec.Dry = args.Dry
 	exec.Skip = args.Skip
 	exec.NoAbort = args.NoAbort
+	exec.Parallel = args.Parallel
 
 	if args.Gradual {
 		ad := make(advancer.Channel)
@@ -163,41 +236,104 @@ func main() {
 
 	res, err := exec.Execute(goatfiles, state, !args.ReducedErrors)
 	res.Log()
-	if err != nil {
-		if args.ReducedErrors {
-			err = filterTeardownParamErrors(err)
+
+	if harRecorder != nil {
+		if hErr := harRecorder.WriteToFile(args.Har); hErr != nil {
+			log.Error().Err(hErr).Field("file", args.Har).Msg("Failed writing HAR file")
 		}
+	}
 
-		entry := log.Fatal().Err(err)
+	for _, spec := range args.Report {
+		if rErr := report.WriteToFile(spec, res); rErr != nil {
+			log.Error().Err(rErr).Field("report", spec).Msg("Failed writing report")
+		}
+	}
 
-		if batchErr, ok := errs.As[*executor.BatchResultError](err); ok {
-			if sErr := storeLastFailedFiles(batchErr.FailedFiles()); err != nil {
-				log.Error().Err(sErr).Msg("failed storing latest failed files")
-			}
+	return res, err
+}
 
-			coloredMessages := batchErr.ErrorMessages()
-			for i, p := range coloredMessages {
-				coloredMessages[i] = clr.Print(clr.Format(p, clr.ColorFGRed))
-			}
-			entry.Field("failed_files", coloredMessages)
+// logExecutionError logs the given error of an execution
+// using the given log entry and stores the failed files
+// for --retry-failed.
+func logExecutionError(entry *rogu.Event, args *Args, err error) {
+	if args.ReducedErrors {
+		err = filterTeardownParamErrors(err)
+	}
+
+	entry.Err(err)
+
+	if batchErr, ok := errs.As[*executor.BatchResultError](err); ok {
+		if sErr := storeLastFailedFiles(batchErr.FailedFiles()); sErr != nil {
+			log.Error().Err(sErr).Msg("failed storing latest failed files")
 		}
 
-		entry.Msg(clr.Print(clr.Format("execution failed", clr.ColorFGRed, clr.FormatBold)))
-		return
+		coloredMessages := batchErr.ErrorMessages()
+		for i, p := range coloredMessages {
+			coloredMessages[i] = clr.Print(clr.Format(p, clr.ColorFGRed))
+		}
+		entry.Field("failed_files", coloredMessages)
 	}
 
-	log.Info().Msg(clr.Print(clr.Format("Execution finished successfully", clr.ColorFGGreen, clr.FormatBold)))
+	entry.Msg(clr.Print(clr.Format("execution failed", clr.ColorFGRed, clr.FormatBold)))
 }
 
 func (Args) Description() string {
 	return "Automation tool for executing and evaluating API requests."
 }
 
+func (Args) Epilogue() string {
+	return "Subcommands:\n" +
+		"  export                 Export requests as curl or HTTPie commands or HAR (see 'goat export --help')\n" +
+		"  fmt                    Format Goatfiles in canonical form (see 'goat fmt --help')\n" +
+		"  import                 Generate Goatfiles from Postman collections or OpenAPI specs (see 'goat import --help')\n" +
+		"  lint                   Check Goatfiles for problems (see 'goat lint --help')\n" +
+		"  lsp                    Start a language server for Goatfiles (see 'goat lsp --help')"
+}
+
 func (Args) Version() string {
 	return fmt.Sprintf("goat %s (%s %s %s)",
 		version.Version, version.CommitHash, version.BuildDate, runtime.Version())
 }
 
+// loadInitialState returns the initial state built
+// from the given profiles, parameter files and key-value
+// arguments.
+func loadInitialState(profiles, params, kvArgs []string) (engine.State, error) {
+	state := make(engine.State)
+
+	err := config.LoadProfiles(profiles, state)
+	if err != nil {
+		return nil, errs.WithPrefix("failed loading profiles:", err)
+	}
+
+	cfgState, err := config.Parse[engine.State](params, "GOAT_")
+	if err != nil {
+		return nil, errs.WithPrefix("parameter parsing failed:", err)
+	}
+	state.Merge(cfgState)
+
+	err = config.ParseKVArgs(kvArgs, state)
+	if err != nil {
+		return nil, errs.WithPrefix("argument parsing failed:", err)
+	}
+
+	return state, nil
+}
+
+// mustParseSubcommand parses the given arguments
+// of the subcommand with the given name into dest.
+// On failure, the usage is printed and the program
+// exits.
+func mustParseSubcommand(name string, dest any, argv []string) *arg.Parser {
+	p, err := arg.NewParser(arg.Config{Program: "goat " + name}, dest)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed initializing argument parser")
+		return nil
+	}
+	p.MustParse(argv)
+	return p
+}
+
 func advanceManually(a advancer.Advancer) {
 	scanner := bufio.NewScanner(os.Stdin)
 	log.Info().Msg(
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/studio-b12/goat/pkg/clr"
+	"github.com/studio-b12/goat/pkg/executor"
+	"github.com/studio-b12/goat/pkg/util"
+	"github.com/studio-b12/goat/pkg/watch"
+	"github.com/zekrotja/rogu/log"
+)
+
+// watchPollInterval is the interval in which
+// the watched files are checked for changes.
+const watchPollInterval = 500 * time.Millisecond
+
+// watchGoatfiles executes the Goatfiles at the given locations
+// and re-executes the affected batches each time one of the
+// files they depend on changes until ctx is done.
+//
+// Each execution is started with a freshly loaded initial
+// state. When files change during an execution, the running
+// execution is canceled via its context, so that the teardown
+// steps of the running batch are still executed, before the
+// affected batches are executed again together with the
+// batches which have not been completed before the cancellation.
+func watchGoatfiles(ctx context.Context, args *Args, locations []string) {
+	poller := watch.NewPoller()
+
+	batches, files := watchedFiles(args, locations)
+	poller.Update(files)
+
+	var (
+		cancelRun  context.CancelFunc
+		done       chan struct{}
+		unfinished []string
+	)
+
+	start := func(goatfiles []string) {
+		var runCtx context.Context
+		runCtx, cancelRun = context.WithCancel(ctx)
+		done = make(chan struct{})
+
+		go func() {
+			defer close(done)
+			unfinished = runWatchCycle(runCtx, args, goatfiles)
+		}()
+	}
+
+	// stop cancels the running execution and returns the
+	// Goatfiles which have not been executed completely.
+	stop := func() []string {
+		cancelRun()
+		<-done
+		return unfinished
+	}
+
+	start(goatfilesOf(batches))
+
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			stop()
+			return
+		case <-ticker.C:
+		}
+
+		batches, files = watchedFiles(args, locations)
+		changed := poller.Update(files)
+		if len(changed) == 0 {
+			continue
+		}
+
+		var affected []string
+		for goatfile, deps := range batches {
+			for _, f := range changed {
+				if slices.Contains(deps, f) {
+					affected = append(affected, goatfile)
+					break
+				}
+			}
+		}
+		if len(affected) == 0 {
+			continue
+		}
+
+		log.Info().Field("changed", changed).Msg(clr.Print(clr.Format(
+			"Files changed, re-executing affected Goatfiles ...", clr.ColorFGCyan, clr.FormatBold)))
+
+		for _, goatfile := range stop() {
+			if _, ok := batches[goatfile]; ok && !slices.Contains(affected, goatfile) {
+				affected = append(affected, goatfile)
+			}
+		}
+		slices.Sort(affected)
+
+		start(affected)
+	}
+}
+
+// runWatchCycle executes the given Goatfiles once
+// with a freshly loaded initial state. When ctx is
+// canceled during the execution, the Goatfiles which
+// have not been executed completely are returned.
+func runWatchCycle(ctx context.Context, args *Args, goatfiles []string) (unfinished []string) {
+	defer log.Info().Msg(clr.Print(clr.Format("Waiting for changes ...", clr.ColorFGBlack)))
+
+	state, err := loadInitialState(args.Profile, args.Params, args.Arg)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed loading initial state")
+		return nil
+	}
+
+	res, err := execute(ctx, args, goatfiles, state)
+	if ctx.Err() != nil {
+		unfinished = unfinishedGoatfiles(goatfiles, res)
+	}
+	if err != nil {
+		logExecutionError(log.Error(), args, err)
+		return unfinished
+	}
+
+	log.Info().Msg(clr.Print(clr.Format("Execution finished successfully", clr.ColorFGGreen, clr.FormatBold)))
+
+	return unfinished
+}
+
+// unfinishedGoatfiles returns the given Goatfiles which
+// have not been completed in the given result, either
+// because their batch has been canceled or because
+// they have not been started at all.
+func unfinishedGoatfiles(goatfiles []string, res executor.Result) (unfinished []string) {
+	for _, goatfile := range goatfiles {
+		completed := slices.ContainsFunc(res.Batches, func(batch executor.BatchResult) bool {
+			return batch.Path == goatfile && !batch.Canceled
+		})
+		if !completed {
+			unfinished = append(unfinished, goatfile)
+		}
+	}
+	return unfinished
+}
+
+// watchedFiles returns the Goatfiles executed as batches
+// for the given locations mapped to the files they depend
+// on, including the params files, as well as a list of
+// all of these files.
+func watchedFiles(args *Args, locations []string) (batches map[string][]string, files []string) {
+	batches = make(map[string][]string)
+	files = append(files, args.Params...)
+
+	goatfiles, err := watch.Goatfiles(locations)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed collecting Goatfiles to watch")
+	}
+
+	for _, gf := range goatfiles {
+		deps := watch.Dependencies(&util.RootFs{}, gf)
+		deps = append(deps, args.Params...)
+		batches[gf] = deps
+		files = append(files, deps...)
+	}
+
+	return batches, files
+}
+
+// goatfilesOf returns the sorted Goatfiles
+// of the given batches.
+func goatfilesOf(batches map[string][]string) []string {
+	goatfiles := make([]string, 0, len(batches))
+	for gf := range batches {
+		goatfiles = append(goatfiles, gf)
+	}
+	slices.Sort(goatfiles)
+	return goatfiles
+}
@@ -0,0 +1,194 @@
+// Package diff implements a simple line based
+// diff in the unified diff format.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines
+// shown around changed lines.
+const contextLines = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	line string
+}
+
+// Unified returns the differences between a and b in the
+// unified diff format. The given names are used as file
+// names in the diff header. An empty string is returned
+// when a and b are equal.
+func Unified(nameA, nameB, a, b string) string {
+	if a == b {
+		return ""
+	}
+
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", nameA, nameB)
+
+	for _, h := range hunks(ops) {
+		h.write(&sb, ops)
+	}
+
+	return sb.String()
+}
+
+// splitLines returns the lines of s including their line
+// breaks, so that a missing line break at the end of s
+// differs from an existing one.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the operations to transform a into b
+// based on the longest common subsequence of both.
+func diffLines(a, b []string) []op {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]op, 0, max(len(a), len(b)))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{opEqual, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{opDelete, a[i]})
+			i++
+		default:
+			ops = append(ops, op{opInsert, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, op{opDelete, a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, op{opInsert, b[j]})
+	}
+
+	return ops
+}
+
+// hunk is a range of operations which
+// is printed as one block.
+type hunk struct {
+	start, end     int // range in ops
+	startA, startB int // first line numbers in a and b
+}
+
+func hunks(ops []op) []hunk {
+	var (
+		res        []hunk
+		lineA      = 1
+		lineB      = 1
+		linesA     = make([]int, len(ops))
+		linesB     = make([]int, len(ops))
+		lastChange = -1
+	)
+
+	for i, o := range ops {
+		linesA[i], linesB[i] = lineA, lineB
+		if o.kind != opInsert {
+			lineA++
+		}
+		if o.kind != opDelete {
+			lineB++
+		}
+	}
+
+	for i, o := range ops {
+		if o.kind == opEqual {
+			continue
+		}
+
+		start := max(i-contextLines, 0)
+		if len(res) > 0 && start <= lastChange+contextLines {
+			res[len(res)-1].end = min(i+contextLines+1, len(ops))
+		} else {
+			res = append(res, hunk{
+				start:  start,
+				end:    min(i+contextLines+1, len(ops)),
+				startA: linesA[start],
+				startB: linesB[start],
+			})
+		}
+		lastChange = i
+	}
+
+	return res
+}
+
+func (t hunk) write(sb *strings.Builder, ops []op) {
+	var countA, countB int
+	for _, o := range ops[t.start:t.end] {
+		if o.kind != opInsert {
+			countA++
+		}
+		if o.kind != opDelete {
+			countB++
+		}
+	}
+
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n",
+		hunkRange(t.startA, countA), hunkRange(t.startB, countB))
+
+	for _, o := range ops[t.start:t.end] {
+		switch o.kind {
+		case opEqual:
+			sb.WriteString(" ")
+		case opDelete:
+			sb.WriteString("-")
+		case opInsert:
+			sb.WriteString("+")
+		}
+		sb.WriteString(o.line)
+		if !strings.HasSuffix(o.line, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// By convention, empty ranges refer to the
+		// line before the range.
+		return fmt.Sprintf("%d,0", start-1)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
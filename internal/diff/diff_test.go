@@ -0,0 +1,53 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnified(t *testing.T) {
+	t.Run("equal", func(t *testing.T) {
+		assert.Equal(t, "", Unified("a", "b", "foo\nbar\n", "foo\nbar\n"))
+	})
+
+	t.Run("changes", func(t *testing.T) {
+		a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+		b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+
+		expected := "--- a\n+++ b\n" +
+			"@@ -1,6 +1,6 @@\n" +
+			" 1\n 2\n-3\n+three\n 4\n 5\n 6\n" +
+			"@@ -10,3 +10,4 @@\n" +
+			" 10\n 11\n 12\n+13\n"
+
+		assert.Equal(t, expected, Unified("a", "b", a, b))
+	})
+
+	t.Run("merged-hunks", func(t *testing.T) {
+		a := "1\n2\n3\n4\n5\n"
+		b := "one\n2\n3\n4\nfive\n"
+
+		expected := "--- a\n+++ b\n" +
+			"@@ -1,5 +1,5 @@\n" +
+			"-1\n+one\n 2\n 3\n 4\n-5\n+five\n"
+
+		assert.Equal(t, expected, Unified("a", "b", a, b))
+	})
+
+	t.Run("missing-newline", func(t *testing.T) {
+		expected := "--- a\n+++ b\n" +
+			"@@ -1,2 +1,2 @@\n" +
+			" 1\n-2\n\\ No newline at end of file\n+2\n"
+
+		assert.Equal(t, expected, Unified("a", "b", "1\n2", "1\n2\n"))
+	})
+
+	t.Run("added-newline", func(t *testing.T) {
+		expected := "--- a\n+++ b\n" +
+			"@@ -1 +1 @@\n" +
+			"-1\n+1\n\\ No newline at end of file\n"
+
+		assert.Equal(t, expected, Unified("a", "b", "1\n", "1"))
+	})
+}
@@ -1,21 +1,101 @@
 package engine
 
+import "github.com/zekrotja/rogu"
+
 // Engine defines a service which can run scripts.
 type Engine interface {
+	// SetLogger sets the logger used by the
+	// logging builtins of the runtime.
+	SetLogger(l rogu.Logger)
+
 	// SetState sets the given state s
 	// to the global state of the runtime.
 	SetState(s State)
 
+	// SetDir sets the directory against which
+	// relative file paths passed to builtins
+	// of the runtime are resolved.
+	SetDir(dir string)
+
 	// Set sets the given value by the given
 	// name to the global context of the runtime.
 	Set(name string, v any) error
 
 	// Run executes the given script in the
-	// runtime.
-	Run(script string) error
+	// runtime. The origin of the script is used
+	// to report the position of failing code.
+	Run(script string, origin Origin) error
+
+	// Eval evaluates the given expression in
+	// the runtime and returns its result. The
+	// origin of the expression is used to report
+	// the position of failing code.
+	Eval(expr string, origin Origin) (any, error)
+
+	// SetSoftAssertions sets whether failing
+	// assertions are collected and returned
+	// together after the script has been run
+	// instead of aborting the script.
+	SetSoftAssertions(soft bool)
+
+	// SetHTTPHandler sets the handler performing
+	// HTTP requests issued by scripts. When nil,
+	// scripts can not perform HTTP requests.
+	SetHTTPHandler(h HTTPHandler)
+
+	// Checks returns the number of passed and
+	// failed assertions of the last run script.
+	Checks() Checks
 
 	// State returns a map of all set
 	// variables in the global state
 	// which are not of the type 'function'.
 	State() State
 }
+
+// Origin describes the location
+// a script has been loaded from.
+type Origin struct {
+	// File is the path of the file
+	// containing the script.
+	File string
+
+	// Line is the line in File the
+	// script starts at, starting
+	// with 1.
+	Line int
+}
+
+// HTTPRequest describes a HTTP
+// request issued by a script.
+type HTTPRequest struct {
+	Method string
+	URL    string
+	Header map[string][]string
+	Body   []byte
+
+	// Options contains request options like
+	// the ones specified in the [Options]
+	// block of a request.
+	Options map[string]any
+}
+
+// HTTPHandler performs the given HTTP request
+// issued by a script and returns the response
+// which is passed back to the script.
+type HTTPHandler func(req HTTPRequest) (any, error)
+
+// Checks holds the number of passed and
+// failed assertions of a script run.
+type Checks struct {
+	Passed int
+	Failed int
+}
+
+// Add returns the sum of t and other.
+func (t Checks) Add(other Checks) Checks {
+	return Checks{
+		Passed: t.Passed + other.Passed,
+		Failed: t.Failed + other.Failed,
+	}
+}
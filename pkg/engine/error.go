@@ -1,6 +1,10 @@
 package engine
 
-import "github.com/studio-b12/goat/pkg/errs"
+import (
+	"fmt"
+
+	"github.com/studio-b12/goat/pkg/errs"
+)
 
 // Exception wraps an engine execution error
 // and holds a simple message concluding the
@@ -9,11 +13,40 @@ type Exception struct {
 	errs.InnerError
 
 	Msg string
+	// Pos is the position of the failing
+	// code, if known.
+	Pos Position
 }
 
 func (t Exception) Error() string {
-	if t.Msg == "" {
-		return "<unknown exception>"
+	msg := t.Msg
+	if msg == "" {
+		msg = "<unknown exception>"
+	}
+	if t.Pos.IsSet() {
+		msg = fmt.Sprintf("%s (at %s)", msg, t.Pos)
 	}
-	return t.Msg
+	return msg
+}
+
+// Position describes the location of
+// code in a script file.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+
+	// Source contains the trimmed
+	// line of code at the position.
+	Source string
+}
+
+// IsSet returns true if the position
+// references a file.
+func (t Position) IsSet() bool {
+	return t.File != ""
+}
+
+func (t Position) String() string {
+	return fmt.Sprintf("%s:%d:%d", t.File, t.Line, t.Column)
 }
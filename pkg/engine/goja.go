@@ -1,15 +1,50 @@
 package engine
 
 import (
+	"os"
 	"reflect"
+	"strings"
 
 	"github.com/dop251/goja"
+	"github.com/studio-b12/goat/pkg/errs"
+	"github.com/studio-b12/goat/pkg/schema"
+	"github.com/zekrotja/rogu"
+	"github.com/zekrotja/rogu/log"
 )
 
-// Goja is the Engine implementation using
-// ECMAScript 5.
+// Goja is the Engine implementation using the
+// goja runtime, which supports ECMAScript 5.1
+// and most features up to ECMAScript 2020.
+//
+// Scripts can import ES modules from files
+// relative to the directory set via SetDir.
+// Each module is only loaded once per runtime.
 type Goja struct {
-	rt *goja.Runtime
+	rt      *goja.Runtime
+	log     rogu.Logger
+	dir     string
+	opts    GojaOptions
+	modules map[string]*goja.Object
+	sources map[string]string
+
+	soft     bool
+	checks   Checks
+	failures []Exception
+
+	http HTTPHandler
+
+	// schemas caches the schema documents
+	// used by the assert_schema builtin.
+	schemas schema.Cache
+}
+
+// GojaOptions wraps options controlling the
+// behavior of builtins of the Goja engine.
+type GojaOptions struct {
+	// UpdateSnapshots makes the snapshot builtin
+	// overwrite stored snapshots instead of
+	// comparing against them.
+	UpdateSnapshots bool
 }
 
 var _ Engine = (*Goja)(nil)
@@ -17,12 +52,34 @@ var _ Engine = (*Goja)(nil)
 // NewGoja initializes the Goja engine runtime
 // and sets builtin functions to the global scope.
 func NewGoja() Engine {
+	return NewGojaWithOptions(GojaOptions{})
+}
+
+// NewGojaWithOptions initializes the Goja engine
+// runtime like NewGoja using the given options.
+func NewGojaWithOptions(opts GojaOptions) Engine {
 	var t Goja
 
 	t.rt = goja.New()
+	t.log = log.Copy()
+	t.opts = opts
+	t.modules = make(map[string]*goja.Object)
+	t.sources = make(map[string]string)
 
 	t.Set("assert", t.builtin_assert)
 	t.Set("assert_eq", t.builtin_assert_eq)
+	t.Set("assert_ne", t.builtin_assert_ne)
+	t.Set("assert_gt", t.builtin_assert_gt)
+	t.Set("assert_ge", t.builtin_assert_ge)
+	t.Set("assert_lt", t.builtin_assert_lt)
+	t.Set("assert_le", t.builtin_assert_le)
+	t.Set("assert_contains", t.builtin_assert_contains)
+	t.Set("assert_match", t.builtin_assert_match)
+	t.Set("assert_type", t.builtin_assert_type)
+	t.Set("assert_len", t.builtin_assert_len)
+	t.Set("assert_header", t.builtin_assert_header)
+	t.Set("assert_status_in", t.builtin_assert_status_in)
+	t.Set("assert_schema", t.builtin_assert_schema)
 	t.Set("debug", t.builtin_debug)
 	t.Set("debugf", t.builtin_debugf)
 	t.Set("info", t.builtin_info)
@@ -37,10 +94,21 @@ func NewGoja() Engine {
 	t.Set("printf", t.builtin_printf)
 	t.Set("println", t.builtin_println)
 	t.Set("jq", t.builtin_jq)
+	t.Set("snapshot", t.builtin_snapshot)
+	t.Set("http", t.httpObject())
+	t.Set(importFuncName, t.builtin_import)
 
 	return &t
 }
 
+func (t *Goja) SetLogger(l rogu.Logger) {
+	t.log = l
+}
+
+func (t *Goja) SetDir(dir string) {
+	t.dir = dir
+}
+
 func (t *Goja) SetState(s State) {
 	for k, v := range s {
 		t.Set(k, v)
@@ -51,23 +119,76 @@ func (t *Goja) Set(name string, v any) error {
 	return t.rt.Set(name, v)
 }
 
-func (t *Goja) Run(script string) error {
-	_, err := t.rt.RunString(script)
-	if gojaException, ok := err.(*goja.Exception); ok {
-		// Extract Goja Exceptions into a new exception
-		// wrapper so that we can handle how error
-		// messages are printed.
-		var ex Exception
-		ex.Inner = gojaException
-		val := gojaException.Value()
-		if val != nil {
-			ex.Msg = val.String()
+func (t *Goja) Run(script string, origin Origin) error {
+	script, err := transformScript(script, t.dir)
+	if err != nil {
+		return err
+	}
+
+	// Lines are prepended so that positions in the
+	// compiled script match the lines in the origin.
+	if origin.Line > 1 {
+		script = strings.Repeat("\n", origin.Line-1) + script
+	}
+
+	prg, err := goja.Compile(origin.File, script, false)
+	if err != nil {
+		return err
+	}
+
+	t.checks = Checks{}
+	t.failures = nil
+
+	_, err = t.rt.RunProgram(prg)
+	err = t.wrapException(err)
+
+	if len(t.failures) != 0 {
+		var failures errs.Errors
+		for _, f := range t.failures {
+			failures = failures.Append(f)
+		}
+		if err != nil {
+			failures = failures.Append(err)
 		}
-		return ex
+		return failures.Condense()
 	}
+
 	return err
 }
 
+func (t *Goja) Eval(expr string, origin Origin) (any, error) {
+	// The expression is wrapped in parentheses so that
+	// object literals are not parsed as blocks.
+	src := "(" + expr + "\n)"
+	if origin.Line > 1 {
+		src = strings.Repeat("\n", origin.Line-1) + src
+	}
+
+	prg, err := goja.Compile(origin.File, src, false)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := t.rt.RunProgram(prg)
+	if err != nil {
+		return nil, t.wrapException(err)
+	}
+
+	return v.Export(), nil
+}
+
+func (t *Goja) SetSoftAssertions(soft bool) {
+	t.soft = soft
+}
+
+func (t *Goja) SetHTTPHandler(h HTTPHandler) {
+	t.http = h
+}
+
+func (t *Goja) Checks() Checks {
+	return t.checks
+}
+
 func (t *Goja) State() State {
 	values := make(State)
 	for _, key := range t.rt.GlobalObject().Keys() {
@@ -83,3 +204,65 @@ func (t *Goja) State() State {
 
 	return values
 }
+
+// wrapException extracts Goja exceptions into a new
+// exception wrapper so that we can handle how error
+// messages are printed. Other errors are returned
+// unchanged.
+func (t *Goja) wrapException(err error) error {
+	gojaException, ok := err.(*goja.Exception)
+	if !ok {
+		return err
+	}
+
+	var ex Exception
+	ex.Inner = gojaException
+	val := gojaException.Value()
+	if val != nil {
+		ex.Msg = val.String()
+	}
+	ex.Pos = t.stackPosition(gojaException.Stack())
+	return ex
+}
+
+// stackPosition returns the position of the innermost
+// code in the given stack which has been loaded from a
+// file.
+func (t *Goja) stackPosition(stack []goja.StackFrame) Position {
+	for _, frame := range stack {
+		if frame.SrcName() == "" || frame.SrcName() == "<native>" {
+			continue
+		}
+
+		pos := frame.Position()
+		return Position{
+			File:   pos.Filename,
+			Line:   pos.Line,
+			Column: pos.Column,
+			Source: t.sourceLine(pos.Filename, pos.Line),
+		}
+	}
+
+	return Position{}
+}
+
+// sourceLine returns the trimmed line with the given
+// number, starting with 1, of the file at pth. Files
+// are only read once per runtime.
+func (t *Goja) sourceLine(pth string, line int) string {
+	src, ok := t.sources[pth]
+	if !ok {
+		data, err := os.ReadFile(pth)
+		if err != nil {
+			return ""
+		}
+		src = string(data)
+		t.sources[pth] = src
+	}
+
+	lines := strings.Split(src, "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return strings.TrimSpace(lines[line-1])
+}
@@ -2,15 +2,19 @@ package engine
 
 import (
 	"fmt"
-	"github.com/itchyny/gojq"
+	"path/filepath"
 	"reflect"
+	"regexp"
 	"strings"
+	"unicode/utf8"
 
-	"github.com/zekrotja/rogu/log"
+	"github.com/itchyny/gojq"
+	"github.com/studio-b12/goat/pkg/snapshot"
 )
 
 func (t *Goja) builtin_assert(v bool, msg ...string) {
 	if v {
+		t.pass()
 		return
 	}
 
@@ -19,11 +23,12 @@ func (t *Goja) builtin_assert(v bool, msg ...string) {
 		mesg = fmt.Sprintf("%s: %s", mesg, strings.Join(msg, " "))
 	}
 
-	panic(t.rt.ToValue(mesg))
+	t.throw(mesg)
 }
 
 func (t *Goja) builtin_assert_eq(value any, expected any, msg ...string) {
-	if reflect.DeepEqual(value, expected) {
+	if equal(value, expected) {
+		t.pass()
 		return
 	}
 
@@ -32,29 +37,270 @@ func (t *Goja) builtin_assert_eq(value any, expected any, msg ...string) {
 		part = strings.Join(msg, " ")
 	}
 
+	if changes, ok := diff(expected, value); ok {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "assertion failed: %s: value does not match expected value (%d difference(s))",
+			part, len(changes))
+		for _, c := range changes {
+			sb.WriteString("\n  ")
+			sb.WriteString(c.String())
+		}
+		t.throw(sb.String())
+		return
+	}
+
 	mesg := fmt.Sprintf("assertion failed: %s: expected `%v` != received `%v`", part, expected, value)
 
+	t.throw(mesg)
+}
+
+func (t *Goja) builtin_assert_ne(value any, unexpected any, msg ...string) {
+	if !equal(value, unexpected) {
+		t.pass()
+		return
+	}
+
+	t.fail(msg, "value `%v` must not be equal to `%v`", value, unexpected)
+}
+
+func (t *Goja) builtin_assert_gt(value any, limit any, msg ...string) {
+	t.assertCompare(value, limit, ">", func(a, b float64) bool { return a > b }, msg)
+}
+
+func (t *Goja) builtin_assert_ge(value any, limit any, msg ...string) {
+	t.assertCompare(value, limit, ">=", func(a, b float64) bool { return a >= b }, msg)
+}
+
+func (t *Goja) builtin_assert_lt(value any, limit any, msg ...string) {
+	t.assertCompare(value, limit, "<", func(a, b float64) bool { return a < b }, msg)
+}
+
+func (t *Goja) builtin_assert_le(value any, limit any, msg ...string) {
+	t.assertCompare(value, limit, "<=", func(a, b float64) bool { return a <= b }, msg)
+}
+
+func (t *Goja) builtin_assert_contains(container any, element any, msg ...string) {
+	switch c := container.(type) {
+	case string:
+		if s, ok := element.(string); ok && strings.Contains(c, s) {
+			t.pass()
+			return
+		}
+		t.fail(msg, "`%s` does not contain `%v`", c, element)
+
+	case []any:
+		for _, v := range c {
+			if equal(v, element) {
+				t.pass()
+				return
+			}
+		}
+		t.fail(msg, "list does not contain `%v`", element)
+
+	case map[string]any:
+		if key, ok := element.(string); ok {
+			if _, ok = c[key]; ok {
+				t.pass()
+				return
+			}
+		}
+		t.fail(msg, "object does not contain key `%v`", element)
+
+	default:
+		t.fail(msg, "value of type %s can not contain other values", jsType(container))
+	}
+}
+
+func (t *Goja) builtin_assert_match(value any, pattern string, msg ...string) {
+	rx, err := regexp.Compile(pattern)
+	if err != nil {
+		panic(t.rt.ToValue(fmt.Sprintf("invalid pattern: %s", err.Error())))
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		s = fmt.Sprintf("%v", value)
+	}
+
+	if rx.MatchString(s) {
+		t.pass()
+		return
+	}
+
+	t.fail(msg, "`%s` does not match pattern `%s`", s, pattern)
+}
+
+func (t *Goja) builtin_assert_type(value any, typ string, msg ...string) {
+	actual := jsType(value)
+	if actual == typ {
+		t.pass()
+		return
+	}
+
+	t.fail(msg, "expected value of type %s, but was %s", typ, actual)
+}
+
+func (t *Goja) builtin_assert_len(value any, length int, msg ...string) {
+	var actual int
+	switch v := value.(type) {
+	case string:
+		actual = utf8.RuneCountInString(v)
+	case []any:
+		actual = len(v)
+	case map[string]any:
+		actual = len(v)
+	default:
+		rv := reflect.ValueOf(value)
+		switch rv.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			actual = rv.Len()
+		default:
+			t.fail(msg, "value of type %s has no length", jsType(value))
+			return
+		}
+	}
+
+	if actual == length {
+		t.pass()
+		return
+	}
+
+	t.fail(msg, "expected length %d, but was %d", length, actual)
+}
+
+func (t *Goja) builtin_assert_header(response any, name string, expected any, msg ...string) {
+	values, ok := headerValues(response, name)
+	if !ok {
+		t.fail(msg, "header `%s` is not set", name)
+		return
+	}
+
+	if expected == nil {
+		t.pass()
+		return
+	}
+
+	for _, v := range values {
+		if equal(v, expected) {
+			t.pass()
+			return
+		}
+	}
+
+	t.fail(msg, "expected header `%s` to be `%v`, but was `%s`", name, expected, strings.Join(values, ", "))
+}
+
+func (t *Goja) builtin_assert_status_in(response any, codes []int, msg ...string) {
+	status, ok := toNumber(response)
+	if !ok {
+		v, _ := field(response, "StatusCode")
+		status, ok = toNumber(v)
+	}
+	if !ok {
+		panic(t.rt.ToValue("invalid response: no status code found"))
+	}
+
+	for _, code := range codes {
+		if float64(code) == status {
+			t.pass()
+			return
+		}
+	}
+
+	t.fail(msg, "expected status code to be one of %v, but was %v", codes, status)
+}
+
+func (t *Goja) builtin_assert_schema(value any, schemaPath string, msg ...string) {
+	if !filepath.IsAbs(schemaPath) {
+		schemaPath = filepath.Join(t.dir, schemaPath)
+	}
+
+	err := t.schemas.Validate(value, schemaPath)
+	if err == nil {
+		t.pass()
+		return
+	}
+
+	mesg := "assertion failed"
+	if len(msg) != 0 {
+		mesg = fmt.Sprintf("%s: %s", mesg, strings.Join(msg, " "))
+	}
+
+	t.throw(fmt.Sprintf("%s: %s", mesg, err.Error()))
+}
+
+// pass records a passed assertion.
+func (t *Goja) pass() {
+	t.checks.Passed++
+}
+
+// throw records a failed assertion with the given
+// message. When soft assertions are enabled, the
+// failure is collected and the script continues.
+// Otherwise, the message is thrown as exception.
+func (t *Goja) throw(mesg string) {
+	t.checks.Failed++
+
+	if t.soft {
+		t.failures = append(t.failures, Exception{
+			Msg: mesg,
+			Pos: t.stackPosition(t.rt.CaptureCallStack(0, nil)),
+		})
+		return
+	}
+
 	panic(t.rt.ToValue(mesg))
 }
 
+// fail records a failed assertion like throw with the
+// given user message parts and formatted description.
+func (t *Goja) fail(msg []string, format string, v ...any) {
+	mesg := "assertion failed"
+	if len(msg) != 0 {
+		mesg = fmt.Sprintf("%s: %s", mesg, strings.Join(msg, " "))
+	}
+
+	t.throw(fmt.Sprintf("%s: %s", mesg, fmt.Sprintf(format, v...)))
+}
+
+func (t *Goja) assertCompare(value, limit any, op string, cmp func(a, b float64) bool, msg []string) {
+	a, ok := toNumber(value)
+	if !ok {
+		t.fail(msg, "value `%v` is not a number", value)
+		return
+	}
+	b, ok := toNumber(limit)
+	if !ok {
+		t.fail(msg, "limit `%v` is not a number", limit)
+		return
+	}
+
+	if cmp(a, b) {
+		t.pass()
+		return
+	}
+
+	t.fail(msg, "expected `%v` %s `%v`", value, op, limit)
+}
+
 func (t *Goja) builtin_debug(msg ...string) {
-	log.Debug().Msg(strings.Join(msg, " "))
+	t.log.Debug().Msg(strings.Join(msg, " "))
 }
 
 func (t *Goja) builtin_info(msg ...string) {
-	log.Info().Msg(strings.Join(msg, " "))
+	t.log.Info().Msg(strings.Join(msg, " "))
 }
 
 func (t *Goja) builtin_warn(msg ...string) {
-	log.Warn().Msg(strings.Join(msg, " "))
+	t.log.Warn().Msg(strings.Join(msg, " "))
 }
 
 func (t *Goja) builtin_error(msg ...string) {
-	log.Error().Msg(strings.Join(msg, " "))
+	t.log.Error().Msg(strings.Join(msg, " "))
 }
 
 func (t *Goja) builtin_fatal(msg ...string) {
-	log.Fatal().Msg(strings.Join(msg, " "))
+	t.log.Fatal().Msg(strings.Join(msg, " "))
 }
 
 func (t *Goja) builtin_print(msg ...string) {
@@ -66,23 +312,23 @@ func (t *Goja) builtin_println(msg ...string) {
 }
 
 func (t *Goja) builtin_debugf(format string, v ...any) {
-	log.Debug().Msgf(format, v...)
+	t.log.Debug().Msgf(format, v...)
 }
 
 func (t *Goja) builtin_infof(format string, v ...any) {
-	log.Info().Msgf(format, v...)
+	t.log.Info().Msgf(format, v...)
 }
 
 func (t *Goja) builtin_warnf(format string, v ...any) {
-	log.Warn().Msgf(format, v...)
+	t.log.Warn().Msgf(format, v...)
 }
 
 func (t *Goja) builtin_errorf(format string, v ...any) {
-	log.Error().Msgf(format, v...)
+	t.log.Error().Msgf(format, v...)
 }
 
 func (t *Goja) builtin_fatalf(format string, v ...any) {
-	log.Fatal().Msgf(format, v...)
+	t.log.Fatal().Msgf(format, v...)
 }
 
 func (t *Goja) builtin_printf(format string, v ...any) {
@@ -113,3 +359,172 @@ func (t *Goja) builtin_jq(object any, src string) []any {
 
 	return results
 }
+
+func (t *Goja) builtin_snapshot(value any, name string, ignore ...[]string) {
+	var ignorePaths []string
+	if len(ignore) != 0 {
+		ignorePaths = ignore[0]
+	}
+
+	res, err := snapshot.Match(t.dir, name, value, ignorePaths, t.opts.UpdateSnapshots)
+	if err != nil {
+		t.throw(fmt.Sprintf("snapshot failed: %s", err.Error()))
+		return
+	}
+	t.pass()
+
+	switch res {
+	case snapshot.Created:
+		t.log.Info().Field("name", name).Msg("Snapshot created")
+	case snapshot.Updated:
+		t.log.Info().Field("name", name).Msg("Snapshot updated")
+	}
+}
+
+// equal deep-compares a and b based on their JSON
+// representation, so that numbers of different
+// types with the same value are considered equal.
+func equal(a, b any) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+
+	na, errA := snapshot.Normalize(a, nil)
+	nb, errB := snapshot.Normalize(b, nil)
+	if errA != nil || errB != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(na, nb)
+}
+
+// diff returns the differences between the given
+// values if both are objects or lists.
+func diff(expected, actual any) ([]snapshot.Change, bool) {
+	ne, err := snapshot.Normalize(expected, nil)
+	if err != nil {
+		return nil, false
+	}
+	na, err := snapshot.Normalize(actual, nil)
+	if err != nil {
+		return nil, false
+	}
+
+	if !isContainer(ne) || !isContainer(na) {
+		return nil, false
+	}
+
+	return snapshot.Diff(ne, na), true
+}
+
+func isContainer(v any) bool {
+	switch v.(type) {
+	case map[string]any, []any:
+		return true
+	default:
+		return false
+	}
+}
+
+// toNumber returns the numeric value of v
+// if v is of any integer or float type.
+func toNumber(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// jsType returns the JavaScript type name of v, where
+// lists are reported as "array" and null as "null".
+func jsType(v any) string {
+	if v == nil {
+		return "null"
+	}
+	if _, ok := toNumber(v); ok {
+		return "number"
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Func:
+		return "function"
+	default:
+		return "object"
+	}
+}
+
+// field returns the value of the field or entry
+// with the given name of the struct or map v.
+func field(v any, name string) (any, bool) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	switch rv.Kind() {
+	case reflect.Struct:
+		f := rv.FieldByName(name)
+		if !f.IsValid() || !f.CanInterface() {
+			return nil, false
+		}
+		return f.Interface(), true
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, false
+		}
+		e := rv.MapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()))
+		if !e.IsValid() {
+			return nil, false
+		}
+		return e.Interface(), true
+	default:
+		return nil, false
+	}
+}
+
+// headerValues returns the values of the header with
+// the given name, matched case-insensitively, of the
+// given response or header map.
+func headerValues(response any, name string) ([]string, bool) {
+	header, ok := field(response, "Header")
+	if !ok {
+		header = response
+	}
+
+	rv := reflect.ValueOf(header)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+
+	iter := rv.MapRange()
+	for iter.Next() {
+		if !strings.EqualFold(iter.Key().String(), name) {
+			continue
+		}
+
+		var values []string
+		switch vt := iter.Value().Interface().(type) {
+		case []string:
+			values = vt
+		case []any:
+			for _, v := range vt {
+				values = append(values, fmt.Sprintf("%v", v))
+			}
+		default:
+			values = []string{fmt.Sprintf("%v", vt)}
+		}
+		return values, true
+	}
+
+	return nil, false
+}
@@ -0,0 +1,132 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// httpObject returns the object exposed as "http" to
+// scripts. It is created from a function, which is
+// equivalent to http.request, so that it is not
+// contained in the state returned by State.
+func (t *Goja) httpObject() *goja.Object {
+	obj := t.rt.ToValue(t.builtin_http_request).ToObject(t.rt)
+	obj.Set("request", t.builtin_http_request)
+	obj.Set("get", t.builtin_http_get)
+	obj.Set("post", t.builtin_http_post)
+	return obj
+}
+
+func (t *Goja) builtin_http_get(url string, params ...map[string]any) any {
+	p := httpParams(params)
+	p["method"] = http.MethodGet
+	p["url"] = url
+	return t.builtin_http_request(p)
+}
+
+func (t *Goja) builtin_http_post(url string, body any, params ...map[string]any) any {
+	p := httpParams(params)
+	p["method"] = http.MethodPost
+	p["url"] = url
+	p["body"] = body
+	return t.builtin_http_request(p)
+}
+
+func (t *Goja) builtin_http_request(params map[string]any) any {
+	if t.http == nil {
+		panic(t.rt.ToValue("http requests are not available in this context"))
+	}
+
+	req, err := httpRequest(params)
+	if err != nil {
+		panic(t.rt.ToValue(fmt.Sprintf("invalid http request: %s", err.Error())))
+	}
+
+	res, err := t.http(req)
+	if err != nil {
+		panic(t.rt.ToValue(fmt.Sprintf("http request failed: %s", err.Error())))
+	}
+
+	return res
+}
+
+// httpParams returns a copy of the first of the
+// given optional request parameter maps.
+func httpParams(params []map[string]any) map[string]any {
+	p := make(map[string]any)
+	if len(params) != 0 {
+		for k, v := range params[0] {
+			p[k] = v
+		}
+	}
+	return p
+}
+
+// httpRequest builds a HTTPRequest from the given
+// script parameters. Bodies which are neither
+// strings nor byte slices are encoded as JSON.
+func httpRequest(params map[string]any) (req HTTPRequest, err error) {
+	req.Method = http.MethodGet
+	if v, ok := params["method"]; ok && v != nil {
+		method, ok := v.(string)
+		if !ok {
+			return HTTPRequest{}, fmt.Errorf("method must be a string")
+		}
+		req.Method = strings.ToUpper(method)
+	}
+
+	req.URL, _ = params["url"].(string)
+	if req.URL == "" {
+		return HTTPRequest{}, fmt.Errorf("no url specified")
+	}
+
+	req.Header = make(map[string][]string)
+	if v, ok := params["header"]; ok && v != nil {
+		header, ok := v.(map[string]any)
+		if !ok {
+			return HTTPRequest{}, fmt.Errorf("header must be an object")
+		}
+		for k, v := range header {
+			k = http.CanonicalHeaderKey(k)
+			if vals, ok := v.([]any); ok {
+				for _, val := range vals {
+					req.Header[k] = append(req.Header[k], fmt.Sprint(val))
+				}
+				continue
+			}
+			req.Header[k] = append(req.Header[k], fmt.Sprint(v))
+		}
+	}
+
+	switch body := params["body"].(type) {
+	case nil:
+	case string:
+		req.Body = []byte(body)
+	default:
+		if rv := reflect.ValueOf(body); rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			req.Body = rv.Bytes()
+			break
+		}
+		req.Body, err = json.Marshal(body)
+		if err != nil {
+			return HTTPRequest{}, fmt.Errorf("failed encoding body: %s", err.Error())
+		}
+		if len(req.Header["Content-Type"]) == 0 {
+			req.Header["Content-Type"] = []string{"application/json"}
+		}
+	}
+
+	if v, ok := params["options"]; ok && v != nil {
+		req.Options, ok = v.(map[string]any)
+		if !ok {
+			return HTTPRequest{}, fmt.Errorf("options must be an object")
+		}
+	}
+
+	return req, nil
+}
@@ -0,0 +1,335 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dop251/goja"
+	"github.com/studio-b12/goat/pkg/errs"
+)
+
+// importFuncName is the name of the global function
+// import declarations are translated to.
+const importFuncName = "__goat_import"
+
+const identPattern = `[A-Za-z_$][\w$]*`
+
+var (
+	importPattern = regexp.MustCompile(`(?m)^[ \t]*import[ \t]+` +
+		`(?:(` + identPattern + `)[ \t]*,?[ \t]*)?` +
+		`(?:\{([^}]*)\}[ \t]*|\*[ \t]*as[ \t]+(` + identPattern + `)[ \t]*)?` +
+		`(?:from[ \t]*)?["']([^"'\n]+)["'][ \t]*;?`)
+	exportDeclPattern = regexp.MustCompile(`(?m)^([ \t]*)export[ \t]+` +
+		`((?:async[ \t]+)?function[ \t]*\*?|class|const|let|var)[ \t]*(` + identPattern + `)`)
+	exportDefaultPattern = regexp.MustCompile(`(?m)^([ \t]*)export[ \t]+default[ \t]+`)
+	exportListPattern    = regexp.MustCompile(`(?m)^[ \t]*export[ \t]*` +
+		`(?:\{([^}]*)\}|\*)[ \t]*(?:from[ \t]*["']([^"'\n]+)["'])?[ \t]*;?`)
+)
+
+// importSpec describes a single name imported
+// from or exported by a module.
+type importSpec struct {
+	name  string
+	alias string
+}
+
+// Import describes an import declaration or a
+// re-export statement of a script or module.
+type Import struct {
+	// Specifier is the module specifier as
+	// written in the source.
+	Specifier string
+	// Names are the local names bound by the
+	// declaration. Re-export statements do not
+	// bind any names.
+	Names []string
+}
+
+// ParseImports returns the import declarations and
+// re-export statements in the given source together
+// with the source without the import declarations.
+// The returned source keeps the line structure of src.
+func ParseImports(src string) (imports []Import, rest string) {
+	rest, _ = replaceAllStringFunc(importPattern, src, func(sub []string) (string, error) {
+		imp := Import{Specifier: sub[4]}
+		if sub[1] != "" {
+			imp.Names = append(imp.Names, sub[1])
+		}
+		if sub[3] != "" {
+			imp.Names = append(imp.Names, sub[3])
+		}
+		for _, spec := range parseSpecs(sub[2]) {
+			imp.Names = append(imp.Names, spec.alias)
+		}
+		imports = append(imports, imp)
+		return "", nil
+	})
+
+	for _, sub := range exportListPattern.FindAllStringSubmatch(rest, -1) {
+		if sub[2] != "" {
+			imports = append(imports, Import{Specifier: sub[2]})
+		}
+	}
+
+	return imports, rest
+}
+
+// importModule returns the exports of the module at the
+// given absolute path. Modules are only loaded and executed
+// once per runtime.
+func (t *Goja) importModule(pth string) (*goja.Object, error) {
+	if exports, ok := t.modules[pth]; ok {
+		return exports, nil
+	}
+
+	src, err := os.ReadFile(pth)
+	if err != nil {
+		return nil, errs.WithPrefix("failed reading module:", err)
+	}
+
+	exports := t.rt.NewObject()
+
+	if strings.ToLower(filepath.Ext(pth)) == ".json" {
+		var v any
+		if err = json.Unmarshal(src, &v); err != nil {
+			return nil, errs.WithPrefix("failed parsing module:", err)
+		}
+		exports.Set("default", v)
+		t.modules[pth] = exports
+		return exports, nil
+	}
+
+	code, err := transformModule(string(src), filepath.Dir(pth))
+	if err != nil {
+		return nil, err
+	}
+
+	// The wrapper is kept on the first line so that line
+	// numbers in errors match the lines of the module file.
+	prg, err := goja.Compile(pth, `(function(exports) {"use strict";`+code+"\n})", false)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, err := t.rt.RunProgram(prg)
+	if err != nil {
+		return nil, err
+	}
+	call, ok := goja.AssertFunction(fn)
+	if !ok {
+		return nil, fmt.Errorf("invalid module wrapper")
+	}
+
+	// Exports are registered before execution so that
+	// cyclic imports receive the partial exports instead
+	// of recursing endlessly.
+	t.modules[pth] = exports
+	_, err = call(goja.Undefined(), exports)
+	if err != nil {
+		delete(t.modules, pth)
+		return nil, err
+	}
+
+	return exports, nil
+}
+
+// builtin_import implements the function import
+// declarations are translated to.
+func (t *Goja) builtin_import(pth string) *goja.Object {
+	exports, err := t.importModule(pth)
+	if err != nil {
+		if ex, ok := err.(*goja.Exception); ok {
+			panic(ex)
+		}
+		panic(t.rt.NewGoError(fmt.Errorf("failed importing module %s: %s", pth, err.Error())))
+	}
+	return exports
+}
+
+// transformScript translates the import declarations in
+// the given script into global variable declarations
+// importing modules relative to dir.
+func transformScript(src, dir string) (string, error) {
+	return transformImports(src, dir, "var")
+}
+
+// transformModule translates the import declarations and
+// export statements of the given module source into code
+// which can be run in a function with an exports object
+// parameter. Imported modules are resolved relative to dir.
+//
+// All replacements keep the line structure of the source.
+func transformModule(src, dir string) (string, error) {
+	src, err := transformImports(src, dir, "const")
+	if err != nil {
+		return "", err
+	}
+
+	var exported []importSpec
+
+	src = exportDeclPattern.ReplaceAllStringFunc(src, func(m string) string {
+		sub := exportDeclPattern.FindStringSubmatch(m)
+		exported = append(exported, importSpec{name: sub[3], alias: sub[3]})
+		return sub[1] + sub[2] + " " + sub[3]
+	})
+
+	src = exportDefaultPattern.ReplaceAllString(src, "${1}exports.default = ")
+
+	src, err = replaceAllStringFunc(exportListPattern, src, func(sub []string) (string, error) {
+		specs := parseSpecs(sub[1])
+
+		if sub[2] == "" {
+			exported = append(exported, specs...)
+			return "", nil
+		}
+
+		pth, err := resolveModule(sub[2], dir)
+		if err != nil {
+			return "", err
+		}
+		mod := fmt.Sprintf("%s(%s)", importFuncName, strconv.Quote(pth))
+
+		if strings.TrimSpace(sub[1]) == "" && !strings.Contains(sub[0], "{") {
+			return fmt.Sprintf(
+				`(function(m) { for (const k in m) if (k !== "default") exports[k] = m[k]; })(%s);`, mod), nil
+		}
+
+		var sb strings.Builder
+		for _, spec := range specs {
+			fmt.Fprintf(&sb, "exports[%s] = %s[%s]; ",
+				strconv.Quote(spec.alias), mod, strconv.Quote(spec.name))
+		}
+		return sb.String(), nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteString(src)
+	sb.WriteString(";")
+	for _, spec := range exported {
+		fmt.Fprintf(&sb, " exports[%s] = %s;", strconv.Quote(spec.alias), spec.name)
+	}
+
+	return sb.String(), nil
+}
+
+// transformImports replaces all import declarations in src
+// with variable declarations using the given declaration
+// keyword.
+func transformImports(src, dir, decl string) (string, error) {
+	return replaceAllStringFunc(importPattern, src, func(sub []string) (string, error) {
+		defaultName, named, namespace, specifier := sub[1], sub[2], sub[3], sub[4]
+
+		pth, err := resolveModule(specifier, dir)
+		if err != nil {
+			return "", err
+		}
+		mod := fmt.Sprintf("%s(%s)", importFuncName, strconv.Quote(pth))
+
+		var stmts []string
+		if defaultName != "" {
+			stmts = append(stmts, fmt.Sprintf("%s %s = %s.default;", decl, defaultName, mod))
+		}
+		if namespace != "" {
+			stmts = append(stmts, fmt.Sprintf("%s %s = %s;", decl, namespace, mod))
+		}
+		if specs := parseSpecs(named); len(specs) != 0 {
+			fields := make([]string, 0, len(specs))
+			for _, spec := range specs {
+				fields = append(fields, fmt.Sprintf("%s: %s", strconv.Quote(spec.name), spec.alias))
+			}
+			stmts = append(stmts, fmt.Sprintf("%s { %s } = %s;", decl, strings.Join(fields, ", "), mod))
+		}
+		if len(stmts) == 0 {
+			stmts = append(stmts, mod+";")
+		}
+
+		return strings.Join(stmts, " "), nil
+	})
+}
+
+// resolveModule returns the absolute path of the module
+// with the given specifier relative to dir. When the
+// specified file does not exist, the ".js" extension is
+// tried.
+func resolveModule(specifier, dir string) (string, error) {
+	if !strings.HasPrefix(specifier, "./") && !strings.HasPrefix(specifier, "../") &&
+		!filepath.IsAbs(specifier) {
+		return "", fmt.Errorf("invalid module specifier '%s' (relative or absolute path expected)", specifier)
+	}
+
+	pth := specifier
+	if !filepath.IsAbs(pth) {
+		pth = filepath.Join(dir, pth)
+	}
+
+	pth, err := filepath.Abs(pth)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err = os.Stat(pth); os.IsNotExist(err) && filepath.Ext(pth) == "" {
+		pth += ".js"
+	}
+
+	return pth, nil
+}
+
+// parseSpecs parses a comma separated list of
+// names with optional aliases (`name as alias`).
+func parseSpecs(list string) []importSpec {
+	var specs []importSpec
+	for _, part := range strings.Split(list, ",") {
+		fields := strings.Fields(part)
+		switch {
+		case len(fields) == 1:
+			specs = append(specs, importSpec{name: fields[0], alias: fields[0]})
+		case len(fields) == 3 && fields[1] == "as":
+			specs = append(specs, importSpec{name: fields[0], alias: fields[2]})
+		}
+	}
+	return specs
+}
+
+// replaceAllStringFunc replaces all matches of rx in src
+// with the result of repl called with the submatches of
+// the match. Line breaks in replaced matches are kept.
+func replaceAllStringFunc(
+	rx *regexp.Regexp,
+	src string,
+	repl func(sub []string) (string, error),
+) (string, error) {
+	var (
+		sb   strings.Builder
+		last int
+	)
+
+	for _, idx := range rx.FindAllStringSubmatchIndex(src, -1) {
+		sub := make([]string, len(idx)/2)
+		for i := range sub {
+			if idx[2*i] >= 0 {
+				sub[i] = src[idx[2*i]:idx[2*i+1]]
+			}
+		}
+
+		r, err := repl(sub)
+		if err != nil {
+			return "", err
+		}
+
+		sb.WriteString(src[last:idx[0]])
+		sb.WriteString(r)
+		sb.WriteString(strings.Repeat("\n", strings.Count(sub[0], "\n")))
+		last = idx[1]
+	}
+
+	sb.WriteString(src[last:])
+	return sb.String(), nil
+}
@@ -17,6 +17,38 @@ func (t State) Merge(with State) {
 	}
 }
 
+// Copy returns a deep copy of the state. Nested
+// maps and slices are copied as well, so that the
+// returned state can be modified without affecting
+// the original state.
+func (t State) Copy() State {
+	if t == nil {
+		return nil
+	}
+	return copyValue(map[string]any(t)).(map[string]any)
+}
+
 func (t State) String() string {
 	return util.SafeJsonMarshalIndent(t)
 }
+
+func copyValue(v any) any {
+	switch vt := v.(type) {
+	case State:
+		return vt.Copy()
+	case map[string]any:
+		m := make(map[string]any, len(vt))
+		for k, v := range vt {
+			m[k] = copyValue(v)
+		}
+		return m
+	case []any:
+		arr := make([]any, len(vt))
+		for i, v := range vt {
+			arr[i] = copyValue(v)
+		}
+		return arr
+	default:
+		return v
+	}
+}
@@ -0,0 +1,167 @@
+package executor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/studio-b12/goat/pkg/errs"
+	"github.com/studio-b12/goat/pkg/requester"
+	"github.com/studio-b12/goat/pkg/signing"
+)
+
+// authCache holds the OAuth2 access tokens and digest
+// challenges obtained during an execution.
+type authCache struct {
+	mtx     sync.Mutex
+	tokens  map[oauth2Config]*oauth2Token
+	fetches map[oauth2Config]*tokenFetch
+	digests map[digestKey]*digestSession
+}
+
+func newAuthCache() *authCache {
+	return &authCache{
+		tokens:  make(map[oauth2Config]*oauth2Token),
+		fetches: make(map[oauth2Config]*tokenFetch),
+		digests: make(map[digestKey]*digestSession),
+	}
+}
+
+// digestKey identifies the digest challenge
+// received for a host and user.
+type digestKey struct {
+	host     string
+	username string
+}
+
+// digestSession holds a received digest challenge
+// and the number of times its nonce has been used.
+type digestSession struct {
+	challenge signing.DigestChallenge
+	nc        int
+}
+
+// authorize sets the Authorization header of the given
+// request according to the given auth options or signs
+// the request. When renew is true, cached credentials
+// are renewed.
+//
+// For digest authentication, the header is only set when a
+// challenge has been received from the host before.
+func (t *Executor) authorize(req *http.Request, opt AuthOptions, reqOpts requester.Options, renew bool) error {
+	switch strings.ToLower(opt.Type) {
+
+	case AuthTypeOAuth2:
+		authorization, err := t.oauth2Authorization(opt, reqOpts, renew)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", authorization)
+
+	case AuthTypeSigV4:
+		body, err := bufferBody(req)
+		if err != nil {
+			return err
+		}
+		return signing.SigV4(req, body, signing.SigV4Credentials{
+			Region:       opt.Region,
+			Service:      opt.Service,
+			AccessKey:    opt.AccessKey,
+			SecretKey:    opt.SecretKey,
+			SessionToken: opt.SessionToken,
+		}, time.Now())
+
+	case AuthTypeHMAC:
+		body, err := bufferBody(req)
+		if err != nil {
+			return err
+		}
+		return signing.HMAC(req, body, signing.HMACOptions{
+			Secret:    opt.Secret,
+			Algorithm: opt.Algorithm,
+			Header:    opt.Header,
+			Template:  opt.Template,
+			Encoding:  opt.Encoding,
+			Prefix:    opt.Prefix,
+		})
+
+	case AuthTypeDigest:
+		body, err := bufferBody(req)
+		if err != nil {
+			return err
+		}
+
+		t.auth.mtx.Lock()
+		defer t.auth.mtx.Unlock()
+
+		session, ok := t.auth.digests[digestKey{host: req.URL.Host, username: opt.UserName}]
+		if !ok {
+			return nil
+		}
+		session.nc++
+
+		authorization, err := signing.Digest(req, body, session.challenge, opt.UserName, opt.Password, session.nc)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", authorization)
+
+	default:
+		req.Header.Set("Authorization", opt.HeaderValue())
+	}
+
+	return nil
+}
+
+// challenge returns true when the given request, which has
+// been answered with 401 Unauthorized, should be repeated
+// with renewed credentials. For digest authentication, the
+// received challenge is stored for subsequent requests.
+func (t *Executor) challenge(req *http.Request, resp *http.Response, opt AuthOptions) bool {
+	switch strings.ToLower(opt.Type) {
+
+	case AuthTypeOAuth2:
+		return true
+
+	case AuthTypeDigest:
+		c, ok := signing.ParseDigestChallenge(resp.Header.Values("WWW-Authenticate"))
+		if !ok {
+			return false
+		}
+
+		t.auth.mtx.Lock()
+		defer t.auth.mtx.Unlock()
+
+		t.auth.digests[digestKey{host: req.URL.Host, username: opt.UserName}] = &digestSession{challenge: c}
+		return true
+
+	default:
+		return false
+	}
+}
+
+// bufferBody reads the body of the given request and
+// replaces it with a buffered copy, so that the body
+// can be signed and sent afterwards.
+func bufferBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, errs.WithPrefix("failed reading request body:", err)
+	}
+	req.Body.Close()
+
+	req.ContentLength = int64(len(body))
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+
+	return body, nil
+}
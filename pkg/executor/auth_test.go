@@ -0,0 +1,123 @@
+package executor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-b12/goat/pkg/requester"
+)
+
+func TestExecuteSignedAuth(t *testing.T) {
+	t.Run("sigv4", func(t *testing.T) {
+		_, err := executeGoatfile(t, `
+POST http://localhost/api?b=1&a=2
+
+[Auth]
+type = "sigv4"
+region = "eu-central-1"
+service = "execute-api"
+accesskey = "AKID"
+secretkey = "secret"
+sessiontoken = "session"
+
+[Body]
+{"name": "foo"}
+
+[Script]
+assert_eq(response.Body.body, '{"name": "foo"}\n');
+assert_match(response.Body.amzDate, "^\\d{8}T\\d{6}Z$");
+assert_match(response.Body.auth, "^AWS4-HMAC-SHA256 Credential=AKID/\\d{8}/eu-central-1/execute-api/aws4_request, " +
+	"SignedHeaders=host;x-amz-date;x-amz-security-token, Signature=[0-9a-f]{64}$");
+`, echoRequester{})
+		require.Nil(t, err, err)
+	})
+
+	t.Run("hmac", func(t *testing.T) {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte("POST /api {\"name\": \"foo\"}\n"))
+		signature := hex.EncodeToString(mac.Sum(nil))
+
+		_, err := executeGoatfile(t, fmt.Sprintf(`
+POST http://localhost/api
+
+[Auth]
+type = "hmac"
+secret = "secret"
+template = "{method} {path} {body}"
+prefix = "HMAC "
+
+[Body]
+{"name": "foo"}
+
+[Script]
+assert_eq(response.Body.auth, "HMAC %s");
+assert_eq(response.Body.body, '{"name": "foo"}\n');
+`, signature), echoRequester{})
+		require.Nil(t, err, err)
+	})
+
+	t.Run("hmac-invalid", func(t *testing.T) {
+		_, err := executeGoatfile(t, `
+GET http://localhost/api
+
+[Auth]
+type = "hmac"
+`, echoRequester{})
+		require.NotNil(t, err)
+		assert.ErrorContains(t, err, "authorization failed: secret must be specified")
+	})
+
+	t.Run("digest", func(t *testing.T) {
+		var (
+			mtx        sync.Mutex
+			challenges int
+			authorized []string
+		)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mtx.Lock()
+			defer mtx.Unlock()
+
+			auth := r.Header.Get("Authorization")
+			if !strings.HasPrefix(auth, `Digest username="foo", realm="goat", uri="/api"`) {
+				challenges++
+				w.Header().Add("WWW-Authenticate", `Basic realm="goat"`)
+				w.Header().Add("WWW-Authenticate",
+					`Digest realm="goat", qop="auth", algorithm=SHA-256, nonce="abc", opaque="xyz"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			authorized = append(authorized, auth)
+		}))
+		t.Cleanup(server.Close)
+
+		request := fmt.Sprintf(`GET %s/api
+
+[Auth]
+type = "digest"
+username = "foo"
+password = "bar"
+
+[Script]
+assert_eq(response.StatusCode, 200);`, server.URL)
+
+		_, err := executeGoatfile(t, request+"\n\n---\n\n"+request,
+			requester.NewHttpWithCookies(func(client *http.Client) {}))
+		require.Nil(t, err, err)
+
+		assert.Equal(t, 1, challenges)
+		require.Len(t, authorized, 2)
+		assert.Contains(t, authorized[0], "nc=00000001")
+		assert.Contains(t, authorized[1], "nc=00000002")
+		assert.Contains(t, authorized[1], `opaque="xyz"`)
+	})
+}
@@ -1,8 +1,10 @@
 package executor
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net"
 	"path/filepath"
 
 	"github.com/studio-b12/goat/pkg/errs"
@@ -11,6 +13,10 @@ import (
 
 var (
 	ErrCanceled = errors.New("canceled")
+
+	// errRequestFailed is the error of requests
+	// marked as failed via ResultSection.IncFailed.
+	errRequestFailed = errors.New("request failed")
 )
 
 type BatchExecutionError struct {
@@ -111,3 +117,27 @@ func NewNoAbortError(err error) error {
 		},
 	}
 }
+
+// TimeoutError wraps an error occurred because a
+// request has not been completed within its timeout.
+type TimeoutError struct {
+	errs.InnerError
+}
+
+func NewTimeoutError(err error) error {
+	return TimeoutError{
+		InnerError: errs.InnerError{
+			Inner: err,
+		},
+	}
+}
+
+// isTimeout returns true when err has been
+// caused by an exceeded timeout.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
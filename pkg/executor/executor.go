@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
@@ -17,6 +19,7 @@ import (
 	"github.com/studio-b12/goat/pkg/errs"
 	"github.com/studio-b12/goat/pkg/goatfile"
 	"github.com/studio-b12/goat/pkg/requester"
+	"github.com/studio-b12/goat/pkg/schema"
 	"github.com/studio-b12/goat/pkg/util"
 	"github.com/zekrotja/rogu"
 	"github.com/zekrotja/rogu/log"
@@ -28,11 +31,25 @@ type Executor struct {
 	req         requester.Requester
 
 	ctx context.Context
+	log rogu.Logger
 
-	Dry     bool
-	NoAbort bool
-	Skip    []string
-	Waiter  advancer.Waiter
+	// cookieNamespace is passed to the requester to separate
+	// the cookie jars of batches executed in parallel.
+	cookieNamespace string
+
+	// auth caches the OAuth2 access tokens and digest
+	// challenges shared by all batches.
+	auth *authCache
+
+	// schemas caches the schema documents used for
+	// response validation shared by all batches.
+	schemas *schema.Cache
+
+	Dry      bool
+	NoAbort  bool
+	Parallel int
+	Skip     []string
+	Waiter   advancer.Waiter
 }
 
 // New initializes a new instance of Executor using
@@ -49,8 +66,11 @@ func New(ctx context.Context, engineMaker func() engine.Engine, req requester.Re
 	var t Executor
 
 	t.ctx = ctx
+	t.log = log.Copy()
 	t.engineMaker = engineMaker
 	t.req = req
+	t.auth = newAuthCache()
+	t.schemas = new(schema.Cache)
 	t.Waiter = advancer.None{}
 
 	return &t
@@ -72,7 +92,7 @@ func (t *Executor) Execute(pathes []string, initialParams engine.State, showTear
 				return Result{}, err
 			}
 
-			log.Debug().Msg("Executing goatfile ...")
+			t.log.Debug().Msg("Executing goatfile ...")
 			return t.ExecuteGoatfile(gf, initialParams, showTeardownParamErrors)
 		}
 	}
@@ -83,7 +103,7 @@ func (t *Executor) Execute(pathes []string, initialParams engine.State, showTear
 // ExecuteGoatfile runs the given parsed Goatfile. The given initialParams are
 // used as initial state for the runtime engine.
 func (t *Executor) ExecuteGoatfile(gf goatfile.Goatfile, initialParams engine.State, showTeardownParamErrors bool) (res Result, err error) {
-	log := log.Tagged(strings.TrimSuffix(gf.Path, ".goat"))
+	log := t.log.Tagged(strings.TrimSuffix(gf.Path, ".goat"))
 
 	if t.Dry {
 		log.Warn().Msg("This is a dry run: no requets will be executed")
@@ -92,9 +112,22 @@ func (t *Executor) ExecuteGoatfile(gf goatfile.Goatfile, initialParams engine.St
 	}
 
 	eng := t.engineMaker()
+	eng.SetLogger(t.log)
 	eng.SetState(initialParams)
 
-	return t.executeGoatfile(log, gf, eng, true, showTeardownParamErrors)
+	start := time.Now()
+	res, err = t.executeGoatfile(log, gf, eng, true, showTeardownParamErrors)
+	res.Batches = append(res.Batches, BatchResult{
+		Path:     gf.Path,
+		Start:    start,
+		Duration: time.Since(start),
+		Canceled: t.ctx.Err() != nil,
+		Setup:    res.Setup,
+		Teardown: res.Teardown,
+		Tests:    res.Tests,
+	})
+
+	return res, err
 }
 
 func (t *Executor) executeGoatfile(
@@ -115,10 +148,10 @@ func (t *Executor) executeGoatfile(
 		}
 
 		if len(gf.Teardown) > 0 && printSeperators {
-			printSeparator("TEARDOWN")
+			printSeparator(log, "TEARDOWN")
 		}
 		for _, act := range gf.Teardown {
-			sectRes, exErr := t.executeAction(log, eng, act, gf, showTeardownParamErrors)
+			sectRes, exErr := t.executeAction(log, eng, act, gf, goatfile.SectionTeardown, showTeardownParamErrors)
 			res.Teardown.Merge(sectRes)
 			if exErr != nil {
 				err = errs.Join(err, NewTeardownError(exErr))
@@ -126,7 +159,7 @@ func (t *Executor) executeGoatfile(
 					isParamsParseErr := errs.IsOfType[ParamsParsingError](exErr)
 
 					if !isParamsParseErr || showTeardownParamErrors {
-						log.Error().Err(exErr).Field("req", act).Msg("Teardown step failed")
+						log.Error().Err(exErr).Field("req", act).Fields(sourceFields(exErr)...).Msg("Teardown step failed")
 					}
 
 					// If the returned error comes from the params parsing step, don't
@@ -140,7 +173,7 @@ func (t *Executor) executeGoatfile(
 						continue
 					}
 				} else {
-					log.Error().Err(exErr).Field("act", act).Msg("Action failed")
+					log.Error().Err(exErr).Field("act", act).Fields(sourceFields(exErr)...).Msg("Action failed")
 				}
 
 				break
@@ -158,18 +191,18 @@ func (t *Executor) executeGoatfile(
 		log.Warn().Msg("skipping setup steps")
 	} else {
 		if len(gf.Setup) > 0 && printSeperators {
-			printSeparator("SETUP")
+			printSeparator(log, "SETUP")
 		}
 		for _, act := range gf.Setup {
 			select {
 			case <-t.ctx.Done():
 				return res, ErrCanceled
 			default:
-				sectRes, err := t.executeAction(log, eng, act, gf, showTeardownParamErrors)
+				sectRes, err := t.executeAction(log, eng, act, gf, goatfile.SectionSetup, showTeardownParamErrors)
 				res.Setup.Merge(sectRes)
 				if err != nil {
 					if act.Type() == goatfile.ActionRequest {
-						log.Error().Err(err).Field("req", act).Msg("Setup step failed")
+						log.Error().Err(err).Field("req", act).Fields(sourceFields(err)...).Msg("Setup step failed")
 						if errs.IsOfType[NoAbortError](err) {
 							errsNoAbort = errsNoAbort.Append(errors.Unwrap(err))
 							continue
@@ -191,7 +224,7 @@ func (t *Executor) executeGoatfile(
 		log.Warn().Msg("skipping test steps")
 	} else {
 		if len(gf.Tests) > 0 && printSeperators {
-			printSeparator("TESTS")
+			printSeparator(log, "TESTS")
 		}
 		for _, act := range gf.Tests {
 			select {
@@ -250,34 +283,28 @@ func (t *Executor) executeFromPathes(pathes []string, initialParams engine.State
 		return Result{}, errors.New("no Goatfiles found to execute")
 	}
 
-	var mErr errs.Errors
-
-	for _, gf := range goatfiles {
-		log.Info().Field("path", gf.Path).Msg(clr.Print(clr.Format("Executing batch ...", clr.ColorFGPurple, clr.FormatBold)))
-
-		res, err := t.ExecuteGoatfile(gf, initialParams, showTeardownParamErrors)
-		finalRes.Merge(res)
-		if err != nil {
-			entry := log.Error()
-			if mErr, ok := err.(errs.Errors); ok {
-				errLines := make([]string, 0, len(mErr))
-				for _, e := range mErr {
-					if !showTeardownParamErrors && errs.IsOfType[TeardownError](e) && errs.IsOfType[ParamsParsingError](err) {
-						continue
-					}
-					errLines = append(errLines, clr.Print(clr.Format(e.Error(), clr.ColorFGRed)))
-				}
-				entry.Field("errors", errLines)
-			} else {
-				entry.Err(err)
+	var results []batchOutcome
+	if t.Parallel > 1 && len(goatfiles) > 1 {
+		results = t.executeParallel(goatfiles, initialParams, showTeardownParamErrors)
+	} else {
+		results = make([]batchOutcome, 0, len(goatfiles))
+		for _, gf := range goatfiles {
+			if t.ctx.Err() != nil {
+				results = append(results, canceledBatch(gf))
+				continue
 			}
-			entry.Msg(clr.Print(clr.Format("Batch execution failed", clr.ColorFGRed, clr.FormatBold)))
-
-			mErr = mErr.Append(wrapBatchExecutionError(err, gf.Path))
-			continue
+			res, err := t.executeBatch(gf, initialParams, showTeardownParamErrors)
+			results = append(results, batchOutcome{res: res, err: err})
 		}
+	}
+
+	var mErr errs.Errors
 
-		log.Info().Field("path", gf.Path).Msg(clr.Print(clr.Format("Batch finished successfully", clr.ColorFGPurple, clr.FormatBold)))
+	for i, r := range results {
+		finalRes.Merge(r.res)
+		if r.err != nil {
+			mErr = mErr.Append(wrapBatchExecutionError(r.err, goatfiles[i].Path))
+		}
 	}
 
 	if mErr.HasSome() {
@@ -291,8 +318,40 @@ func (t *Executor) executeFromPathes(pathes []string, initialParams engine.State
 	return finalRes, nil
 }
 
+// executeBatch executes the given Goatfile as batch
+// and logs the outcome of the execution.
+func (t *Executor) executeBatch(
+	gf goatfile.Goatfile,
+	initialParams engine.State,
+	showTeardownParamErrors bool,
+) (Result, error) {
+	t.log.Info().Field("path", gf.Path).Msg(clr.Print(clr.Format("Executing batch ...", clr.ColorFGPurple, clr.FormatBold)))
+
+	res, err := t.ExecuteGoatfile(gf, initialParams, showTeardownParamErrors)
+	if err != nil {
+		entry := t.log.Error()
+		if mErr, ok := err.(errs.Errors); ok {
+			errLines := make([]string, 0, len(mErr))
+			for _, e := range mErr {
+				if !showTeardownParamErrors && errs.IsOfType[TeardownError](e) && errs.IsOfType[ParamsParsingError](err) {
+					continue
+				}
+				errLines = append(errLines, clr.Print(clr.Format(e.Error(), clr.ColorFGRed)))
+			}
+			entry.Field("errors", errLines)
+		} else {
+			entry.Err(err)
+		}
+		entry.Msg(clr.Print(clr.Format("Batch execution failed", clr.ColorFGRed, clr.FormatBold)))
+		return res, err
+	}
+
+	t.log.Info().Field("path", gf.Path).Msg(clr.Print(clr.Format("Batch finished successfully", clr.ColorFGPurple, clr.FormatBold)))
+	return res, nil
+}
+
 func (t *Executor) parseGoatfile(path string) (gf goatfile.Goatfile, err error) {
-	log.Debug().Field("from", path).Msg("Parsing goatfile ...")
+	t.log.Debug().Field("from", path).Msg("Parsing goatfile ...")
 
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -319,12 +378,12 @@ func (t *Executor) executeTest(
 	showTeardownParamErrors bool,
 ) (res ResultSection, err error) {
 	var errsNoAbort errs.Errors
-	log := log.Tagged(strings.TrimSuffix(gf.Path, ".goat"))
+	log := t.log.Tagged(strings.TrimSuffix(gf.Path, ".goat"))
 
-	res, err = t.executeAction(log, eng, act, gf, showTeardownParamErrors)
+	res, err = t.executeAction(log, eng, act, gf, goatfile.SectionTests, showTeardownParamErrors)
 	if err != nil {
 		if act.Type() == goatfile.ActionRequest {
-			log.Error().Err(err).Field("req", act).Msg("Test step failed")
+			log.Error().Err(err).Field("req", act).Fields(sourceFields(err)...).Msg("Test step failed")
 
 			if !errs.IsOfType[NoAbortError](err) {
 				return res, err
@@ -348,35 +407,78 @@ func (t *Executor) executeAction(
 	eng engine.Engine,
 	act goatfile.Action,
 	gf goatfile.Goatfile,
+	section goatfile.SectionName,
 	showTeardownParamErrors bool,
 ) (res ResultSection, err error) {
 	log.Trace().Field("act", act).Msg("Executing action")
 
+	start := time.Now()
+
 	switch act.Type() {
 
 	case goatfile.ActionRequest:
-		res.Inc()
 		req := act.(*goatfile.Request)
 		log.Trace().Fields("options", req.Options).Msg("Request Options")
-		err = t.executeRequest(eng, req, gf)
+
+		if _, ok := req.Options[foreachOptionName]; !ok {
+			rec, err := t.executeRequestAction(eng, req, gf, section, -1)
+			res.Add(rec)
+			return res, err
+		}
+
+		items, err := foreachItems(req.Copy().Options[foreachOptionName], eng.State(), req.Path)
 		if err != nil {
-			res.IncFailed()
 			err = errs.WithSuffix(err, fmt.Sprintf("(%s:%d)", req.Path, req.PosLine))
+			res.Add(ActionResult{
+				Type:    goatfile.ActionRequest,
+				Name:    req.String(),
+				Section: section,
+				Path:    req.Path,
+				Line:    req.PosLine,
+				Method:  req.Method,
+				URI:     req.URI,
+				Start:   start,
+				Err:     err,
+			})
+			return res, err
 		}
-		return res, err
+
+		return t.executeForeach(eng, items, func(i int) (ActionResult, error) {
+			return t.executeRequestAction(eng, req.Copy(), gf, section, i)
+		})
 
 	case goatfile.ActionLogSection:
 		logSection := act.(goatfile.LogSection)
-		printSeparator(string(logSection))
+		printSeparator(log, string(logSection))
 		return res, nil
 
 	case goatfile.ActionExecute:
 		execParams := act.(goatfile.Execute)
-		r, err := t.executeExecute(execParams, eng, showTeardownParamErrors)
+
+		if _, ok := execParams.Params[foreachOptionName]; !ok {
+			rec, err := t.executeExecuteAction(eng, execParams, section, -1, showTeardownParamErrors)
+			res.Add(rec)
+			return res, err
+		}
+
+		items, err := foreachItems(execParams.Copy().Params[foreachOptionName], eng.State(), execParams.Path)
 		if err != nil {
-			err = errs.WithSuffix(err, "(imported)")
+			err = errs.WithSuffix(err, fmt.Sprintf("(%s:%d)", execParams.Path, execParams.PosLine))
+			res.Add(ActionResult{
+				Type:    goatfile.ActionExecute,
+				Name:    execParams.String(),
+				Section: section,
+				Path:    execParams.Path,
+				Line:    execParams.PosLine,
+				Start:   start,
+				Err:     err,
+			})
+			return res, err
 		}
-		return r.Sum(), err
+
+		return t.executeForeach(eng, items, func(i int) (ActionResult, error) {
+			return t.executeExecuteAction(eng, execParams.Copy(), section, i, showTeardownParamErrors)
+		})
 
 	default:
 		panic(fmt.Sprintf("An invalid action has been executed: %v\n"+
@@ -386,7 +488,98 @@ func (t *Executor) executeAction(
 	}
 }
 
-func (t *Executor) executeRequest(eng engine.Engine, req *goatfile.Request, gf goatfile.Goatfile) (err error) {
+// executeRequestAction executes the given request and returns
+// its result. When iteration is not negative, the request is
+// executed as the iteration with the given index of a foreach
+// option.
+func (t *Executor) executeRequestAction(
+	eng engine.Engine,
+	req *goatfile.Request,
+	gf goatfile.Goatfile,
+	section goatfile.SectionName,
+	iteration int,
+) (ActionResult, error) {
+	start := time.Now()
+
+	rec := ActionResult{
+		Type:    goatfile.ActionRequest,
+		Name:    req.String(),
+		Section: section,
+		Path:    req.Path,
+		Line:    req.PosLine,
+		Method:  req.Method,
+		URI:     req.URI,
+		Start:   start,
+	}
+
+	pos := fmt.Sprintf("%s:%d", req.Path, req.PosLine)
+	if iteration >= 0 {
+		delete(req.Options, foreachOptionName)
+		rec.Name = fmt.Sprintf("%s [%d]", rec.Name, iteration)
+		pos = fmt.Sprintf("%s, iteration %d", pos, iteration)
+	}
+
+	err := t.executeRequest(eng, req, gf, &rec)
+	if err != nil {
+		err = errs.WithSuffix(err, fmt.Sprintf("(%s)", pos))
+	}
+	rec.Duration = time.Since(start)
+	rec.Err = err
+
+	return rec, err
+}
+
+// executeExecuteAction executes the given execute statement
+// and returns its result. When iteration is not negative, the
+// statement is executed as the iteration with the given index
+// of a foreach parameter.
+func (t *Executor) executeExecuteAction(
+	eng engine.Engine,
+	execParams goatfile.Execute,
+	section goatfile.SectionName,
+	iteration int,
+	showTeardownParamErrors bool,
+) (ActionResult, error) {
+	start := time.Now()
+
+	rec := ActionResult{
+		Type:    goatfile.ActionExecute,
+		Name:    execParams.String(),
+		Section: section,
+		Path:    execParams.Path,
+		Line:    execParams.PosLine,
+		Start:   start,
+	}
+
+	suffix := "(imported)"
+	if iteration >= 0 {
+		delete(execParams.Params, foreachOptionName)
+		rec.Name = fmt.Sprintf("%s [%d]", execParams.String(), iteration)
+		suffix = fmt.Sprintf("(imported, iteration %d)", iteration)
+	}
+
+	r, err := t.executeExecute(execParams, eng, showTeardownParamErrors)
+	if err != nil {
+		err = errs.WithSuffix(err, suffix)
+	}
+	rec.Duration = time.Since(start)
+	rec.Err = err
+	rec.Children = r.Sum().Actions
+	rec.Checks = r.Sum().Checks()
+	rec.ScriptRequests = r.Sum().ScriptRequests()
+
+	return rec, err
+}
+
+// executeRequest executes the given request and
+// records the resolved URI, the response status code
+// and whether the request has been skipped into rec.
+func (t *Executor) executeRequest(
+	eng engine.Engine,
+	req *goatfile.Request,
+	gf goatfile.Goatfile,
+	rec *ActionResult,
+) (err error) {
 	req.Merge(gf.Defaults)
 
 	if !t.isAbortOnError(req) {
@@ -398,6 +591,9 @@ func (t *Executor) executeRequest(eng engine.Engine, req *goatfile.Request, gf g
 	}
 
 	state := eng.State()
+	eng.SetDir(path.Dir(req.Path))
+	eng.SetHTTPHandler(t.scriptHTTPHandler(req, rec))
+	defer eng.SetHTTPHandler(nil)
 
 	err = req.PreSubstituteWithParams(state)
 	if err != nil {
@@ -410,7 +606,8 @@ func (t *Executor) executeRequest(eng engine.Engine, req *goatfile.Request, gf g
 	}
 
 	if preScript != "" {
-		err = eng.Run(preScript)
+		err = eng.Run(preScript, req.PreScriptOrigin)
+		rec.Checks = eng.Checks()
 		if err != nil {
 			return errs.WithPrefix("preScript failed:", err)
 		}
@@ -423,14 +620,20 @@ func (t *Executor) executeRequest(eng engine.Engine, req *goatfile.Request, gf g
 			NewParamsParsingError(err))
 	}
 
-	execOpts := ExecOptionsFromMap(req.Options)
+	rec.URI = req.URI
+
+	execOpts, err := ExecOptionsFromMap(req.Options)
+	if err != nil {
+		return errs.WithPrefix("failed parsing options:", err)
+	}
 	if !execOpts.Condition {
-		log.Warn().Field("req", req).Msg("Skipped due to condition")
+		t.log.Warn().Field("req", req).Msg("Skipped due to condition")
+		rec.Skipped = true
 		return nil
 	}
 
 	if execOpts.Delay > 0 {
-		log.Info().
+		t.log.Info().
 			Field("req", req).
 			Field("delay", execOpts.Delay).
 			Msg(clr.Print(clr.Format("Awaiting delay ...", clr.ColorFGBlack)))
@@ -451,38 +654,236 @@ func (t *Executor) executeRequest(eng engine.Engine, req *goatfile.Request, gf g
 			NewParamsParsingError(err))
 	}
 
-	httpReq, err := req.ToHttpRequest()
-	if err != nil {
-		return errs.WithPrefix("failed transforming to http request:", err)
-	}
+	attempts := execOpts.Retry + 1
+	preChecks := rec.Checks
+	for attempt := 1; ; attempt++ {
+		rec.Checks = preChecks
+		var retryable bool
+		retryable, err = t.executeAttempt(eng, req, state, rec, execOpts, attempt < attempts)
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= attempts {
+			if attempt > 1 {
+				err = errs.WithPrefix(fmt.Sprintf("failed after %d attempts:", attempt), err)
+			}
+			return err
+		}
 
-	if authOpts, ok := AuthOptionsFromMap(req.Auth); ok {
-		httpReq.Header.Set("Authorization", authOpts.HeaderValue())
+		delay := execOpts.RetryDelayAt(attempt)
+		t.log.Warn().
+			Err(err).
+			Field("req", req).
+			Field("attempt", fmt.Sprintf("%d/%d", attempt, attempts)).
+			Field("delay", delay).
+			Msg("Request attempt failed, retrying ...")
+
+		select {
+		case <-t.contextFor(rec.Section).Done():
+			return errs.WithPrefix(fmt.Sprintf("canceled after %d attempts:", attempt), err)
+		case <-time.After(delay):
+		}
 	}
+}
 
-	reqOpts := requester.OptionsFromMap(req.Options)
-	httpResp, err := t.req.Do(httpReq, reqOpts)
+// executeAttempt sends the given request, validates the
+// response body against the specified schemas and runs its
+// script against the response. It returns whether the attempt
+// may be retried on failure according to the given options.
+//
+// When retryStatus is true, a response with a status code
+// contained in the retryon option fails the attempt without
+// running the validation and the script.
+func (t *Executor) executeAttempt(
+	eng engine.Engine,
+	req *goatfile.Request,
+	state engine.State,
+	rec *ActionResult,
+	execOpts ExecOptions,
+	retryStatus bool,
+) (retryable bool, err error) {
+	httpReq, resp, err := t.poll(eng, req, rec, execOpts)
 	if err != nil {
-		return errs.WithPrefix("http request failed:", err)
+		return true, err
 	}
 
-	resp, err := FromHttpResponse(httpResp, req.Options)
-	if err != nil {
-		return errs.WithPrefix("response interpretation failed:", err)
+	retryOn := execOpts.RetryOn
+	if retryStatus && slices.Contains(retryOn.StatusCodes, resp.StatusCode) {
+		return true, fmt.Errorf("response status code %d", resp.StatusCode)
 	}
 
 	state.Merge(engine.State{"response": resp})
 	eng.SetState(state)
 
+	err = t.validateResponse(req, httpReq, resp, execOpts)
+	if err != nil {
+		return !retryOn.IsSet() || retryOn.Script, err
+	}
+
 	script, err := util.ReadReaderToString(req.Script.Reader())
 	if err != nil {
-		return errs.WithPrefix("reading script failed:", err)
+		return false, errs.WithPrefix("reading script failed:", err)
 	}
 
 	if script != "" {
-		err = eng.Run(script)
+		eng.SetSoftAssertions(execOpts.SoftAssert)
+		err = eng.Run(script, req.ScriptOrigin)
+		eng.SetSoftAssertions(false)
+		rec.Checks = rec.Checks.Add(eng.Checks())
 		if err != nil {
-			return errs.WithPrefix("script failed:", err)
+			return !retryOn.IsSet() || retryOn.Script, errs.WithPrefix("script failed:", err)
+		}
+	}
+
+	return false, nil
+}
+
+// send sends the given request and returns the sent
+// HTTP request and the interpreted response.
+func (t *Executor) send(req *goatfile.Request, rec *ActionResult) (*http.Request, Response, error) {
+	authOpts, hasAuth := AuthOptionsFromMap(req.Auth)
+	src := requester.Source{Path: rec.Path, Line: rec.Line, Section: string(rec.Section)}
+
+	reqOpts, err := t.requesterOptions(req.Options, src)
+	if err != nil {
+		return nil, Response{}, errs.WithPrefix("failed parsing options:", err)
+	}
+
+	for renew := false; ; renew = true {
+		httpReq, err := req.ToHttpRequest()
+		if err != nil {
+			return nil, Response{}, errs.WithPrefix("failed transforming to http request:", err)
+		}
+
+		if hasAuth {
+			err = t.authorize(httpReq, authOpts, reqOpts, renew)
+			if err != nil {
+				return nil, Response{}, errs.WithPrefix("authorization failed:", err)
+			}
+		}
+
+		httpResp, err := t.req.Do(httpReq, reqOpts)
+		if err != nil {
+			if isTimeout(err) {
+				err = NewTimeoutError(err)
+			}
+			return nil, Response{}, errs.WithPrefix("http request failed:", err)
+		}
+
+		// Access tokens might be revoked before they expire and
+		// digest authentication requires a challenge, so the
+		// request is repeated once after being unauthorized.
+		if !renew && httpResp.StatusCode == http.StatusUnauthorized && t.challenge(httpReq, httpResp, authOpts) {
+			httpResp.Body.Close()
+			t.log.Debug().Field("req", req).Msg("Request unauthorized, repeating with new credentials ...")
+			continue
+		}
+
+		rec.StatusCode = httpResp.StatusCode
+
+		resp, err := FromHttpResponse(httpResp, req.Options)
+		if err != nil {
+			if isTimeout(err) {
+				err = NewTimeoutError(err)
+			}
+			return nil, Response{}, errs.WithPrefix("response interpretation failed:", err)
+		}
+
+		return httpReq, resp, nil
+	}
+}
+
+// requesterOptions returns the requester options built from
+// the given request options for a request at the given source.
+// Relative certificate paths are resolved against the directory
+// of the Goatfile containing the request.
+func (t *Executor) requesterOptions(options map[string]any, src requester.Source) (requester.Options, error) {
+	reqOpts, err := requester.OptionsFromMap(options)
+	if err != nil {
+		return requester.Options{}, err
+	}
+	reqOpts.CookieJarNamespace = t.cookieNamespace
+	reqOpts.Source = src
+
+	for _, pth := range []*string{&reqOpts.TLS.CACert, &reqOpts.TLS.ClientCert, &reqOpts.TLS.ClientKey} {
+		if *pth != "" {
+			*pth = resolvePath(src.Path, *pth)
+		}
+	}
+
+	return reqOpts, nil
+}
+
+// poll sends the given request like send. When the until
+// option is specified, the request is repeated in the
+// specified poll interval until the until expression
+// evaluates to true against the response or until the
+// poll timeout has been exceeded.
+//
+// Between polls, the waiter of the executor is awaited
+// and polling is canceled when the context of the
+// executor is done, except for teardown steps.
+func (t *Executor) poll(
+	eng engine.Engine,
+	req *goatfile.Request,
+	rec *ActionResult,
+	execOpts ExecOptions,
+) (*http.Request, Response, error) {
+	start := time.Now()
+
+	for poll := 1; ; poll++ {
+		httpReq, resp, err := t.send(req, rec)
+		if err != nil || execOpts.Until == "" {
+			return httpReq, resp, err
+		}
+
+		eng.Set("response", resp)
+		v, err := eng.Eval(execOpts.Until, req.UntilOrigin)
+		if err != nil {
+			return nil, Response{}, errs.WithPrefix("failed evaluating until expression:", err)
+		}
+		if done, _ := v.(bool); done {
+			return httpReq, resp, nil
+		}
+
+		if execOpts.PollTimeout > 0 && time.Since(start)+execOpts.PollInterval > execOpts.PollTimeout {
+			return nil, Response{}, NewTimeoutError(fmt.Errorf(
+				"until condition not met after %d polls within %s", poll, execOpts.PollTimeout))
+		}
+
+		t.log.Info().
+			Field("req", req).
+			Field("poll", poll).
+			Field("interval", execOpts.PollInterval).
+			Msg(clr.Print(clr.Format("Until condition not met, polling ...", clr.ColorFGBlack)))
+
+		select {
+		case <-t.contextFor(rec.Section).Done():
+			return nil, Response{}, fmt.Errorf("canceled after %d polls", poll)
+		case <-time.After(execOpts.PollInterval):
+		}
+
+		t.Waiter.Wait()
+	}
+}
+
+// validateResponse validates the body of the given response
+// against the schemas specified by the schema and openapi
+// options. Relative schema paths are resolved against the
+// directory of the Goatfile containing the request.
+func (t *Executor) validateResponse(req *goatfile.Request, httpReq *http.Request, resp Response, execOpts ExecOptions) error {
+	if execOpts.Schema != "" {
+		err := t.schemas.Validate(jsonBody(resp), resolvePath(req.Path, execOpts.Schema))
+		if err != nil {
+			return errs.WithPrefix("schema validation failed:", err)
+		}
+	}
+
+	if execOpts.OpenAPI != "" {
+		err := t.schemas.ValidateOpenAPI(jsonBody(resp), resolvePath(req.Path, execOpts.OpenAPI),
+			httpReq.Method, httpReq.URL.Path, resp.StatusCode)
+		if err != nil {
+			return errs.WithPrefix("OpenAPI validation failed:", err)
 		}
 	}
 
@@ -503,9 +904,10 @@ func (t *Executor) executeExecute(params goatfile.Execute, eng engine.Engine, sh
 		return Result{}, err
 	}
 
-	log := log.Tagged(strings.TrimSuffix(gf.Path, ".goat"))
+	log := t.log.Tagged(strings.TrimSuffix(gf.Path, ".goat"))
 
 	isolatedEng := t.engineMaker()
+	isolatedEng.SetLogger(t.log)
 	isolatedEng.SetState(params.Params)
 
 	res, err := t.executeGoatfile(log, gf, isolatedEng, false, showTeardownParamErrors)
@@ -526,6 +928,17 @@ func (t *Executor) executeExecute(params goatfile.Execute, eng engine.Engine, sh
 	return res, nil
 }
 
+// contextFor returns the context of the executor for
+// actions in the given section. Because teardown steps
+// are not affected by the context state, a context which
+// is never canceled is returned for the teardown section.
+func (t *Executor) contextFor(section goatfile.SectionName) context.Context {
+	if section == goatfile.SectionTeardown {
+		return context.WithoutCancel(t.ctx)
+	}
+	return t.ctx
+}
+
 func (t *Executor) isSkip(section goatfile.SectionName) bool {
 	for _, s := range t.Skip {
 		if strings.ToLower(s) == string(section) {
@@ -0,0 +1,790 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-b12/goat/pkg/engine"
+	"github.com/studio-b12/goat/pkg/errs"
+	"github.com/studio-b12/goat/pkg/requester"
+)
+
+// statusSequence responds with the given status
+// codes in order, repeating the last one.
+type statusSequence struct {
+	mtx      sync.Mutex
+	statuses []int
+	calls    int
+}
+
+func (t *statusSequence) Do(req *http.Request, opt requester.Options) (*http.Response, error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	status := t.statuses[min(t.calls, len(t.statuses)-1)]
+	t.calls++
+
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("")),
+	}, nil
+}
+
+// timeoutRequester fails all requests with a timeout.
+type timeoutRequester struct{}
+
+func (timeoutRequester) Do(req *http.Request, opt requester.Options) (*http.Response, error) {
+	return nil, &url.Error{Op: req.Method, URL: req.URL.String(), Err: context.DeadlineExceeded}
+}
+
+// jsonRequester responds to all requests with
+// the given JSON body.
+type jsonRequester string
+
+func (t jsonRequester) Do(req *http.Request, opt requester.Options) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(string(t))),
+	}, nil
+}
+
+// recordingRequester responds to all requests with
+// status 200 and records the requested URLs.
+type recordingRequester struct {
+	mtx  sync.Mutex
+	urls []string
+}
+
+func (t *recordingRequester) Do(req *http.Request, opt requester.Options) (*http.Response, error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.urls = append(t.urls, req.URL.String())
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("")),
+	}, nil
+}
+
+// echoRequester responds to all requests with a
+// JSON body describing the received request.
+type echoRequester struct{}
+
+func (echoRequester) Do(req *http.Request, opt requester.Options) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.Marshal(map[string]any{
+		"method":      req.Method,
+		"url":         req.URL.String(),
+		"token":       req.Header.Get("X-Token"),
+		"auth":        req.Header.Get("Authorization"),
+		"amzDate":     req.Header.Get("X-Amz-Date"),
+		"contentType": req.Header.Get("Content-Type"),
+		"body":        string(body),
+		"cookiejar":   opt.CookieJar,
+		"line":        opt.Source.Line,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonRequester(data).Do(req, opt)
+}
+
+// executeGoatfile writes the given Goatfile content and
+// additional files into a temporary directory and
+// executes the Goatfile.
+func executeGoatfile(t *testing.T, content string, req requester.Requester, files ...string) (Result, error) {
+	t.Helper()
+
+	dir := t.TempDir()
+	for i := 0; i < len(files)-1; i += 2 {
+		pth := filepath.Join(dir, filepath.FromSlash(files[i]))
+		err := os.MkdirAll(filepath.Dir(pth), os.ModePerm)
+		require.Nil(t, err, err)
+		err = os.WriteFile(pth, []byte(files[i+1]), 0644)
+		require.Nil(t, err, err)
+	}
+
+	pth := filepath.Join(dir, "test.goat")
+	err := os.WriteFile(pth, []byte(content), 0644)
+	require.Nil(t, err, err)
+
+	return New(context.Background(), engine.NewGoja, req).
+		Execute([]string{pth}, engine.State{}, true)
+}
+
+func TestExecuteRetry(t *testing.T) {
+	execute := func(t *testing.T, content string, statuses ...int) (*statusSequence, error) {
+		t.Helper()
+
+		req := &statusSequence{statuses: statuses}
+		_, err := executeGoatfile(t, content, req)
+		return req, err
+	}
+
+	const script = "\n\n[Script]\nassert(response.StatusCode == 200);"
+
+	t.Run("succeeds-eventually", func(t *testing.T) {
+		req, err := execute(t, "GET http://localhost\n\n[Options]\nretry = 3"+script, 500, 500, 200)
+		assert.Nil(t, err, err)
+		assert.Equal(t, 3, req.calls)
+	})
+
+	t.Run("retries-exhausted", func(t *testing.T) {
+		req, err := execute(t, "GET http://localhost\n\n[Options]\nretry = 2"+script, 500)
+		require.NotNil(t, err)
+		assert.Equal(t, 3, req.calls)
+		assert.Contains(t, err.Error(), "failed after 3 attempts")
+		assert.Contains(t, err.Error(), "script failed")
+	})
+
+	t.Run("no-retry", func(t *testing.T) {
+		req, err := execute(t, "GET http://localhost"+script, 500, 200)
+		assert.NotNil(t, err)
+		assert.Equal(t, 1, req.calls)
+	})
+
+	t.Run("retryon-status", func(t *testing.T) {
+		req, err := execute(t, "GET http://localhost\n\n[Options]\nretry = 3\nretryon = [503]\n", 503, 503, 200)
+		assert.Nil(t, err, err)
+		assert.Equal(t, 3, req.calls)
+
+		// The script decides on the last attempt.
+		req, err = execute(t, "GET http://localhost\n\n[Options]\nretry = 1\nretryon = [503]\n", 503)
+		assert.Nil(t, err, err)
+		assert.Equal(t, 2, req.calls)
+	})
+
+	t.Run("retryon-status-not-script", func(t *testing.T) {
+		req, err := execute(t, "GET http://localhost\n\n[Options]\nretry = 3\nretryon = [503]"+script, 500)
+		assert.NotNil(t, err)
+		assert.Equal(t, 1, req.calls)
+	})
+}
+
+func TestExecuteTimeout(t *testing.T) {
+	res, err := executeGoatfile(t, "GET http://localhost\n\n[Options]\ntimeout = \"1s\"\n", timeoutRequester{})
+	require.NotNil(t, err)
+	assert.True(t, errs.IsOfType[TimeoutError](err))
+
+	actions := res.Batches[0].Actions()
+	require.Len(t, actions, 1)
+	assert.True(t, errs.IsOfType[TimeoutError](actions[0].Err))
+
+	_, err = executeGoatfile(t, "GET http://localhost/fail\n\n[Script]\nassert(false);", &statusSequence{statuses: []int{200}})
+	require.NotNil(t, err)
+	assert.False(t, errs.IsOfType[TimeoutError](err))
+}
+
+// cancelingRequester cancels the execution
+// on the first request.
+type cancelingRequester struct {
+	cancel context.CancelFunc
+}
+
+func (t cancelingRequester) Do(req *http.Request, opt requester.Options) (*http.Response, error) {
+	t.cancel()
+	return jsonRequester("{}").Do(req, opt)
+}
+
+func TestExecuteCanceled(t *testing.T) {
+	content := "GET http://localhost/1\n\n---\n\nGET http://localhost/2\n"
+
+	res, err := executeGoatfile(t, content, &statusSequence{statuses: []int{200}})
+	require.Nil(t, err, err)
+	require.Len(t, res.Batches, 1)
+	assert.False(t, res.Batches[0].Canceled)
+
+	pth := filepath.Join(t.TempDir(), "test.goat")
+	err = os.WriteFile(pth, []byte(content), 0644)
+	require.Nil(t, err, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	res, err = New(ctx, engine.NewGoja, cancelingRequester{cancel: cancel}).
+		Execute([]string{pth}, engine.State{}, true)
+	require.NotNil(t, err)
+	require.Len(t, res.Batches, 1)
+	assert.True(t, res.Batches[0].Canceled)
+	assert.Len(t, res.Batches[0].Actions(), 1)
+}
+
+// cancelingSequence cancels the execution on each
+// request and responds like statusSequence.
+type cancelingSequence struct {
+	statusSequence
+	cancel context.CancelFunc
+}
+
+func (t *cancelingSequence) Do(req *http.Request, opt requester.Options) (*http.Response, error) {
+	t.cancel()
+	return t.statusSequence.Do(req, opt)
+}
+
+// executeCanceledGoatfile executes the given Goatfile content
+// with a context which is canceled on the first request.
+func executeCanceledGoatfile(t *testing.T, content string, statuses ...int) (*cancelingSequence, Result, error) {
+	t.Helper()
+
+	pth := filepath.Join(t.TempDir(), "test.goat")
+	err := os.WriteFile(pth, []byte(content), 0644)
+	require.Nil(t, err, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req := &cancelingSequence{statusSequence: statusSequence{statuses: statuses}, cancel: cancel}
+	res, err := New(ctx, engine.NewGoja, req).Execute([]string{pth}, engine.State{}, true)
+	return req, res, err
+}
+
+func TestExecuteRetryCanceledTeardown(t *testing.T) {
+	req, res, err := executeCanceledGoatfile(t, `
+### Tests
+
+GET http://localhost/tests
+
+### Teardown
+
+DELETE http://localhost/teardown
+
+[Options]
+retry = 1
+retrydelay = "20ms"
+
+[Script]
+assert(response.StatusCode == 200);
+`, 200, 500, 200)
+	require.Nil(t, err, err)
+
+	assert.Equal(t, 3, req.calls)
+	require.Len(t, res.Teardown.Actions, 1)
+	assert.Nil(t, res.Teardown.Actions[0].Err)
+}
+
+func TestExecuteCanceledBeforeStart(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a", "b", "c"} {
+		content := "### Setup\n\nGET http://localhost/" + name + "/setup\n\n" +
+			"### Teardown\n\nDELETE http://localhost/" + name + "/teardown\n"
+		err := os.WriteFile(filepath.Join(dir, name+".goat"), []byte(content), 0644)
+		require.Nil(t, err, err)
+	}
+
+	for _, parallel := range []int{0, 2} {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		req := &recordingRequester{}
+		exec := New(ctx, engine.NewGoja, req)
+		exec.Parallel = parallel
+
+		res, err := exec.Execute([]string{dir}, engine.State{}, true)
+		require.NotNil(t, err)
+		assert.Empty(t, req.urls)
+		require.Len(t, res.Batches, 3)
+		for i, name := range []string{"a", "b", "c"} {
+			assert.Equal(t, filepath.Join(dir, name+".goat"), res.Batches[i].Path)
+			assert.True(t, res.Batches[i].Canceled)
+		}
+	}
+}
+
+func TestExecuteSchema(t *testing.T) {
+	const userSchema = `{"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}`
+
+	_, err := executeGoatfile(t, "GET http://localhost\n\n[Options]\nschema = \"user.json\"\n",
+		jsonRequester(`{"id": 1}`), "user.json", userSchema)
+	assert.Nil(t, err, err)
+
+	_, err = executeGoatfile(t, "GET http://localhost\n\n[Options]\nschema = \"user.json\"\n",
+		jsonRequester(`{"id": "1"}`), "user.json", userSchema)
+	assert.ErrorContains(t, err, "schema validation failed")
+	assert.ErrorContains(t, err, "'/id': got string, want integer")
+
+	_, err = executeGoatfile(t, "GET http://localhost\n\n[Options]\nschema = @user.json\n",
+		jsonRequester(`{"id": 1}`), "user.json", userSchema)
+	assert.Nil(t, err, err)
+
+	_, err = executeGoatfile(t, "GET http://localhost\n\n[Options]\nschema = @user.json\n",
+		jsonRequester(`{"id": "not-an-int"}`), "user.json", userSchema)
+	assert.ErrorContains(t, err, "schema validation failed")
+	assert.ErrorContains(t, err, "'/id': got string, want integer")
+
+	_, err = executeGoatfile(t, "GET http://localhost\n\n[Options]\nschema = 5\n",
+		jsonRequester(`{"id": 1}`), "user.json", userSchema)
+	assert.ErrorContains(t, err, "invalid schema value type int64")
+
+	_, err = executeGoatfile(t, "GET http://localhost\n\n[Script]\nassert_schema(response.Body, \"user.json\");",
+		jsonRequester(`{"id": 1}`), "user.json", userSchema)
+	assert.Nil(t, err, err)
+
+	_, err = executeGoatfile(t, "GET http://localhost\n\n[Script]\nassert_schema(response.Body, \"user.json\");",
+		jsonRequester(`{}`), "user.json", userSchema)
+	assert.ErrorContains(t, err, "assertion failed: value does not match schema")
+}
+
+func TestExecuteOpenAPI(t *testing.T) {
+	const spec = `{"openapi": "3.1.0", "paths": {"/users/{id}": {"get": {"responses": {"200": {
+		"content": {"application/json": {"schema": {"type": "object", "required": ["id"]}}}}}}}}}`
+
+	_, err := executeGoatfile(t, "GET http://localhost/users/1\n\n[Options]\nopenapi = \"openapi.json\"\n",
+		jsonRequester(`{"id": 1}`), "openapi.json", spec)
+	assert.Nil(t, err, err)
+
+	_, err = executeGoatfile(t, "GET http://localhost/users/1\n\n[Options]\nopenapi = \"openapi.json\"\n",
+		jsonRequester(`{}`), "openapi.json", spec)
+	assert.ErrorContains(t, err, "OpenAPI validation failed")
+
+	_, err = executeGoatfile(t, "GET http://localhost/users/1\n\n[Options]\nopenapi = @openapi.json\n",
+		jsonRequester(`{"id": 1}`), "openapi.json", spec)
+	assert.Nil(t, err, err)
+
+	_, err = executeGoatfile(t, "GET http://localhost/users/1\n\n[Options]\nopenapi = @openapi.json\n",
+		jsonRequester(`{}`), "openapi.json", spec)
+	assert.ErrorContains(t, err, "OpenAPI validation failed")
+
+	_, err = executeGoatfile(t, "GET http://localhost/posts\n\n[Options]\nopenapi = \"openapi.json\"\n",
+		jsonRequester(`{}`), "openapi.json", spec)
+	assert.ErrorContains(t, err, "no operation found for path /posts")
+}
+
+func TestExecuteSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	pth := filepath.Join(dir, "test.goat")
+	err := os.WriteFile(pth, []byte("GET http://localhost\n\n[Script]\nsnapshot(response.Body, \"user\", [\".id\"]);"), 0644)
+	require.Nil(t, err, err)
+
+	execute := func(body string, update bool) error {
+		engineMaker := func() engine.Engine {
+			return engine.NewGojaWithOptions(engine.GojaOptions{UpdateSnapshots: update})
+		}
+		_, err := New(context.Background(), engineMaker, jsonRequester(body)).
+			Execute([]string{pth}, engine.State{}, true)
+		return err
+	}
+
+	assert.Nil(t, execute(`{"id": 1, "name": "foo"}`, false))
+	assert.FileExists(t, filepath.Join(dir, "__snapshots__", "user.json"))
+
+	assert.Nil(t, execute(`{"id": 2, "name": "foo"}`, false))
+
+	err = execute(`{"id": 2, "name": "bar"}`, false)
+	assert.ErrorContains(t, err, "snapshot failed: value does not match snapshot 'user'")
+	assert.ErrorContains(t, err, `~ .name: "foo" -> "bar"`)
+
+	assert.Nil(t, execute(`{"id": 2, "name": "bar"}`, true))
+	assert.Nil(t, execute(`{"id": 3, "name": "bar"}`, false))
+}
+
+func TestExecuteForeach(t *testing.T) {
+	t.Run("list", func(t *testing.T) {
+		req := &recordingRequester{}
+		res, err := executeGoatfile(t,
+			"GET http://localhost/{{.index}}/{{.item}}\n\n[Options]\nforeach = [\"a\", \"b\"]", req)
+		require.Nil(t, err, err)
+		assert.Equal(t, []string{"http://localhost/0/a", "http://localhost/1/b"}, req.urls)
+
+		actions := res.Sum().Actions
+		require.Len(t, actions, 2)
+		assert.Equal(t, "GET http://localhost/{{.index}}/{{.item}} [1]", actions[1].Name)
+	})
+
+	t.Run("parameter", func(t *testing.T) {
+		req := &recordingRequester{}
+		_, err := executeGoatfile(t,
+			"GET http://localhost\n\n[Script]\nvar ids = [1, 2];\n\n---\n\n"+
+				"GET http://localhost/{{.item}}\n\n[Options]\nforeach = {{.ids}}\n\n---\n\n"+
+				"GET http://localhost\n\n[Script]\nassert(typeof item === 'undefined' || item === null);", req)
+		require.Nil(t, err, err)
+		assert.Equal(t, []string{
+			"http://localhost", "http://localhost/1", "http://localhost/2", "http://localhost",
+		}, req.urls)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		req := &recordingRequester{}
+		_, err := executeGoatfile(t,
+			"GET http://localhost/{{.item.name}}?role={{.item.role}}\n\n[Options]\nforeach = @users.csv", req,
+			"users.csv", "name,role\nalice,admin\nbob,user\n")
+		require.Nil(t, err, err)
+		assert.Equal(t, []string{"http://localhost/alice?role=admin", "http://localhost/bob?role=user"}, req.urls)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		req := &recordingRequester{}
+		_, err := executeGoatfile(t,
+			"GET http://localhost/{{.item.id}}\n\n[Options]\nforeach = \"items.json\"", req,
+			"items.json", `[{"id": 1}, {"id": 2}]`)
+		require.Nil(t, err, err)
+		assert.Equal(t, []string{"http://localhost/1", "http://localhost/2"}, req.urls)
+	})
+
+	t.Run("execute", func(t *testing.T) {
+		req := &recordingRequester{}
+		res, err := executeGoatfile(t,
+			"execute ./sub (\n  id=\"{{.item.id}}\"\n  foreach=@ids.csv\n)", req,
+			"sub.goat", "GET http://localhost/sub/{{.id}}",
+			"ids.csv", "id\nx\ny\n")
+		require.Nil(t, err, err)
+		assert.Equal(t, []string{"http://localhost/sub/x", "http://localhost/sub/y"}, req.urls)
+		assert.Len(t, res.Sum().Actions, 2)
+	})
+
+	t.Run("noabort", func(t *testing.T) {
+		req := &statusSequence{statuses: []int{500, 200, 500}}
+		_, err := executeGoatfile(t,
+			"GET http://localhost\n\n[Options]\nforeach = [1, 2, 3]\nnoabort = true\n\n"+
+				"[Script]\nassert(response.StatusCode == 200);", req)
+		require.NotNil(t, err)
+		assert.Equal(t, 3, req.calls)
+		assert.Contains(t, err.Error(), "iteration 0")
+		assert.Contains(t, err.Error(), "iteration 2")
+	})
+
+	t.Run("invalid-file", func(t *testing.T) {
+		_, err := executeGoatfile(t,
+			"GET http://localhost\n\n[Options]\nforeach = \"missing.csv\"", &recordingRequester{})
+		assert.ErrorContains(t, err, "failed opening foreach file")
+	})
+}
+
+func TestExecuteScriptImport(t *testing.T) {
+	const helpers = `import { greeting } from "./lib/strings.js";
+import config from "./config.json";
+
+export const answer = 42;
+
+export function greet(name) {
+	return greeting + ", " + name + "!";
+}
+
+export function fail() {
+	throw new Error("helper failed");
+}
+
+export default config.name;
+`
+
+	res := func(t *testing.T, script string) error {
+		t.Helper()
+		_, err := executeGoatfile(t, "GET http://localhost\n\n[Script]\n"+script, &recordingRequester{},
+			"helpers.js", helpers,
+			"lib/strings.js", "export const greeting = \"Hello\";",
+			"config.json", `{"name": "goat"}`)
+		return err
+	}
+
+	t.Run("named", func(t *testing.T) {
+		err := res(t, "import { greet, answer as a } from \"./helpers.js\";\n"+
+			"assert_eq(greet(\"goat\"), \"Hello, goat!\");\nassert_eq(a, 42);")
+		assert.Nil(t, err, err)
+	})
+
+	t.Run("default-and-namespace", func(t *testing.T) {
+		err := res(t, "import name, * as helpers from \"./helpers\";\n"+
+			"assert_eq(name, \"goat\");\nassert_eq(helpers.answer, 42);")
+		assert.Nil(t, err, err)
+	})
+
+	t.Run("error-position", func(t *testing.T) {
+		err := res(t, "import { fail } from \"./helpers.js\";\nfail();")
+		require.NotNil(t, err)
+		assert.ErrorContains(t, err, "helper failed")
+		assert.ErrorContains(t, err, "helpers.js:11:8")
+	})
+
+	t.Run("invalid-specifier", func(t *testing.T) {
+		err := res(t, "import { greet } from \"helpers\";")
+		assert.ErrorContains(t, err, "invalid module specifier 'helpers'")
+	})
+}
+
+func TestExecuteScriptPosition(t *testing.T) {
+	t.Run("inline", func(t *testing.T) {
+		_, err := executeGoatfile(t,
+			"GET http://localhost\n\n[Script]\nvar a = 1;\n\nassert_eq(a, 2);", &recordingRequester{})
+		require.NotNil(t, err)
+		assert.ErrorContains(t, err, "test.goat:6:10)")
+
+		ex, ok := errs.As[engine.Exception](err)
+		require.True(t, ok)
+		assert.Equal(t, 6, ex.Pos.Line)
+		assert.Equal(t, "assert_eq(a, 2);", ex.Pos.Source)
+	})
+
+	t.Run("escaped", func(t *testing.T) {
+		_, err := executeGoatfile(t,
+			"GET http://localhost\n\n[PreScript]\n```\nvar a = 1;\nassert(a == 2);\n```", &recordingRequester{})
+		assert.ErrorContains(t, err, "test.goat:6:7)")
+	})
+
+	t.Run("file", func(t *testing.T) {
+		_, err := executeGoatfile(t,
+			"GET http://localhost\n\n[Script]\n@script.js", &recordingRequester{},
+			"script.js", "var a = 1;\nassert(a == 2);")
+		assert.ErrorContains(t, err, "script.js:2:7)")
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		_, err := executeGoatfile(t,
+			"### Defaults\n\n[Script]\nassert(false);\n\n### Tests\n\nGET http://localhost", &recordingRequester{})
+		assert.ErrorContains(t, err, "test.goat:4:7)")
+	})
+}
+
+func TestExecuteAssertions(t *testing.T) {
+	const body = `{"id": 1, "name": "goat", "tags": ["a", "b"], "nested": {"count": 2}}`
+
+	execute := func(t *testing.T, script string) error {
+		t.Helper()
+		_, err := executeGoatfile(t, "GET http://localhost\n\n[Script]\n"+script, jsonRequester(body))
+		return err
+	}
+
+	passing := []string{
+		`assert_eq(response.StatusCode, 200);`,
+		`assert_eq(response.Body.nested, {count: 2});`,
+		`assert_ne(response.Body.id, 2);`,
+		`assert_gt(response.Body.id, 0); assert_ge(response.Body.id, 1);`,
+		`assert_lt(response.Body.id, 2); assert_le(response.Body.id, 1.0);`,
+		`assert_contains(response.Body.name, "oa");`,
+		`assert_contains(response.Body.tags, "b");`,
+		`assert_contains(response.Body, "nested");`,
+		`assert_match(response.Body.name, "^go+at$");`,
+		`assert_type(response.Body.id, "number"); assert_type(response.Body.tags, "array");`,
+		`assert_type(response.Body.nested, "object"); assert_type(response.Body.name, "string");`,
+		`assert_len(response.Body.tags, 2); assert_len(response.Body.name, 4);`,
+		`assert_header(response, "content-type", "application/json");`,
+		`assert_header(response, "Content-Type");`,
+		`assert_status_in(response, [200, 201]);`,
+	}
+
+	for _, script := range passing {
+		assert.Nil(t, execute(t, script), script)
+	}
+
+	failing := map[string]string{
+		`assert_eq(response.Body, {id: 2, name: "goat", tags: ["a"], nested: {count: 2}});`: "" +
+			"value does not match expected value (2 difference(s))\n  ~ .id: 2 -> 1\n  + .tags[1]: \"b\"",
+		`assert_ne(response.Body.id, 1, "id");`:                  "assertion failed: id: value `1` must not be equal to `1`",
+		`assert_gt(response.Body.id, 1);`:                        "expected `1` > `1`",
+		`assert_contains(response.Body.tags, "c");`:              "list does not contain `c`",
+		`assert_match(response.Body.name, "^sheep$");`:           "`goat` does not match pattern `^sheep$`",
+		`assert_type(response.Body.tags, "object");`:             "expected value of type object, but was array",
+		`assert_len(response.Body.tags, 3);`:                     "expected length 3, but was 2",
+		`assert_header(response, "X-Foo");`:                      "header `X-Foo` is not set",
+		`assert_header(response, "Content-Type", "text/plain");`: "expected header `Content-Type` to be `text/plain`",
+		`assert_status_in(response, [201, 204]);`:                "expected status code to be one of [201 204], but was 200",
+	}
+
+	for script, msg := range failing {
+		assert.ErrorContains(t, execute(t, script), msg, script)
+	}
+}
+
+func TestExecuteSoftAssertions(t *testing.T) {
+	const script = "\n\n[Script]\nassert(false, \"first\");\nassert_eq(1, 1);\nassert_eq(response.StatusCode, 201, \"second\");"
+
+	t.Run("soft", func(t *testing.T) {
+		res, err := executeGoatfile(t, "GET http://localhost\n\n[Options]\nsoftassert = true"+script,
+			&recordingRequester{})
+		require.NotNil(t, err)
+		assert.ErrorContains(t, err, "2 errors occured")
+		assert.ErrorContains(t, err, "assertion failed: first (at ")
+		assert.ErrorContains(t, err, "test.goat:7:7)")
+		assert.ErrorContains(t, err, "assertion failed: second")
+		assert.ErrorContains(t, err, "test.goat:9:10)")
+
+		actions := res.Sum().Actions
+		require.Len(t, actions, 1)
+		assert.Equal(t, engine.Checks{Passed: 1, Failed: 2}, actions[0].Checks)
+	})
+
+	t.Run("hard", func(t *testing.T) {
+		res, err := executeGoatfile(t, "GET http://localhost"+script, &recordingRequester{})
+		require.NotNil(t, err)
+		assert.ErrorContains(t, err, "assertion failed: first")
+		assert.NotContains(t, err.Error(), "second")
+
+		assert.Equal(t, engine.Checks{Passed: 0, Failed: 1}, res.Sum().Actions[0].Checks)
+	})
+
+	t.Run("passing", func(t *testing.T) {
+		res, err := executeGoatfile(t,
+			"GET http://localhost\n\n[Options]\nsoftassert = true\n\n[Script]\nassert(true);\nassert_eq(1, 1);",
+			&recordingRequester{})
+		require.Nil(t, err, err)
+		assert.Equal(t, engine.Checks{Passed: 2}, res.Sum().Checks())
+	})
+}
+
+func TestExecuteScriptHTTP(t *testing.T) {
+	t.Run("requests", func(t *testing.T) {
+		res, err := executeGoatfile(t, `
+GET http://localhost/main
+
+[Options]
+cookiejar = "session"
+
+[Script]
+const r = http.get("http://localhost/status", {header: {"X-Token": "abc"}});
+assert_eq(r.StatusCode, 200);
+assert_eq(r.Body.method, "GET");
+assert_eq(r.Body.url, "http://localhost/status");
+assert_eq(r.Body.token, "abc");
+assert_eq(r.Body.cookiejar, "session");
+assert_eq(r.Body.line, 2);
+
+const p = http.post("http://localhost/jobs", {id: 1});
+assert_eq(p.Body.method, "POST");
+assert_eq(p.Body.body, '{"id":1}');
+assert_eq(p.Body.contentType, "application/json");
+
+const d = http.request({method: "delete", url: "http://localhost/jobs/1", options: {cookiejar: "other"}});
+assert_eq(d.Body.method, "DELETE");
+assert_eq(d.Body.cookiejar, "other");
+`, echoRequester{})
+		require.Nil(t, err, err)
+
+		assert.Equal(t, 3, res.Sum().Actions[0].ScriptRequests)
+		assert.Equal(t, 3, res.Sum().ScriptRequests())
+	})
+
+	t.Run("prescript", func(t *testing.T) {
+		rr := &recordingRequester{}
+		_, err := executeGoatfile(t, `
+GET http://localhost/{{.id}}
+
+[PreScript]
+http.get("http://localhost/token");
+var id = 1;
+`, rr)
+		require.Nil(t, err, err)
+		assert.Equal(t, []string{"http://localhost/token", "http://localhost/1"}, rr.urls)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		_, err := executeGoatfile(t, `
+GET http://localhost
+
+[Script]
+http.get("http://localhost/status");
+`, timeoutRequester{})
+		require.NotNil(t, err)
+		assert.ErrorContains(t, err, "http request failed")
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := executeGoatfile(t, `
+GET http://localhost
+
+[Script]
+http.request({method: "GET"});
+`, &recordingRequester{})
+		require.NotNil(t, err)
+		assert.ErrorContains(t, err, "invalid http request: no url specified")
+	})
+}
+
+func TestExecuteUntil(t *testing.T) {
+	t.Run("polls", func(t *testing.T) {
+		req := &statusSequence{statuses: []int{202, 202, 200}}
+		res, err := executeGoatfile(t, `
+GET http://localhost/jobs/1
+
+[Options]
+until = "response.StatusCode === 200"
+pollinterval = 1
+
+[Script]
+assert_eq(response.StatusCode, 200);
+`, req)
+		require.Nil(t, err, err)
+
+		assert.Equal(t, 3, req.calls)
+		assert.Equal(t, 200, res.Sum().Actions[0].StatusCode)
+		assert.Equal(t, engine.Checks{Passed: 1}, res.Sum().Actions[0].Checks)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		req := &statusSequence{statuses: []int{202}}
+		_, err := executeGoatfile(t, `
+GET http://localhost/jobs/1
+
+[Options]
+until = "response.StatusCode === 200"
+pollinterval = "10ms"
+polltimeout = "35ms"
+
+[Script]
+assert(false, "script must not be run");
+`, req)
+		require.NotNil(t, err)
+		assert.Regexp(t, `until condition not met after \d+ polls within 35ms`, err.Error())
+		assert.NotContains(t, err.Error(), "script must not be run")
+		assert.True(t, errs.IsOfType[TimeoutError](err))
+		assert.GreaterOrEqual(t, req.calls, 2)
+	})
+
+	t.Run("canceled-teardown", func(t *testing.T) {
+		req, res, err := executeCanceledGoatfile(t, `
+### Tests
+
+GET http://localhost/tests
+
+### Teardown
+
+DELETE http://localhost/jobs/1
+
+[Options]
+until = "response.StatusCode === 200"
+pollinterval = "20ms"
+`, 200, 202, 200)
+		require.Nil(t, err, err)
+
+		assert.Equal(t, 3, req.calls)
+		require.Len(t, res.Teardown.Actions, 1)
+		assert.Equal(t, 200, res.Teardown.Actions[0].StatusCode)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := executeGoatfile(t, `
+GET http://localhost/jobs/1
+
+[Options]
+until = "response.Body.status === "
+`, &recordingRequester{})
+		require.NotNil(t, err)
+		assert.ErrorContains(t, err, "failed evaluating until expression")
+		assert.Regexp(t, `test\.goat: Line \d+:\d+`, err.Error())
+
+		_, err = executeGoatfile(t, "GET http://localhost/jobs/1\n\n[Options]\nuntil = \"response.Body.job.done\"\n",
+			jsonRequester(`{}`))
+		require.NotNil(t, err)
+		assert.ErrorContains(t, err, "test.goat:4:")
+	})
+}
@@ -0,0 +1,150 @@
+package executor
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/studio-b12/goat/pkg/engine"
+	"github.com/studio-b12/goat/pkg/errs"
+	"github.com/studio-b12/goat/pkg/goatfile"
+	"github.com/studio-b12/goat/pkg/goatfile/ast"
+)
+
+const (
+	foreachOptionName = "foreach"
+	foreachItemName   = "item"
+	foreachIndexName  = "index"
+)
+
+// executeForeach calls exec for each of the given items with
+// the item and its index bound to the state of eng as "item"
+// and "index". The previous values of these variables are
+// restored afterwards.
+//
+// Iterations failing with a NoAbortError do not stop the
+// iteration. Their errors are returned combined as a
+// NoAbortError after all items have been executed.
+func (t *Executor) executeForeach(
+	eng engine.Engine,
+	items []any,
+	exec func(i int) (ActionResult, error),
+) (res ResultSection, err error) {
+	prev := eng.State()
+	defer func() {
+		eng.Set(foreachItemName, prev[foreachItemName])
+		eng.Set(foreachIndexName, prev[foreachIndexName])
+	}()
+
+	var errsNoAbort errs.Errors
+	for i, item := range items {
+		eng.Set(foreachItemName, item)
+		eng.Set(foreachIndexName, i)
+
+		rec, err := exec(i)
+		res.Add(rec)
+		if err != nil {
+			if !errs.IsOfType[NoAbortError](err) {
+				return res, err
+			}
+			errsNoAbort = errsNoAbort.Append(err)
+		}
+	}
+
+	if errsNoAbort.HasSome() {
+		return res, NewNoAbortError(errsNoAbort.Condense())
+	}
+
+	return res, nil
+}
+
+// foreachItems returns the items to iterate over specified
+// by the given foreach value.
+//
+// The value can either be a list, a parameter value resolving
+// to a list or the path to a CSV or JSON file, passed as string
+// or file descriptor, relative to the Goatfile at gfPath.
+// Templates in strings are applied with the given state.
+func foreachItems(v any, state engine.State, gfPath string) ([]any, error) {
+	switch vt := v.(type) {
+	case goatfile.ParameterValue:
+		res, err := vt.Evaluate(state)
+		if err != nil {
+			return nil, errs.WithPrefix("failed evaluating foreach value:", err)
+		}
+		items, ok := res.([]any)
+		if !ok {
+			return nil, fmt.Errorf("foreach value must resolve to a list, but was %T", res)
+		}
+		return items, nil
+
+	case []any:
+		err := goatfile.ApplyTemplateToArray(vt, state)
+		if err != nil {
+			return nil, errs.WithPrefix("failed applying template to foreach value:", err)
+		}
+		return vt, nil
+
+	case ast.FileDescriptor:
+		return foreachItems(vt.Path, state, gfPath)
+
+	case string:
+		pth, err := goatfile.ApplyTemplate(vt, state)
+		if err != nil {
+			return nil, errs.WithPrefix("failed applying template to foreach value:", err)
+		}
+		return loadForeachFile(resolvePath(gfPath, pth))
+
+	default:
+		return nil, fmt.Errorf("invalid foreach value type %T (list, parameter or file path expected)", v)
+	}
+}
+
+// loadForeachFile loads the items from the CSV or JSON file
+// at the given path. Each row of a CSV file is returned as a
+// map with the column names of the header row as keys. JSON
+// files must contain a list.
+func loadForeachFile(pth string) ([]any, error) {
+	f, err := os.Open(pth)
+	if err != nil {
+		return nil, errs.WithPrefix("failed opening foreach file:", err)
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(pth)) {
+	case ".csv":
+		records, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			return nil, errs.WithPrefix("failed parsing foreach file:", err)
+		}
+		if len(records) == 0 {
+			return []any{}, nil
+		}
+
+		header := records[0]
+		items := make([]any, 0, len(records)-1)
+		for _, record := range records[1:] {
+			item := make(map[string]any, len(header))
+			for i, name := range header {
+				item[name] = record[i]
+			}
+			items = append(items, item)
+		}
+		return items, nil
+
+	case ".json":
+		var items []any
+		err = json.NewDecoder(f).Decode(&items)
+		if err != nil {
+			return nil, errs.WithPrefix("failed parsing foreach file:", err)
+		}
+		return items, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported foreach file type '%s' (.csv or .json expected)",
+			filepath.Ext(pth))
+	}
+}
@@ -0,0 +1,249 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/studio-b12/goat/pkg/errs"
+	"github.com/studio-b12/goat/pkg/goatfile"
+	"github.com/studio-b12/goat/pkg/requester"
+)
+
+// tokenExpiryDelta is subtracted from the lifetime of
+// access tokens so that tokens are renewed before they
+// expire during a request.
+const tokenExpiryDelta = 10 * time.Second
+
+// oauth2Config identifies the configuration an
+// OAuth2 access token has been requested with.
+type oauth2Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scope        string
+	GrantType    string
+	UserName     string
+	Password     string
+	RefreshToken string
+}
+
+func oauth2ConfigFromAuthOptions(opt AuthOptions) oauth2Config {
+	cfg := oauth2Config{
+		TokenURL:     opt.TokenURL,
+		ClientID:     opt.ClientID,
+		ClientSecret: opt.ClientSecret,
+		Scope:        opt.Scope,
+		GrantType:    strings.ToLower(opt.GrantType),
+		UserName:     opt.UserName,
+		Password:     opt.Password,
+		RefreshToken: opt.RefreshToken,
+	}
+
+	if cfg.GrantType == "" {
+		cfg.GrantType = "client_credentials"
+	}
+
+	return cfg
+}
+
+// oauth2Token holds an access token obtained
+// from an OAuth2 token endpoint.
+type oauth2Token struct {
+	AccessToken  string      `json:"access_token"`
+	TokenType    string      `json:"token_type"`
+	RefreshToken string      `json:"refresh_token"`
+	ExpiresIn    json.Number `json:"expires_in"`
+
+	expires time.Time
+}
+
+// valid returns true when the token has not expired.
+func (t *oauth2Token) valid() bool {
+	return t.expires.IsZero() || time.Now().Before(t.expires)
+}
+
+// HeaderValue returns the value of the
+// Authorization header for the token.
+func (t *oauth2Token) HeaderValue() string {
+	typ := t.TokenType
+	if typ == "" || strings.EqualFold(typ, "bearer") {
+		typ = "Bearer"
+	}
+	return fmt.Sprintf("%s %s", typ, t.AccessToken)
+}
+
+// tokenFetch is an access token request in flight,
+// which concurrent requests with the same OAuth2
+// configuration wait for instead of requesting
+// another token.
+type tokenFetch struct {
+	done  chan struct{}
+	token *oauth2Token
+	err   error
+}
+
+// oauth2Authorization returns the value of the Authorization
+// header for the OAuth2 configuration of the given auth options.
+// The access token is requested and cached on first use. When
+// renew is true or when the cached token has expired, a new
+// token is requested using the refresh token, if available,
+// or the configured grant type.
+//
+// The token is requested with the given options of the
+// request to be authorized. The auth cache is only locked
+// while accessing the cached tokens, so that token requests
+// do not block requests with other configurations. Concurrent
+// requests with the same configuration share a single token
+// request.
+func (t *Executor) oauth2Authorization(opt AuthOptions, reqOpts requester.Options, renew bool) (string, error) {
+	cfg := oauth2ConfigFromAuthOptions(opt)
+
+	t.auth.mtx.Lock()
+
+	token, ok := t.auth.tokens[cfg]
+	if ok && !renew && token.valid() {
+		t.auth.mtx.Unlock()
+		return token.HeaderValue(), nil
+	}
+
+	fetch, inFlight := t.auth.fetches[cfg]
+	if !inFlight {
+		fetch = &tokenFetch{done: make(chan struct{})}
+		t.auth.fetches[cfg] = fetch
+	}
+
+	t.auth.mtx.Unlock()
+
+	if inFlight {
+		<-fetch.done
+	} else {
+		fetch.token, fetch.err = t.fetchToken(cfg, token, reqOpts)
+
+		t.auth.mtx.Lock()
+		if fetch.err == nil {
+			t.auth.tokens[cfg] = fetch.token
+		}
+		delete(t.auth.fetches, cfg)
+		t.auth.mtx.Unlock()
+
+		close(fetch.done)
+	}
+
+	if fetch.err != nil {
+		return "", fetch.err
+	}
+
+	return fetch.token.HeaderValue(), nil
+}
+
+// fetchToken requests a new access token for the given
+// configuration. When the given previous token contains
+// a refresh token, the token is refreshed first.
+func (t *Executor) fetchToken(cfg oauth2Config, prev *oauth2Token, reqOpts requester.Options) (*oauth2Token, error) {
+	if prev != nil && prev.RefreshToken != "" {
+		refreshed, err := t.requestToken(cfg, "refresh_token", prev.RefreshToken, reqOpts)
+		if err == nil {
+			if refreshed.RefreshToken == "" {
+				refreshed.RefreshToken = prev.RefreshToken
+			}
+			return refreshed, nil
+		}
+		t.log.Warn().Err(err).Field("tokenurl", cfg.TokenURL).
+			Msg("Refreshing OAuth2 token failed, requesting new token ...")
+	}
+
+	return t.requestToken(cfg, cfg.GrantType, cfg.RefreshToken, reqOpts)
+}
+
+// requestToken requests a new access token from the token
+// endpoint of the given configuration using the given
+// grant type. The client credentials are passed using
+// basic authentication.
+//
+// The token request is sent with the given options of the
+// request to be authorized, so that its TLS, proxy and
+// timeout options apply, but without sending or storing
+// cookies.
+func (t *Executor) requestToken(
+	cfg oauth2Config,
+	grantType string,
+	refreshToken string,
+	reqOpts requester.Options,
+) (*oauth2Token, error) {
+	if cfg.TokenURL == "" {
+		return nil, fmt.Errorf("no tokenurl specified")
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", grantType)
+	if cfg.Scope != "" {
+		form.Set("scope", cfg.Scope)
+	}
+
+	switch grantType {
+	case "client_credentials":
+	case "password":
+		form.Set("username", cfg.UserName)
+		form.Set("password", cfg.Password)
+	case "refresh_token":
+		if refreshToken == "" {
+			return nil, fmt.Errorf("no refreshtoken specified")
+		}
+		form.Set("refresh_token", refreshToken)
+	default:
+		return nil, fmt.Errorf("unsupported grant type '%s' "+
+			"(client_credentials, password or refresh_token expected)", grantType)
+	}
+
+	ctx := t.contextFor(goatfile.SectionName(reqOpts.Source.Section))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, errs.WithPrefix("failed creating token request:", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("Accept", "application/json")
+	if cfg.ClientID != "" {
+		httpReq.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	}
+
+	reqOpts.SendCookies = false
+	reqOpts.StoreCookies = false
+
+	t.log.Debug().Fields("tokenurl", cfg.TokenURL, "granttype", grantType).Msg("Requesting OAuth2 token ...")
+
+	httpResp, err := t.req.Do(httpReq, reqOpts)
+	if err != nil {
+		return nil, errs.WithPrefix("token request failed:", err)
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, errs.WithPrefix("failed reading token response:", err)
+	}
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		return nil, fmt.Errorf("token request failed with status %d: %s",
+			httpResp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	var token oauth2Token
+	err = json.Unmarshal(data, &token)
+	if err != nil {
+		return nil, errs.WithPrefix("failed parsing token response:", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("token response contains no access token")
+	}
+
+	if expiresIn, _ := token.ExpiresIn.Int64(); expiresIn > 0 {
+		token.expires = time.Now().Add(time.Duration(expiresIn)*time.Second - tokenExpiryDelta)
+	}
+
+	return &token, nil
+}
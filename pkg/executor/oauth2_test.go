@@ -0,0 +1,296 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-b12/goat/pkg/engine"
+	"github.com/studio-b12/goat/pkg/goatfile"
+	"github.com/studio-b12/goat/pkg/requester"
+)
+
+// tokenServer is a mock OAuth2 token endpoint issuing
+// numbered access tokens and a mock API accepting only
+// the most recently issued token.
+type tokenServer struct {
+	*httptest.Server
+
+	mtx       sync.Mutex
+	expiresIn int
+	requests  []url.Values
+	clients   []string
+	current   string
+}
+
+func newTokenServer(t *testing.T, expiresIn int) *tokenServer {
+	ts := &tokenServer{expiresIn: expiresIn}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		ts.mtx.Lock()
+		defer ts.mtx.Unlock()
+
+		err := r.ParseForm()
+		require.Nil(t, err, err)
+
+		clientID, clientSecret, _ := r.BasicAuth()
+		ts.requests = append(ts.requests, r.PostForm)
+		ts.clients = append(ts.clients, clientID+":"+clientSecret)
+
+		if clientSecret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+
+		ts.current = fmt.Sprintf("token-%d", len(ts.requests))
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  ts.current,
+			"token_type":    "bearer",
+			"expires_in":    ts.expiresIn,
+			"refresh_token": "refresh-" + ts.current,
+		})
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		ts.mtx.Lock()
+		defer ts.mtx.Unlock()
+
+		if r.Header.Get("Authorization") != "Bearer "+ts.current {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	})
+	mux.HandleFunc("/revoke", func(w http.ResponseWriter, r *http.Request) {
+		ts.mtx.Lock()
+		defer ts.mtx.Unlock()
+
+		ts.current = ""
+	})
+
+	ts.Server = httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func TestExecuteOAuth2(t *testing.T) {
+	req := requester.NewHttpWithCookies(func(client *http.Client) {})
+
+	auth := func(ts *tokenServer, extra ...string) string {
+		return fmt.Sprintf(`
+[Auth]
+type = "oauth2"
+tokenurl = "%s/token"
+clientid = "goat"
+clientsecret = "secret"
+%s`, ts.URL, strings.Join(extra, "\n"))
+	}
+
+	t.Run("client-credentials", func(t *testing.T) {
+		ts := newTokenServer(t, 3600)
+		request := fmt.Sprintf("GET %s/api\n%s\n\n[Script]\nassert_eq(response.StatusCode, 200);",
+			ts.URL, auth(ts, `scope = "read write"`))
+
+		_, err := executeGoatfile(t, request+"\n\n---\n\n"+request, req)
+		require.Nil(t, err, err)
+
+		require.Len(t, ts.requests, 1)
+		assert.Equal(t, "client_credentials", ts.requests[0].Get("grant_type"))
+		assert.Equal(t, "read write", ts.requests[0].Get("scope"))
+		assert.Equal(t, "goat:secret", ts.clients[0])
+	})
+
+	t.Run("password", func(t *testing.T) {
+		ts := newTokenServer(t, 3600)
+		_, err := executeGoatfile(t, fmt.Sprintf("GET %s/api\n%s", ts.URL,
+			auth(ts, `granttype = "password"`, `username = "foo"`, `password = "bar"`)), req)
+		require.Nil(t, err, err)
+
+		require.Len(t, ts.requests, 1)
+		assert.Equal(t, "password", ts.requests[0].Get("grant_type"))
+		assert.Equal(t, "foo", ts.requests[0].Get("username"))
+		assert.Equal(t, "bar", ts.requests[0].Get("password"))
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		ts := newTokenServer(t, 1)
+		request := fmt.Sprintf("GET %s/api\n%s\n\n[Script]\nassert_eq(response.StatusCode, 200);",
+			ts.URL, auth(ts))
+
+		_, err := executeGoatfile(t, request+"\n\n---\n\n"+request, req)
+		require.Nil(t, err, err)
+
+		require.Len(t, ts.requests, 2)
+		assert.Equal(t, "client_credentials", ts.requests[0].Get("grant_type"))
+		assert.Equal(t, "refresh_token", ts.requests[1].Get("grant_type"))
+		assert.Equal(t, "refresh-token-1", ts.requests[1].Get("refresh_token"))
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		ts := newTokenServer(t, 3600)
+		request := fmt.Sprintf("GET %s/api\n%s\n\n[Script]\nassert_eq(response.StatusCode, 200);",
+			ts.URL, auth(ts))
+
+		_, err := executeGoatfile(t, fmt.Sprintf("%s\nhttp.get(\"%s/revoke\");\n\n---\n\n%s",
+			request, ts.URL, request), req)
+		require.Nil(t, err, err)
+
+		require.Len(t, ts.requests, 2)
+		assert.Equal(t, "refresh_token", ts.requests[1].Get("grant_type"))
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		ts := newTokenServer(t, 3600)
+		_, err := executeGoatfile(t, fmt.Sprintf(`
+GET %s/api
+
+[Auth]
+type = "oauth2"
+tokenurl = "%s/token"
+clientid = "goat"
+clientsecret = "wrong"
+`, ts.URL, ts.URL), req)
+		require.NotNil(t, err)
+		assert.ErrorContains(t, err, "authorization failed: token request failed with status 401")
+	})
+}
+
+// blockingTokenRequester issues access tokens named after the
+// host of the token URL. Token requests to the host "slow" are
+// blocked until release is closed.
+type blockingTokenRequester struct {
+	mtx     sync.Mutex
+	calls   map[string]int
+	started chan struct{}
+	release chan struct{}
+}
+
+func (t *blockingTokenRequester) Do(req *http.Request, opt requester.Options) (*http.Response, error) {
+	t.mtx.Lock()
+	t.calls[req.URL.Host]++
+	t.mtx.Unlock()
+
+	if req.URL.Host == "slow" {
+		t.started <- struct{}{}
+		<-t.release
+	}
+
+	return jsonRequester(`{"access_token": "token-`+req.URL.Host+`"}`).Do(req, opt)
+}
+
+func TestOAuth2Authorization_concurrent(t *testing.T) {
+	req := &blockingTokenRequester{
+		calls:   map[string]int{},
+		started: make(chan struct{}, 1),
+		release: make(chan struct{}),
+	}
+	ex := New(context.Background(), engine.NewGoja, req)
+
+	opts := func(host string) AuthOptions {
+		return AuthOptions{Type: AuthTypeOAuth2, TokenURL: "http://" + host + "/token", ClientID: "goat"}
+	}
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]string, 3)
+	)
+	authorize := func(i int) {
+		defer wg.Done()
+		v, err := ex.oauth2Authorization(opts("slow"), requester.Options{}, false)
+		assert.Nil(t, err, err)
+		results[i] = v
+	}
+
+	wg.Add(1)
+	go authorize(0)
+	<-req.started
+
+	wg.Add(2)
+	go authorize(1)
+	go authorize(2)
+
+	// Requests with other configurations are not blocked
+	// by the token request in flight.
+	v, err := ex.oauth2Authorization(opts("fast"), requester.Options{}, false)
+	require.Nil(t, err, err)
+	assert.Equal(t, "Bearer token-fast", v)
+
+	close(req.release)
+	wg.Wait()
+
+	assert.Equal(t, []string{"Bearer token-slow", "Bearer token-slow", "Bearer token-slow"}, results)
+	assert.Equal(t, map[string]int{"slow": 1, "fast": 1}, req.calls)
+}
+
+// tokenOptionsRecorder issues access tokens and records
+// the options and contexts of the token requests.
+type tokenOptionsRecorder struct {
+	opts []requester.Options
+	ctxs []context.Context
+}
+
+func (t *tokenOptionsRecorder) Do(req *http.Request, opt requester.Options) (*http.Response, error) {
+	if req.URL.Path == "/token" {
+		t.opts = append(t.opts, opt)
+		t.ctxs = append(t.ctxs, req.Context())
+	}
+	return jsonRequester(`{"access_token": "token"}`).Do(req, opt)
+}
+
+func TestOAuth2Authorization_requestOptions(t *testing.T) {
+	req := &tokenOptionsRecorder{}
+	_, err := executeGoatfile(t, `
+GET http://localhost/api
+
+[Options]
+proxy = "http://proxy.local:8080"
+timeout = "5s"
+cacert = @certs/ca.pem
+
+[Auth]
+type = "oauth2"
+tokenurl = "http://localhost/token"
+clientid = "goat"
+`, req)
+	require.Nil(t, err, err)
+
+	require.Len(t, req.opts, 1)
+	assert.Equal(t, "http://proxy.local:8080", req.opts[0].Proxy)
+	assert.Equal(t, 5*time.Second, req.opts[0].Timeout)
+	assert.Equal(t, "ca.pem", filepath.Base(req.opts[0].TLS.CACert))
+	assert.False(t, req.opts[0].SendCookies)
+	assert.False(t, req.opts[0].StoreCookies)
+}
+
+func TestOAuth2Authorization_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req := &tokenOptionsRecorder{}
+	ex := New(ctx, engine.NewGoja, req)
+
+	opts := AuthOptions{Type: AuthTypeOAuth2, TokenURL: "http://localhost/token"}
+
+	_, err := ex.oauth2Authorization(opts,
+		requester.Options{Source: requester.Source{Section: string(goatfile.SectionTests)}}, false)
+	require.Nil(t, err, err)
+	_, err = ex.oauth2Authorization(opts,
+		requester.Options{Source: requester.Source{Section: string(goatfile.SectionTeardown)}}, true)
+	require.Nil(t, err, err)
+
+	require.Len(t, req.ctxs, 2)
+	assert.ErrorIs(t, req.ctxs[0].Err(), context.Canceled)
+	assert.Nil(t, req.ctxs[1].Err())
+}
@@ -3,9 +3,80 @@ package executor
 import (
 	"encoding/base64"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/studio-b12/goat/pkg/util"
 )
 
+// OptionKeys contains the keys of all request
+// options which are evaluated during the
+// execution of a request.
+var OptionKeys = []string{
+	// AbortOptions
+	"noabort",
+	"alwaysabort",
+
+	// ExecOptions
+	"condition",
+	"delay",
+	"retry",
+	"retrydelay",
+	"retrybackoff",
+	"retryon",
+	"schema",
+	"openapi",
+	"foreach",
+	"softassert",
+	"until",
+	"pollinterval",
+	"polltimeout",
+
+	// requester.Options
+	"cookiejar",
+	"storecookies",
+	"sendcookies",
+	"responsetype",
+	"followredirects",
+	"timeout",
+	"cacert",
+	"clientcert",
+	"clientkey",
+	"clientcertpassword",
+	"proxy",
+	"httpversion",
+	"keepalive",
+	"resolve",
+}
+
+// AuthOptionKeys contains the keys of all
+// values evaluated in the Auth block of
+// a request.
+var AuthOptionKeys = []string{
+	"type",
+	"username",
+	"password",
+	"token",
+	"tokenurl",
+	"clientid",
+	"clientsecret",
+	"scope",
+	"granttype",
+	"refreshtoken",
+	"region",
+	"service",
+	"accesskey",
+	"secretkey",
+	"sessiontoken",
+	"secret",
+	"algorithm",
+	"header",
+	"template",
+	"encoding",
+	"prefix",
+}
+
 // AbortOptions wraps options that control the
 // abort behavior of an execution batch.
 type AbortOptions struct {
@@ -35,39 +106,180 @@ func AbortOptionsFromMap(m map[string]any) AbortOptions {
 // ExecOptions wraps options that control the
 // execution of a request.
 type ExecOptions struct {
-	Condition bool
-	Delay     time.Duration
+	Condition    bool
+	Delay        time.Duration
+	Retry        int
+	RetryDelay   time.Duration
+	RetryBackoff float64
+	RetryOn      RetryOn
+	Schema       string
+	OpenAPI      string
+	SoftAssert   bool
+	Until        string
+	PollInterval time.Duration
+	PollTimeout  time.Duration
+}
+
+// RetryOn specifies the failures of a request
+// attempt on which the request is retried.
+type RetryOn struct {
+	StatusCodes []int
+	Script      bool
+}
+
+// IsSet returns true when any retry
+// condition has been specified.
+func (t RetryOn) IsSet() bool {
+	return len(t.StatusCodes) > 0 || t.Script
+}
+
+// RetryDelayAt returns the delay to wait before
+// the given retry attempt, starting at 1, taking
+// the retry backoff factor into account.
+func (t ExecOptions) RetryDelayAt(attempt int) time.Duration {
+	delay := float64(t.RetryDelay)
+	for i := 1; i < attempt; i++ {
+		delay *= t.RetryBackoff
+	}
+	return time.Duration(delay)
 }
 
 // ExecOptionsFromMap returns a new instance of
 // ExecOptions extracted from the passed map.
-func ExecOptionsFromMap(m map[string]any) ExecOptions {
+//
+// An error is returned when the value of the schema
+// or openapi option is neither a string nor a file
+// descriptor.
+func ExecOptionsFromMap(m map[string]any) (ExecOptions, error) {
 	opt := ExecOptions{
-		Condition: true,
+		Condition:    true,
+		PollInterval: time.Second,
+		PollTimeout:  time.Minute,
 	}
 
 	if v, ok := m["condition"].(bool); ok {
 		opt.Condition = v
 	}
 
-	v, ok := m["delay"]
-	if ok {
-		switch vt := v.(type) {
-		case int:
-			opt.Delay = time.Duration(vt) * time.Millisecond
-		case string:
-			opt.Delay, _ = time.ParseDuration(vt)
+	opt.Delay = util.DurationFromValue(m["delay"])
+
+	if v, ok := util.IntFromValue(m["retry"]); ok && v > 0 {
+		opt.Retry = v
+	}
+
+	opt.RetryDelay = util.DurationFromValue(m["retrydelay"])
+
+	opt.RetryBackoff = 1
+	if v, ok := m["retrybackoff"].(float64); ok {
+		opt.RetryBackoff = v
+	} else if v, ok := util.IntFromValue(m["retrybackoff"]); ok {
+		opt.RetryBackoff = float64(v)
+	}
+
+	var err error
+
+	opt.Schema, err = util.FilePathFromValue("schema", m["schema"])
+	if err != nil {
+		return ExecOptions{}, err
+	}
+
+	opt.OpenAPI, err = util.FilePathFromValue("openapi", m["openapi"])
+	if err != nil {
+		return ExecOptions{}, err
+	}
+
+	if v, ok := m["softassert"].(bool); ok {
+		opt.SoftAssert = v
+	}
+
+	if v, ok := m["until"].(string); ok {
+		opt.Until = v
+	}
+
+	if _, ok := m["pollinterval"]; ok {
+		opt.PollInterval = util.DurationFromValue(m["pollinterval"])
+	}
+
+	if _, ok := m["polltimeout"]; ok {
+		opt.PollTimeout = util.DurationFromValue(m["polltimeout"])
+	}
+
+	switch vt := m["retryon"].(type) {
+	case []any:
+		for _, e := range vt {
+			opt.RetryOn.add(e)
 		}
+	default:
+		opt.RetryOn.add(vt)
 	}
 
-	return opt
+	return opt, nil
+}
+
+func (t *RetryOn) add(v any) {
+	if code, ok := util.IntFromValue(v); ok {
+		t.StatusCodes = append(t.StatusCodes, code)
+		return
+	}
+
+	vt, ok := v.(string)
+	if !ok {
+		return
+	}
+
+	if strings.ToLower(vt) == "script" {
+		t.Script = true
+	} else if code, err := strconv.Atoi(vt); err == nil {
+		t.StatusCodes = append(t.StatusCodes, code)
+	}
 }
 
+const (
+	// AuthTypeOAuth2 is the auth type for which an access
+	// token is requested from an OAuth2 token endpoint.
+	AuthTypeOAuth2 = "oauth2"
+	// AuthTypeSigV4 is the auth type for which requests
+	// are signed using AWS Signature Version 4.
+	AuthTypeSigV4 = "sigv4"
+	// AuthTypeHMAC is the auth type for which requests
+	// are signed using a HMAC signature.
+	AuthTypeHMAC = "hmac"
+	// AuthTypeDigest is the auth type for which requests
+	// are authenticated using HTTP Digest authentication.
+	AuthTypeDigest = "digest"
+)
+
 type AuthOptions struct {
 	Type     string
 	UserName string
 	Password string
 	Token    string
+
+	// The following options are only
+	// evaluated for the OAuth2 auth type.
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scope        string
+	GrantType    string
+	RefreshToken string
+
+	// The following options are only
+	// evaluated for the SigV4 auth type.
+	Region       string
+	Service      string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+
+	// The following options are only
+	// evaluated for the HMAC auth type.
+	Secret    string
+	Algorithm string
+	Header    string
+	Template  string
+	Encoding  string
+	Prefix    string
 }
 
 func AuthOptionsFromMap(m map[string]any) (opt AuthOptions, ok bool) {
@@ -91,9 +303,92 @@ func AuthOptionsFromMap(m map[string]any) (opt AuthOptions, ok bool) {
 		opt.Token = v
 	}
 
+	if v, ok := m["tokenurl"].(string); ok {
+		opt.TokenURL = v
+	}
+
+	if v, ok := m["clientid"].(string); ok {
+		opt.ClientID = v
+	}
+
+	if v, ok := m["clientsecret"].(string); ok {
+		opt.ClientSecret = v
+	}
+
+	if v, ok := m["scope"].(string); ok {
+		opt.Scope = v
+	}
+
+	if v, ok := m["granttype"].(string); ok {
+		opt.GrantType = v
+	}
+
+	if v, ok := m["refreshtoken"].(string); ok {
+		opt.RefreshToken = v
+	}
+
+	if v, ok := m["region"].(string); ok {
+		opt.Region = v
+	}
+
+	if v, ok := m["service"].(string); ok {
+		opt.Service = v
+	}
+
+	if v, ok := m["accesskey"].(string); ok {
+		opt.AccessKey = v
+	}
+
+	if v, ok := m["secretkey"].(string); ok {
+		opt.SecretKey = v
+	}
+
+	if v, ok := m["sessiontoken"].(string); ok {
+		opt.SessionToken = v
+	}
+
+	if v, ok := m["secret"].(string); ok {
+		opt.Secret = v
+	}
+
+	if v, ok := m["algorithm"].(string); ok {
+		opt.Algorithm = v
+	}
+
+	if v, ok := m["header"].(string); ok {
+		opt.Header = v
+	}
+
+	if v, ok := m["template"].(string); ok {
+		opt.Template = v
+	}
+
+	if v, ok := m["encoding"].(string); ok {
+		opt.Encoding = v
+	}
+
+	if v, ok := m["prefix"].(string); ok {
+		opt.Prefix = v
+	}
+
 	return opt, true
 }
 
+// Dynamic returns true when the Authorization header
+// can not be derived from the options alone but must
+// be obtained during the execution, i.e. by requesting
+// an OAuth2 access token or by signing the request.
+func (t AuthOptions) Dynamic() bool {
+	switch strings.ToLower(t.Type) {
+	case AuthTypeOAuth2, AuthTypeSigV4, AuthTypeHMAC, AuthTypeDigest:
+		return true
+	default:
+		return false
+	}
+}
+
+// HeaderValue returns the value of the Authorization
+// header for basic or token authorization.
 func (t AuthOptions) HeaderValue() string {
 	if t.UserName != "" && t.Password != "" {
 		v := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", t.UserName, t.Password)))
@@ -0,0 +1,96 @@
+package executor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-b12/goat/pkg/goatfile/ast"
+	"github.com/studio-b12/goat/pkg/requester"
+)
+
+func TestExecOptionsFromMap(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		opt, err := ExecOptionsFromMap(map[string]any{})
+		require.Nil(t, err, err)
+		assert.Equal(t, ExecOptions{Condition: true, RetryBackoff: 1,
+			PollInterval: time.Second, PollTimeout: time.Minute}, opt)
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		opt, err := ExecOptionsFromMap(map[string]any{
+			"delay":        int64(200),
+			"retry":        3,
+			"retrydelay":   "1s",
+			"retrybackoff": 1.5,
+			"retryon":      []any{int64(502), "503", "script"},
+		})
+		require.Nil(t, err, err)
+		assert.Equal(t, 200*time.Millisecond, opt.Delay)
+		assert.Equal(t, 3, opt.Retry)
+		assert.Equal(t, time.Second, opt.RetryDelay)
+		assert.Equal(t, 1.5, opt.RetryBackoff)
+		assert.Equal(t, RetryOn{StatusCodes: []int{502, 503}, Script: true}, opt.RetryOn)
+
+		assert.Equal(t, time.Second, opt.RetryDelayAt(1))
+		assert.Equal(t, 1500*time.Millisecond, opt.RetryDelayAt(2))
+		assert.Equal(t, 2250*time.Millisecond, opt.RetryDelayAt(3))
+	})
+
+	t.Run("retryon-single", func(t *testing.T) {
+		opt, err := ExecOptionsFromMap(map[string]any{"retryon": 503})
+		require.Nil(t, err, err)
+		assert.Equal(t, RetryOn{StatusCodes: []int{503}}, opt.RetryOn)
+
+		opt, err = ExecOptionsFromMap(map[string]any{"retryon": "script"})
+		require.Nil(t, err, err)
+		assert.Equal(t, RetryOn{Script: true}, opt.RetryOn)
+	})
+
+	t.Run("poll", func(t *testing.T) {
+		opt, err := ExecOptionsFromMap(map[string]any{
+			"until":        "response.Body.done",
+			"pollinterval": "500ms",
+			"polltimeout":  int64(0),
+		})
+		require.Nil(t, err, err)
+		assert.Equal(t, "response.Body.done", opt.Until)
+		assert.Equal(t, 500*time.Millisecond, opt.PollInterval)
+		assert.Equal(t, time.Duration(0), opt.PollTimeout)
+	})
+
+	t.Run("schema", func(t *testing.T) {
+		opt, err := ExecOptionsFromMap(map[string]any{
+			"schema":  "user.json",
+			"openapi": ast.FileDescriptor{Path: "openapi.yaml"},
+		})
+		require.Nil(t, err, err)
+		assert.Equal(t, "user.json", opt.Schema)
+		assert.Equal(t, "openapi.yaml", opt.OpenAPI)
+
+		_, err = ExecOptionsFromMap(map[string]any{"schema": int64(5)})
+		assert.ErrorContains(t, err, "invalid schema value type int64")
+
+		_, err = ExecOptionsFromMap(map[string]any{"openapi": true})
+		assert.ErrorContains(t, err, "invalid openapi value type bool")
+	})
+}
+
+func TestRequesterOptions(t *testing.T) {
+	var ex Executor
+	opt, err := ex.requesterOptions(map[string]any{
+		"cacert":     ast.FileDescriptor{Path: "certs/ca.pem"},
+		"clientcert": "/etc/certs/client.p12",
+	}, requester.Source{Path: "tests/staging/test.goat", Line: 3})
+	require.Nil(t, err, err)
+
+	assert.Equal(t, requester.TLSOptions{
+		CACert:     "tests/staging/certs/ca.pem",
+		ClientCert: "/etc/certs/client.p12",
+	}, opt.TLS)
+	assert.Equal(t, 3, opt.Source.Line)
+
+	_, err = ex.requesterOptions(map[string]any{"clientcert": true}, requester.Source{})
+	assert.ErrorContains(t, err, "invalid clientcert value type bool")
+}
@@ -0,0 +1,171 @@
+package executor
+
+import (
+	"sync"
+	"time"
+
+	"github.com/studio-b12/goat/pkg/engine"
+	"github.com/studio-b12/goat/pkg/goatfile"
+	"github.com/zekrotja/rogu"
+	"github.com/zekrotja/rogu/level"
+)
+
+// batchOutcome holds the result and error
+// of a single batch execution.
+type batchOutcome struct {
+	res Result
+	err error
+}
+
+// canceledBatch returns the outcome of a batch which
+// has not been started because the execution has been
+// canceled before. No requests, including the teardown
+// steps, are executed for such batches.
+func canceledBatch(gf goatfile.Goatfile) batchOutcome {
+	var res Result
+	res.Batches = append(res.Batches, BatchResult{
+		Path:     gf.Path,
+		Start:    time.Now(),
+		Canceled: true,
+	})
+	return batchOutcome{res: res, err: ErrCanceled}
+}
+
+// executeParallel executes the given Goatfiles concurrently
+// with up to t.Parallel batches at the same time. Each batch
+// is executed with its own cookie jar namespace and its own
+// copy of the initial parameters.
+//
+// The log output of each batch is buffered and written to
+// the executors logger when the batch has finished, so that
+// the log output stays grouped by batch.
+//
+// The returned outcomes are in the same order as the given
+// Goatfiles.
+func (t *Executor) executeParallel(
+	goatfiles []goatfile.Goatfile,
+	initialParams engine.State,
+	showTeardownParamErrors bool,
+) []batchOutcome {
+	results := make([]batchOutcome, len(goatfiles))
+
+	var (
+		wg     sync.WaitGroup
+		logMtx sync.Mutex
+		sem    = make(chan struct{}, t.Parallel)
+	)
+
+	for i, gf := range goatfiles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case <-t.ctx.Done():
+				results[i] = canceledBatch(gf)
+				return
+			case sem <- struct{}{}:
+			}
+			defer func() { <-sem }()
+
+			// The context might have been canceled while
+			// waiting for the semaphore.
+			if t.ctx.Err() != nil {
+				results[i] = canceledBatch(gf)
+				return
+			}
+
+			var buf bufferedLogWriter
+
+			bt := *t
+			bt.log = t.log.Copy().SetWriter(&buf)
+			bt.cookieNamespace = gf.Path
+
+			res, err := bt.executeBatch(gf, initialParams.Copy(), showTeardownParamErrors)
+			results[i] = batchOutcome{res: res, err: err}
+
+			logMtx.Lock()
+			defer logMtx.Unlock()
+			buf.flush(t.log)
+		}()
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+type bufferedLogEntry struct {
+	lvl       level.Level
+	fields    []*rogu.Field
+	tag       string
+	err       error
+	errFormat string
+	msg       string
+}
+
+// bufferedLogWriter implements rogu.Writer and
+// buffers all written log entries until they
+// are flushed into another logger.
+type bufferedLogWriter struct {
+	mtx     sync.Mutex
+	entries []bufferedLogEntry
+}
+
+var _ rogu.Writer = (*bufferedLogWriter)(nil)
+
+func (t *bufferedLogWriter) Write(
+	lvl level.Level,
+	fields []*rogu.Field,
+	tag string,
+	err error,
+	errFormat string,
+	_ string,
+	_ int,
+	msg string,
+) error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	// The passed fields are given back to a pool after
+	// writing, so they must be copied.
+	fieldsCopy := make([]*rogu.Field, 0, len(fields))
+	for _, f := range fields {
+		fieldsCopy = append(fieldsCopy, &rogu.Field{Key: f.Key, Val: f.Val})
+	}
+
+	t.entries = append(t.entries, bufferedLogEntry{
+		lvl:       lvl,
+		fields:    fieldsCopy,
+		tag:       tag,
+		err:       err,
+		errFormat: errFormat,
+		msg:       msg,
+	})
+
+	return nil
+}
+
+// flush writes all buffered entries into
+// the given logger and resets the buffer.
+func (t *bufferedLogWriter) flush(l rogu.Logger) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	for _, e := range t.entries {
+		event := l.WithLevel(e.lvl).Tag(e.tag)
+		for _, f := range e.fields {
+			event.Field(f.Key, f.Val)
+		}
+		if e.err != nil {
+			if e.errFormat != "" {
+				event.Errf(e.err, e.errFormat)
+			} else {
+				event.Err(e.err)
+			}
+		}
+		event.Msg(e.msg)
+	}
+
+	t.entries = nil
+}
@@ -0,0 +1,109 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/studio-b12/goat/pkg/engine"
+	"github.com/studio-b12/goat/pkg/errs"
+	"github.com/studio-b12/goat/pkg/requester"
+	"github.com/zekrotja/rogu"
+	"github.com/zekrotja/rogu/level"
+)
+
+type namespaceRecorder struct {
+	mtx        sync.Mutex
+	namespaces map[string]string
+}
+
+func (t *namespaceRecorder) Do(req *http.Request, opt requester.Options) (*http.Response, error) {
+	t.mtx.Lock()
+	t.namespaces[req.URL.Path] = opt.CookieJarNamespace
+	t.mtx.Unlock()
+
+	// Delay earlier batches longer so that they
+	// finish after the later ones.
+	if strings.HasSuffix(req.URL.Path, "/a") {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	status := http.StatusOK
+	if strings.HasSuffix(req.URL.Path, "/fail") {
+		status = http.StatusInternalServerError
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("")),
+	}, nil
+}
+
+func TestExecuteParallel(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"a.goat":    "GET http://localhost/a",
+		"b.goat":    "GET http://localhost/fail\n\n[Script]\nassert(response.StatusCode == 200);",
+		"c.goat":    "GET http://localhost/c",
+		"d.goat":    "GET http://localhost/fail\n\n[Script]\nassert(response.StatusCode == 200);",
+		"_lib.goat": "GET http://localhost/lib",
+	}
+	for name, content := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		assert.Nil(t, err, err)
+	}
+
+	req := &namespaceRecorder{namespaces: map[string]string{}}
+
+	exec := New(context.Background(), engine.NewGoja, req)
+	exec.Parallel = 4
+
+	res, err := exec.Execute([]string{dir}, engine.State{}, true)
+
+	batchErr, ok := errs.As[*BatchResultError](err)
+	assert.True(t, ok)
+	assert.Equal(t, 4, batchErr.Total)
+	assert.Equal(t, []string{filepath.Join(dir, "b.goat"), filepath.Join(dir, "d.goat")}, batchErr.FailedFiles())
+
+	assert.Equal(t, 4, len(res.Batches))
+	for i, name := range []string{"a.goat", "b.goat", "c.goat", "d.goat"} {
+		assert.Equal(t, filepath.Join(dir, name), res.Batches[i].Path)
+	}
+	assert.Equal(t, 4, res.All())
+	assert.Equal(t, 2, res.Failed())
+
+	assert.Equal(t, filepath.Join(dir, "a.goat"), req.namespaces["/a"])
+	assert.Equal(t, filepath.Join(dir, "c.goat"), req.namespaces["/c"])
+}
+
+func TestBufferedLogWriter(t *testing.T) {
+	var buf bufferedLogWriter
+
+	l := rogu.NewLogger(&buf).SetLevel(level.Debug)
+	l.Info().Field("foo", "bar").Msg("first")
+	l.Tagged("tag").Debug().Msg("second")
+	l.Trace().Msg("filtered")
+
+	var out bufferedLogWriter
+	buf.flush(rogu.NewLogger(&out).SetLevel(level.Debug))
+
+	assert.Equal(t, 0, len(buf.entries))
+	assert.Equal(t, 2, len(out.entries))
+
+	assert.Equal(t, "first", out.entries[0].msg)
+	assert.Equal(t, level.Info, out.entries[0].lvl)
+	assert.Equal(t, "foo", out.entries[0].fields[0].Key)
+	assert.Equal(t, "bar", out.entries[0].fields[0].Val)
+
+	assert.Equal(t, "second", out.entries[1].msg)
+	assert.Equal(t, "tag", out.entries[1].tag)
+}
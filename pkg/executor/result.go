@@ -2,8 +2,11 @@ package executor
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/studio-b12/goat/pkg/clr"
+	"github.com/studio-b12/goat/pkg/engine"
+	"github.com/studio-b12/goat/pkg/goatfile"
 	"github.com/zekrotja/rogu/log"
 )
 
@@ -11,12 +14,17 @@ type Result struct {
 	Setup    ResultSection
 	Teardown ResultSection
 	Tests    ResultSection
+
+	// Batches contains the results of each
+	// executed Goatfile batch.
+	Batches []BatchResult
 }
 
 func (t *Result) Merge(other Result) {
 	t.Setup.Merge(other.Setup)
 	t.Teardown.Merge(other.Teardown)
 	t.Tests.Merge(other.Tests)
+	t.Batches = append(t.Batches, other.Batches...)
 }
 
 func (t Result) All() int {
@@ -31,13 +39,28 @@ func (t Result) Successfull() int {
 	return t.Setup.Successfull() + t.Teardown.Successfull() + t.Tests.Successfull()
 }
 
+func (t Result) Skipped() int {
+	return t.Setup.Skipped() + t.Teardown.Skipped() + t.Tests.Skipped()
+}
+
 func (t Result) Log() {
 	c := clr.ColorFGGreen
 	if t.Failed() > 0 {
 		c = clr.ColorFGRed
 	}
 
-	log.Info().
+	entry := log.Info()
+	if skipped := t.Skipped(); skipped > 0 {
+		entry.Field("skipped", skipped)
+	}
+	if checks := t.Sum().Checks(); checks.Passed+checks.Failed > 0 {
+		entry.Field("checks", fmt.Sprintf("%d/%d", checks.Passed, checks.Failed))
+	}
+	if scripted := t.Sum().ScriptRequests(); scripted > 0 {
+		entry.Field("scripted", scripted)
+	}
+
+	entry.
 		Field("setup", fmt.Sprintf("%d/%d", t.Setup.Successfull(), t.Setup.Failed())).
 		Field("tests", fmt.Sprintf("%d/%d", t.Tests.Successfull(), t.Tests.Failed())).
 		Field("teardown", fmt.Sprintf("%d/%d", t.Teardown.Successfull(), t.Teardown.Failed())).
@@ -53,32 +76,180 @@ func (t Result) Sum() (res ResultSection) {
 	return res
 }
 
+// BatchResult holds the results of the execution
+// of a single Goatfile batch.
+type BatchResult struct {
+	Path     string
+	Start    time.Time
+	Duration time.Duration
+
+	// Canceled is true when the execution has been
+	// canceled before the batch was completed.
+	Canceled bool
+
+	Setup    ResultSection
+	Teardown ResultSection
+	Tests    ResultSection
+}
+
+// Actions returns the results of all actions
+// executed in the batch in order of the
+// sections setup, tests and teardown.
+func (t BatchResult) Actions() []ActionResult {
+	actions := make([]ActionResult, 0,
+		len(t.Setup.Actions)+len(t.Tests.Actions)+len(t.Teardown.Actions))
+	actions = append(actions, t.Setup.Actions...)
+	actions = append(actions, t.Tests.Actions...)
+	actions = append(actions, t.Teardown.Actions...)
+	return actions
+}
+
+// ResultSection holds the results of all actions
+// executed in a section.
 type ResultSection struct {
-	failed int
-	all    int
+	// Actions contains the results of the executed
+	// requests and execute statements in the order
+	// of their execution.
+	Actions []ActionResult
 }
 
 func (t *ResultSection) Merge(other ResultSection) {
-	t.failed += other.failed
-	t.all += other.all
+	t.Actions = append(t.Actions, other.Actions...)
+}
+
+// Add appends the given action result to
+// the section.
+func (t *ResultSection) Add(act ActionResult) {
+	t.Actions = append(t.Actions, act)
 }
 
+// Inc adds a request without further details
+// to the section.
+//
+// Deprecated: Use Add with the ActionResult of
+// the executed request instead.
 func (t *ResultSection) Inc() {
-	t.all++
+	t.Add(ActionResult{Type: goatfile.ActionRequest})
 }
 
+// IncFailed marks the last added request which has
+// not failed as failed. If there is none, a failed
+// request without further details is added.
+//
+// Deprecated: Use Add with the ActionResult of
+// the executed request, containing its error,
+// instead.
 func (t *ResultSection) IncFailed() {
-	t.failed++
+	for i := len(t.Actions) - 1; i >= 0; i-- {
+		if act := &t.Actions[i]; act.Type == goatfile.ActionRequest && !act.Failed() {
+			act.Err = errRequestFailed
+			return
+		}
+	}
+	t.Add(ActionResult{Type: goatfile.ActionRequest, Err: errRequestFailed})
 }
 
+// All returns the number of executed requests
+// including requests executed in imported
+// Goatfiles.
 func (t ResultSection) All() int {
-	return t.all
+	return countRequests(t.Actions, func(ActionResult) bool { return true })
 }
 
+// Failed returns the number of failed requests
+// including requests executed in imported
+// Goatfiles.
 func (t ResultSection) Failed() int {
-	return t.failed
+	return countRequests(t.Actions, ActionResult.Failed)
+}
+
+// Skipped returns the number of requests which
+// have been skipped due to their condition
+// including requests executed in imported
+// Goatfiles.
+func (t ResultSection) Skipped() int {
+	return countRequests(t.Actions, func(act ActionResult) bool { return act.Skipped })
 }
 
 func (t ResultSection) Successfull() int {
-	return t.all - t.failed
+	return t.All() - t.Failed()
+}
+
+// Checks returns the sum of passed and failed
+// assertions of all actions.
+func (t ResultSection) Checks() (c engine.Checks) {
+	for _, act := range t.Actions {
+		c = c.Add(act.Checks)
+	}
+	return c
+}
+
+// ScriptRequests returns the number of HTTP
+// requests performed by scripts of all actions.
+func (t ResultSection) ScriptRequests() (n int) {
+	for _, act := range t.Actions {
+		n += act.ScriptRequests
+	}
+	return n
+}
+
+// ActionResult holds the result of a single
+// executed request or execute statement.
+type ActionResult struct {
+	Type    goatfile.ActionType
+	Name    string
+	Section goatfile.SectionName
+	Path    string
+	Line    int
+
+	// Method and URI are only set for requests. The URI
+	// contains the request URI after the substitution
+	// of parameters.
+	Method string
+	URI    string
+
+	Start      time.Time
+	Duration   time.Duration
+	StatusCode int
+	Skipped    bool
+	Err        error
+
+	// Checks contains the number of passed and
+	// failed assertions of the scripts of a
+	// request or of all requests executed by
+	// an execute statement.
+	Checks engine.Checks
+
+	// ScriptRequests contains the number of HTTP
+	// requests performed by the scripts of a
+	// request or of all requests executed by an
+	// execute statement.
+	ScriptRequests int
+
+	// Children contains the results of the actions
+	// executed in the imported Goatfile of an
+	// execute statement.
+	Children []ActionResult
+}
+
+// Failed returns true when the action
+// has returned an error.
+func (t ActionResult) Failed() bool {
+	return t.Err != nil
+}
+
+// countRequests returns the number of requests in the given
+// list of actions and their children for which pred
+// returns true.
+func countRequests(actions []ActionResult, pred func(ActionResult) bool) (n int) {
+	for _, act := range actions {
+		if act.Type == goatfile.ActionExecute {
+			n += countRequests(act.Children, pred)
+			continue
+		}
+		if pred(act) {
+			n++
+		}
+	}
+	return n
 }
@@ -1,22 +1,61 @@
 package executor
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/studio-b12/goat/pkg/goatfile"
 )
 
 func Test(t *testing.T) {
 	a := Result{}
 	b := Result{
 		Tests: ResultSection{
-			all:    3,
-			failed: 2,
+			Actions: []ActionResult{
+				{Type: goatfile.ActionRequest, Err: errors.New("failed")},
+				{Type: goatfile.ActionRequest, Err: errors.New("failed")},
+				{Type: goatfile.ActionRequest},
+			},
 		},
 	}
 
 	a.Merge(b)
 
-	assert.Equal(t, 3, a.Tests.all)
-	assert.Equal(t, 2, a.Tests.failed)
+	assert.Equal(t, 3, a.Tests.All())
+	assert.Equal(t, 2, a.Tests.Failed())
+}
+
+func TestResultSection(t *testing.T) {
+	s := ResultSection{
+		Actions: []ActionResult{
+			{Type: goatfile.ActionRequest},
+			{Type: goatfile.ActionRequest, Skipped: true},
+			{Type: goatfile.ActionExecute, Err: errors.New("failed"), Children: []ActionResult{
+				{Type: goatfile.ActionRequest},
+				{Type: goatfile.ActionRequest, Err: errors.New("failed")},
+				{Type: goatfile.ActionExecute, Children: []ActionResult{
+					{Type: goatfile.ActionRequest, Skipped: true},
+				}},
+			}},
+		},
+	}
+
+	assert.Equal(t, 5, s.All())
+	assert.Equal(t, 1, s.Failed())
+	assert.Equal(t, 2, s.Skipped())
+	assert.Equal(t, 4, s.Successfull())
+}
+
+func TestResultSection_inc(t *testing.T) {
+	var s ResultSection
+
+	s.Inc()
+	s.Inc()
+	s.IncFailed()
+	s.Inc()
+
+	assert.Equal(t, 3, s.All())
+	assert.Equal(t, 1, s.Failed())
+	assert.Equal(t, 2, s.Successfull())
 }
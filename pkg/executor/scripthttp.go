@@ -0,0 +1,69 @@
+package executor
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/studio-b12/goat/pkg/engine"
+	"github.com/studio-b12/goat/pkg/errs"
+	"github.com/studio-b12/goat/pkg/goatfile"
+	"github.com/studio-b12/goat/pkg/requester"
+)
+
+// scriptHTTPHandler returns the handler performing HTTP
+// requests issued by the scripts of the given request.
+//
+// Scripted requests are sent via the requester of the
+// executor using the options of the given request,
+// overridden by the options passed by the script. The
+// number of scripted requests is recorded into rec.
+func (t *Executor) scriptHTTPHandler(req *goatfile.Request, rec *ActionResult) engine.HTTPHandler {
+	return func(sreq engine.HTTPRequest) (any, error) {
+		httpReq, err := http.NewRequest(sreq.Method, sreq.URL, bytes.NewReader(sreq.Body))
+		if err != nil {
+			return nil, err
+		}
+		for k, vals := range sreq.Header {
+			httpReq.Header[k] = vals
+		}
+
+		options := make(map[string]any, len(req.Options)+len(sreq.Options))
+		for k, v := range req.Options {
+			options[k] = v
+		}
+		for k, v := range sreq.Options {
+			options[k] = v
+		}
+
+		reqOpts, err := t.requesterOptions(options,
+			requester.Source{Path: rec.Path, Line: rec.Line, Section: string(rec.Section)})
+		if err != nil {
+			return nil, errs.WithPrefix("failed parsing options:", err)
+		}
+
+		rec.ScriptRequests++
+
+		httpResp, err := t.req.Do(httpReq, reqOpts)
+		if err != nil {
+			if isTimeout(err) {
+				err = NewTimeoutError(err)
+			}
+			return nil, err
+		}
+		defer httpResp.Body.Close()
+
+		t.log.Debug().
+			Field("req", rec.Name).
+			Field("method", httpReq.Method).
+			Field("url", httpReq.URL).
+			Field("status", httpResp.StatusCode).
+			Msg("Scripted request completed")
+
+		resp, err := FromHttpResponse(httpResp, options)
+		if err != nil {
+			return nil, errs.WithPrefix("response interpretation failed:", err)
+		}
+
+		return resp, nil
+	}
+}
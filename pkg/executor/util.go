@@ -3,14 +3,18 @@ package executor
 import (
 	"encoding/json"
 	"encoding/xml"
+	"fmt"
+	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/studio-b12/goat/pkg/clr"
+	"github.com/studio-b12/goat/pkg/engine"
 	"github.com/studio-b12/goat/pkg/errs"
-	"github.com/zekrotja/rogu/log"
+	"github.com/zekrotja/rogu"
 )
 
-func printSeparator(head string) {
+func printSeparator(log rogu.Logger, head string) {
 	const lenSpacerTotal = 100
 
 	lenSpacer := lenSpacerTotal - 2 - len(head)
@@ -47,3 +51,39 @@ func parseBody(data []byte, responseType string) (any, error) {
 		return string(data), nil
 	}
 }
+
+// resolvePath returns the given file path resolved
+// against the directory of the Goatfile at gfPath
+// when the file path is relative.
+func resolvePath(gfPath, pth string) string {
+	if filepath.IsAbs(pth) {
+		return pth
+	}
+	return filepath.Join(path.Dir(gfPath), pth)
+}
+
+// jsonBody returns the body of the given response
+// as parsed JSON value. Bodies which have not been
+// parsed on receiving, for example because of a
+// missing JSON content type, are parsed if they
+// contain valid JSON.
+func jsonBody(resp Response) any {
+	if _, ok := resp.Body.(string); ok {
+		var body any
+		if err := json.Unmarshal(resp.BodyRaw, &body); err == nil {
+			return body
+		}
+	}
+	return resp.Body
+}
+
+// sourceFields returns log fields quoting the line
+// of script code which caused the given error, if
+// known.
+func sourceFields(err error) []any {
+	ex, ok := errs.As[engine.Exception](err)
+	if !ok || ex.Pos.Source == "" {
+		return nil
+	}
+	return []any{"source", fmt.Sprintf("%d | %s", ex.Pos.Line, ex.Pos.Source)}
+}
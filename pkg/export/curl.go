@@ -0,0 +1,70 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// WriteCurl writes the given requests as curl
+// commands to w. Each command is preceded by a
+// comment containing the position of the request.
+func WriteCurl(w io.Writer, reqs []Request) error {
+	for i, req := range reqs {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(w, curlCommand(req)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func curlCommand(req Request) string {
+	args := []string{"curl"}
+
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodHead:
+		args = append(args, "--head")
+	default:
+		args = append(args, "-X "+shellQuote(req.Method))
+	}
+
+	args = append(args, shellQuote(req.URL))
+
+	for _, name := range sortedHeader(req.Header) {
+		for _, v := range req.Header[name] {
+			args = append(args, "-H "+shellQuote(name+": "+v))
+		}
+	}
+
+	switch body := req.Body.(type) {
+	case TextBody:
+		args = append(args, "--data-raw "+shellQuote(string(body)))
+	case FileBody:
+		args = append(args, "--data-binary "+shellQuote("@"+string(body)))
+	case FormBody:
+		for _, f := range body {
+			if f.File == "" {
+				args = append(args, "--form-string "+shellQuote(f.Name+"="+f.Value))
+				continue
+			}
+			v := f.Name + "=@" + f.File
+			if f.ContentType != "" {
+				v += ";type=" + f.ContentType
+			}
+			args = append(args, "-F "+shellQuote(v))
+		}
+	case URLEncodedBody:
+		for _, f := range body {
+			args = append(args, "--data-urlencode "+shellQuote(f.Name+"="+f.Value))
+		}
+	}
+
+	return fmt.Sprintf("# %s:%d\n%s\n", req.Path, req.Line, strings.Join(args, " \\\n  "))
+}
@@ -0,0 +1,261 @@
+// Package export implements exporting the requests
+// of Goatfiles into the formats of other tools.
+package export
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/studio-b12/goat/pkg/engine"
+	"github.com/studio-b12/goat/pkg/executor"
+	"github.com/studio-b12/goat/pkg/goatfile"
+	"github.com/studio-b12/goat/pkg/goatfile/ast"
+)
+
+var (
+	ErrConditionNotMet = errors.New("request would be skipped due to its condition")
+	ErrRawData         = errors.New("request body contains raw data from the state")
+	ErrDynamicAuth     = errors.New("authorization must be obtained during the execution")
+)
+
+// ResolveError is returned when a request can not be
+// resolved with the given parameters, for example when
+// it depends on state set by scripts at runtime.
+type ResolveError struct {
+	Path string
+	Line int
+	Err  error
+}
+
+func (t ResolveError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", t.Path, t.Line, t.Err.Error())
+}
+
+func (t ResolveError) Unwrap() error {
+	return t.Err
+}
+
+// SkipError is returned when a request is not exported
+// because it would be skipped during the execution due
+// to its condition. Unlike a ResolveError, it does not
+// indicate a problem with the request.
+type SkipError struct {
+	Path string
+	Line int
+}
+
+func (t SkipError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", t.Path, t.Line, ErrConditionNotMet.Error())
+}
+
+func (t SkipError) Unwrap() error {
+	return ErrConditionNotMet
+}
+
+// Request is a request with all templates resolved
+// which can be exported.
+type Request struct {
+	// Path and Line of the request in the Goatfile.
+	Path string
+	Line int
+
+	Method string
+	URL    string
+	Header http.Header
+	Body   Body
+}
+
+// Body is the body of an exported request. It is
+// either nil, TextBody, FileBody, FormBody or
+// URLEncodedBody.
+type Body interface{}
+
+// TextBody contains the body as text.
+type TextBody string
+
+// FileBody contains the path of the file
+// containing the body.
+type FileBody string
+
+// FormBody contains multipart form fields.
+type FormBody []FormField
+
+// URLEncodedBody contains URL encoded form fields.
+type URLEncodedBody []FormField
+
+// FormField is a field of a form. If File is set,
+// the field contains the contents of the file at
+// the given path.
+type FormField struct {
+	Name        string
+	Value       string
+	File        string
+	ContentType string
+}
+
+// Requests returns all requests of the given Goatfile
+// in order of execution merged with the defaults of the
+// Goatfile. Execute statements are not followed.
+func Requests(gf goatfile.Goatfile) []*goatfile.Request {
+	var reqs []*goatfile.Request
+
+	for _, actions := range [][]goatfile.Action{gf.Setup, gf.Tests, gf.Teardown} {
+		for _, act := range actions {
+			req, ok := act.(*goatfile.Request)
+			if !ok {
+				continue
+			}
+			req.Merge(gf.Defaults)
+			reqs = append(reqs, req)
+		}
+	}
+
+	return reqs
+}
+
+// Resolve substitutes the templates of the given request
+// with the given state and returns the resolved request.
+//
+// Because scripts are not executed, templates in the
+// request must only use values from the given state.
+// Otherwise, a ResolveError is returned. When the
+// condition of the request is not met, a SkipError
+// is returned.
+func Resolve(req *goatfile.Request, state engine.State) (Request, error) {
+	res, err := resolve(req, state)
+	if errors.Is(err, ErrConditionNotMet) {
+		return Request{}, SkipError{Path: req.Path, Line: req.PosLine}
+	}
+	if err != nil {
+		return Request{}, ResolveError{Path: req.Path, Line: req.PosLine, Err: err}
+	}
+	return res, nil
+}
+
+func resolve(req *goatfile.Request, state engine.State) (Request, error) {
+	err := req.SubstituteWithParams(state)
+	if err != nil {
+		return Request{}, err
+	}
+
+	execOpts, err := executor.ExecOptionsFromMap(req.Options)
+	if err != nil {
+		return Request{}, err
+	}
+	if !execOpts.Condition {
+		return Request{}, ErrConditionNotMet
+	}
+
+	uri, err := req.URL()
+	if err != nil {
+		return Request{}, err
+	}
+
+	res := Request{
+		Path:   req.Path,
+		Line:   req.PosLine,
+		Method: req.Method,
+		URL:    uri.String(),
+		Header: req.Header.Clone(),
+	}
+	if res.Header == nil {
+		res.Header = http.Header{}
+	}
+
+	switch body := req.Body.(type) {
+	case goatfile.StringContent:
+		res.Body = TextBody(body)
+	case goatfile.FileContent:
+		pth, err := body.FilePath()
+		if err != nil {
+			return Request{}, err
+		}
+		res.Body = FileBody(pth)
+	case goatfile.FormData:
+		res.Body, err = formBody(body)
+		if err != nil {
+			return Request{}, err
+		}
+		// The multipart content type contains the boundary
+		// of the generated body, so it must be left to the
+		// tool which builds the body.
+		for k, v := range res.Header {
+			if strings.EqualFold(k, "Content-Type") && len(v) > 0 && strings.HasPrefix(v[0], "multipart/form-data") {
+				delete(res.Header, k)
+			}
+		}
+	case goatfile.FormUrlEncoded:
+		res.Body = URLEncodedBody(fields(body.Fields()))
+	case goatfile.RawContent:
+		return Request{}, ErrRawData
+	}
+
+	if authOpts, ok := executor.AuthOptionsFromMap(req.Auth); ok {
+		if authOpts.Dynamic() {
+			return Request{}, fmt.Errorf("%w (auth type '%s')", ErrDynamicAuth, authOpts.Type)
+		}
+		res.Header.Set("Authorization", authOpts.HeaderValue())
+	}
+
+	return res, nil
+}
+
+func formBody(body goatfile.FormData) (FormBody, error) {
+	var res FormBody
+
+	for _, f := range fields(body.Fields()) {
+		switch v := body.Fields()[f.Name].(type) {
+		case ast.FileDescriptor:
+			pth, err := body.FilePath(v)
+			if err != nil {
+				return nil, err
+			}
+			f.Value = ""
+			f.File = pth
+			f.ContentType = v.ContentType
+		case ast.RawDescriptor:
+			return nil, ErrRawData
+		}
+		res = append(res, f)
+	}
+
+	return res, nil
+}
+
+// fields returns the given form fields ordered by name.
+func fields(m map[string]any) []FormField {
+	res := make([]FormField, 0, len(m))
+	for k, v := range m {
+		res = append(res, FormField{Name: k, Value: fmt.Sprint(v)})
+	}
+	slices.SortFunc(res, func(a, b FormField) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return res
+}
+
+// sortedHeader returns the header names of
+// the given header in alphabetical order.
+func sortedHeader(header http.Header) []string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// shellQuote quotes the given value to be passed
+// as a single argument in POSIX shells.
+func shellQuote(v string) string {
+	if v != "" && strings.IndexFunc(v, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			strings.ContainsRune("-_./:@%+=,", r))
+	}) == -1 {
+		return v
+	}
+	return "'" + strings.ReplaceAll(v, "'", `'\''`) + "'"
+}
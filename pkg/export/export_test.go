@@ -0,0 +1,258 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-b12/goat/pkg/engine"
+	"github.com/studio-b12/goat/pkg/goatfile"
+	"github.com/studio-b12/goat/pkg/har"
+)
+
+func resolveAll(t *testing.T, raw string, state engine.State) ([]Request, []error) {
+	t.Helper()
+
+	gf, err := goatfile.Unmarshal(raw, "test.goat")
+	require.Nil(t, err, err)
+
+	var (
+		reqs []Request
+		errs []error
+	)
+	for _, req := range Requests(gf) {
+		res, err := Resolve(req, state)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		reqs = append(reqs, res)
+	}
+
+	return reqs, errs
+}
+
+func TestResolve(t *testing.T) {
+	t.Run("general", func(t *testing.T) {
+		raw := "### Defaults\n\n" +
+			"[Header]\nX-Default: yes\n\n" +
+			"### Tests\n\n" +
+			"POST {{.instance}}/users\n\n" +
+			"[QueryParams]\nlimit = {{.limit}}\n\n" +
+			"[Auth]\ntype = \"bearer\"\ntoken = \"{{.token}}\"\n\n" +
+			"[Header]\nContent-Type: application/json\n\n" +
+			"[Body]\n```\n{\"name\": \"{{.name}}\"}\n```\n"
+
+		reqs, errs := resolveAll(t, raw, engine.State{
+			"instance": "http://localhost",
+			"limit":    10,
+			"token":    "abc",
+			"name":     "bob",
+		})
+		assert.Empty(t, errs)
+		require.Len(t, reqs, 1)
+
+		req := reqs[0]
+		assert.Equal(t, "test.goat", req.Path)
+		assert.Equal(t, "POST", req.Method)
+		assert.Equal(t, "http://localhost/users?limit=10", req.URL)
+		assert.Equal(t, "yes", req.Header.Get("X-Default"))
+		assert.Equal(t, "bearer abc", req.Header.Get("Authorization"))
+		assert.Equal(t, TextBody("{\"name\": \"bob\"}\n"), req.Body)
+	})
+
+	t.Run("runtime-state", func(t *testing.T) {
+		raw := "GET {{.instance}}/users\n\n" +
+			"---\n\n" +
+			"GET {{.instance}}/users/{{.response.Body.id}}\n"
+
+		reqs, errs := resolveAll(t, raw, engine.State{"instance": "http://localhost"})
+		assert.Len(t, reqs, 1)
+		require.Len(t, errs, 1)
+
+		resErr, ok := errs[0].(ResolveError)
+		require.True(t, ok)
+		assert.Equal(t, "test.goat", resErr.Path)
+		assert.Equal(t, 5, resErr.Line)
+	})
+
+	t.Run("condition", func(t *testing.T) {
+		raw := "GET http://localhost\n\n" +
+			"[Options]\ncondition = {{.enabled}}\n"
+
+		reqs, errs := resolveAll(t, raw, engine.State{"enabled": false})
+		assert.Empty(t, reqs)
+		require.Len(t, errs, 1)
+		assert.ErrorIs(t, errs[0], ErrConditionNotMet)
+		assert.Equal(t, SkipError{Path: "test.goat", Line: 1}, errs[0])
+
+		reqs, errs = resolveAll(t, raw, engine.State{"enabled": true})
+		assert.Empty(t, errs)
+		assert.Len(t, reqs, 1)
+	})
+
+	t.Run("raw-data", func(t *testing.T) {
+		raw := "POST http://localhost\n\n" +
+			"[Body]\n$body\n"
+
+		reqs, errs := resolveAll(t, raw, engine.State{})
+		assert.Empty(t, reqs)
+		require.Len(t, errs, 1)
+		assert.ErrorIs(t, errs[0], ErrRawData)
+	})
+
+	t.Run("dynamic-auth", func(t *testing.T) {
+		for _, typ := range []string{"oauth2", "sigv4", "hmac", "digest"} {
+			raw := "GET http://localhost\n\n" +
+				"[Auth]\ntype = \"" + typ + "\"\n"
+
+			reqs, errs := resolveAll(t, raw, engine.State{})
+			assert.Empty(t, reqs, typ)
+			require.Len(t, errs, 1, typ)
+			assert.ErrorIs(t, errs[0], ErrDynamicAuth, typ)
+			assert.IsType(t, ResolveError{}, errs[0], typ)
+		}
+	})
+
+	t.Run("form-data", func(t *testing.T) {
+		raw := "POST http://localhost\n\n" +
+			"[Header]\nContent-Type: multipart/form-data; boundary=foo\n\n" +
+			"[FormData]\nname = \"{{.name}}\"\nfile = @data/a.txt:text/plain\n"
+
+		reqs, errs := resolveAll(t, raw, engine.State{"name": "bob"})
+		assert.Empty(t, errs)
+		require.Len(t, reqs, 1)
+
+		assert.Empty(t, reqs[0].Header.Get("Content-Type"))
+		assert.Equal(t, FormBody{
+			{Name: "file", File: "data/a.txt", ContentType: "text/plain"},
+			{Name: "name", Value: "bob"},
+		}, reqs[0].Body)
+	})
+}
+
+func TestWriteCurl(t *testing.T) {
+	reqs := []Request{
+		{
+			Path:   "test.goat",
+			Line:   1,
+			Method: "POST",
+			URL:    "http://localhost/users?a=1&b=2",
+			Header: map[string][]string{"Content-Type": {"application/json"}},
+			Body:   TextBody(`{"name": "it's"}`),
+		},
+		{
+			Path:   "test.goat",
+			Line:   5,
+			Method: "GET",
+			URL:    "http://localhost/users",
+		},
+		{
+			Path:   "test.goat",
+			Line:   7,
+			Method: "PUT",
+			URL:    "http://localhost/upload",
+			Body: FormBody{
+				{Name: "file", File: "a.txt", ContentType: "text/plain"},
+				{Name: "name", Value: "bob"},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := WriteCurl(&buf, reqs)
+	assert.Nil(t, err, err)
+	assert.Equal(t,
+		"# test.goat:1\n"+
+			"curl \\\n"+
+			"  -X POST \\\n"+
+			"  'http://localhost/users?a=1&b=2' \\\n"+
+			"  -H 'Content-Type: application/json' \\\n"+
+			"  --data-raw '{\"name\": \"it'\\''s\"}'\n"+
+			"\n"+
+			"# test.goat:5\n"+
+			"curl \\\n"+
+			"  http://localhost/users\n"+
+			"\n"+
+			"# test.goat:7\n"+
+			"curl \\\n"+
+			"  -X PUT \\\n"+
+			"  http://localhost/upload \\\n"+
+			"  -F 'file=@a.txt;type=text/plain' \\\n"+
+			"  --form-string name=bob\n",
+		buf.String())
+}
+
+func TestWriteHTTPie(t *testing.T) {
+	reqs := []Request{
+		{
+			Path:   "test.goat",
+			Line:   1,
+			Method: "POST",
+			URL:    "http://localhost/users",
+			Header: map[string][]string{"X-Foo": {"bar baz"}},
+			Body:   URLEncodedBody{{Name: "name", Value: "bob"}},
+		},
+		{
+			Path:   "test.goat",
+			Line:   5,
+			Method: "PUT",
+			URL:    "http://localhost/users",
+			Body:   FileBody("body.json"),
+		},
+	}
+
+	var buf bytes.Buffer
+	err := WriteHTTPie(&buf, reqs)
+	assert.Nil(t, err, err)
+	assert.Equal(t,
+		"# test.goat:1\n"+
+			"http \\\n"+
+			"  --ignore-stdin \\\n"+
+			"  --form \\\n"+
+			"  POST \\\n"+
+			"  http://localhost/users \\\n"+
+			"  'X-Foo:bar baz' \\\n"+
+			"  name=bob\n"+
+			"\n"+
+			"# test.goat:5\n"+
+			"http \\\n"+
+			"  PUT \\\n"+
+			"  http://localhost/users \\\n"+
+			"  < body.json\n",
+		buf.String())
+}
+
+func TestWriteHAR(t *testing.T) {
+	reqs := []Request{
+		{
+			Path:   "test.goat",
+			Line:   1,
+			Method: "POST",
+			URL:    "http://localhost/users?limit=10",
+			Header: map[string][]string{"Content-Type": {"application/json"}},
+			Body:   TextBody(`{"name": "bob"}`),
+		},
+	}
+
+	var buf bytes.Buffer
+	err := WriteHAR(&buf, reqs, har.Creator{Name: "goat", Version: "test"})
+	assert.Nil(t, err, err)
+
+	var archive har.HAR
+	err = json.Unmarshal(buf.Bytes(), &archive)
+	require.Nil(t, err, err)
+
+	assert.Equal(t, har.Version, archive.Log.Version)
+	assert.Equal(t, har.Creator{Name: "goat", Version: "test"}, archive.Log.Creator)
+	require.Len(t, archive.Log.Entries, 1)
+
+	entry := archive.Log.Entries[0]
+	assert.Equal(t, "test.goat:1", entry.Comment)
+	assert.Equal(t, "POST", entry.Request.Method)
+	assert.Equal(t, []har.NameValue{{Name: "limit", Value: "10"}}, entry.Request.QueryString)
+	assert.Equal(t, []har.NameValue{{Name: "Content-Type", Value: "application/json"}}, entry.Request.Headers)
+	assert.Equal(t, &har.PostData{MimeType: "application/json", Text: `{"name": "bob"}`}, entry.Request.PostData)
+}
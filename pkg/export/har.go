@@ -0,0 +1,101 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/studio-b12/goat/pkg/har"
+)
+
+// WriteHAR writes the given requests as HTTP Archive
+// created by the given creator to w. Because the requests
+// have not been executed, the responses of the entries
+// are empty.
+//
+// The contents of file bodies are read into the archive.
+func WriteHAR(w io.Writer, reqs []Request, creator har.Creator) error {
+	archive := har.New(creator)
+
+	now := time.Now()
+	for _, req := range reqs {
+		entry, err := harEntry(req, now)
+		if err != nil {
+			return err
+		}
+		archive.Add(entry)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(archive)
+}
+
+func harEntry(req Request, started time.Time) (har.Entry, error) {
+	uri, err := url.Parse(req.URL)
+	if err != nil {
+		return har.Entry{}, err
+	}
+
+	hreq := har.Request{
+		Method:      req.Method,
+		URL:         req.URL,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []har.Cookie{},
+		Headers:     har.Headers(req.Header),
+		QueryString: har.QueryString(uri),
+		HeadersSize: -1,
+		BodySize:    -1,
+	}
+
+	mimeType := req.Header.Get("Content-Type")
+
+	switch body := req.Body.(type) {
+	case TextBody:
+		hreq.PostData = &har.PostData{MimeType: mimeType, Text: string(body)}
+	case FileBody:
+		data, err := os.ReadFile(string(body))
+		if err != nil {
+			return har.Entry{}, err
+		}
+		hreq.PostData = &har.PostData{MimeType: mimeType, Text: string(data)}
+	case FormBody:
+		pd := &har.PostData{MimeType: "multipart/form-data"}
+		for _, f := range body {
+			p := har.Param{Name: f.Name, Value: f.Value}
+			if f.File != "" {
+				p.FileName = filepath.Base(f.File)
+				p.ContentType = f.ContentType
+			}
+			pd.Params = append(pd.Params, p)
+		}
+		hreq.PostData = pd
+	case URLEncodedBody:
+		pd := &har.PostData{MimeType: "application/x-www-form-urlencoded"}
+		values := url.Values{}
+		for _, f := range body {
+			pd.Params = append(pd.Params, har.Param{Name: f.Name, Value: f.Value})
+			values.Add(f.Name, f.Value)
+		}
+		pd.Text = values.Encode()
+		hreq.PostData = pd
+	}
+
+	return har.Entry{
+		StartedDateTime: started,
+		Time:            0,
+		Request:         hreq,
+		Response: har.Response{
+			Cookies:     []har.Cookie{},
+			Headers:     []har.NameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings: har.Timings{},
+		Comment: fmt.Sprintf("%s:%d", req.Path, req.Line),
+	}, nil
+}
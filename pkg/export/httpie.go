@@ -0,0 +1,73 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteHTTPie writes the given requests as HTTPie
+// commands to w. Each command is preceded by a
+// comment containing the position of the request.
+func WriteHTTPie(w io.Writer, reqs []Request) error {
+	for i, req := range reqs {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(w, httpieCommand(req)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func httpieCommand(req Request) string {
+	args := []string{"http"}
+
+	var items []string
+
+	for _, name := range sortedHeader(req.Header) {
+		for _, v := range req.Header[name] {
+			items = append(items, shellQuote(name+":"+v))
+		}
+	}
+
+	var redirect string
+
+	switch body := req.Body.(type) {
+	case TextBody:
+		args = append(args, "--ignore-stdin", "--raw "+shellQuote(string(body)))
+	case FileBody:
+		redirect = "< " + shellQuote(string(body))
+	case FormBody:
+		args = append(args, "--ignore-stdin", "--multipart")
+		for _, f := range body {
+			if f.File == "" {
+				items = append(items, shellQuote(f.Name+"="+f.Value))
+				continue
+			}
+			v := f.Name + "@" + f.File
+			if f.ContentType != "" {
+				v += ";type=" + f.ContentType
+			}
+			items = append(items, shellQuote(v))
+		}
+	case URLEncodedBody:
+		args = append(args, "--ignore-stdin", "--form")
+		for _, f := range body {
+			items = append(items, shellQuote(f.Name+"="+f.Value))
+		}
+	default:
+		args = append(args, "--ignore-stdin")
+	}
+
+	args = append(args, shellQuote(req.Method), shellQuote(req.URL))
+	args = append(args, items...)
+	if redirect != "" {
+		args = append(args, redirect)
+	}
+
+	return fmt.Sprintf("# %s:%d\n%s\n", req.Path, req.Line, strings.Join(args, " \\\n  "))
+}
@@ -113,11 +113,29 @@ type Request struct {
 	Pos    Pos
 	Head   RequestHead
 	Blocks []RequestBlock
+
+	// BlockPos contains the positions of the
+	// block headers by the index of Blocks.
+	BlockPos []Pos
+
+	// ContentPos contains the positions at which
+	// the raw content of blocks like [Script]
+	// starts by the index of Blocks.
+	ContentPos []Pos
 }
 
 type PartialRequest struct {
 	Pos    Pos
 	Blocks []RequestBlock
+
+	// BlockPos contains the positions of the
+	// block headers by the index of Blocks.
+	BlockPos []Pos
+
+	// ContentPos contains the positions at which
+	// the raw content of blocks like [Script]
+	// starts by the index of Blocks.
+	ContentPos []Pos
 }
 
 type HeaderEntries struct {
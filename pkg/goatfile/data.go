@@ -105,7 +105,7 @@ type FileContent struct {
 }
 
 func (t FileContent) Reader() (r io.Reader, err error) {
-	pth, err := joinPath(t.currDir, t.filePath)
+	pth, err := t.FilePath()
 	if err != nil {
 		return nil, err
 	}
@@ -113,6 +113,12 @@ func (t FileContent) Reader() (r io.Reader, err error) {
 	return r, err
 }
 
+// FilePath returns the path of the file resolved
+// relative to the directory of the Goatfile.
+func (t FileContent) FilePath() (string, error) {
+	return joinPath(t.currDir, t.filePath)
+}
+
 // RawContent can be used for reading byte
 // array data
 type RawContent struct {
@@ -214,6 +220,19 @@ func (t FormData) Reader() (io.Reader, error) {
 	return &b, nil
 }
 
+// Fields returns the form fields. File fields
+// contain an ast.FileDescriptor and raw data fields
+// contain an ast.RawDescriptor.
+func (t FormData) Fields() map[string]any {
+	return t.fields
+}
+
+// FilePath returns the path of the given file field
+// resolved relative to the directory of the Goatfile.
+func (t FormData) FilePath(fd ast.FileDescriptor) (string, error) {
+	return joinPath(t.currDir, fd.Path)
+}
+
 func IsNoContent(d Data) bool {
 	_, ok := d.(NoContent)
 	return ok
@@ -257,3 +276,8 @@ func (t FormUrlEncoded) Reader() (io.Reader, error) {
 	rdr := strings.NewReader(values.Encode())
 	return rdr, nil
 }
+
+// Fields returns the form fields.
+func (t FormUrlEncoded) Fields() map[string]any {
+	return t.fields
+}
@@ -16,7 +16,8 @@ type Execute struct {
 	Params  map[string]any
 	Returns map[string]string
 
-	Path string
+	Path    string
+	PosLine int
 }
 
 func ExecuteFromAst(a *ast.Execute, path string) (t Execute, err error) {
@@ -26,12 +27,21 @@ func ExecuteFromAst(a *ast.Execute, path string) (t Execute, err error) {
 
 	t.File = a.Path
 	t.Path = path
+	t.PosLine = a.Pos.Line + 1
 	t.Params = a.Parameters.ToMap()
 	t.Returns = a.Returns.ToMap()
 
 	return t, nil
 }
 
+// Copy returns a deep copy of the execute statement, so
+// that the copy can be substituted with parameters
+// independently of the original statement.
+func (t Execute) Copy() Execute {
+	t.Params = copyMap(t.Params)
+	return t
+}
+
 func (t Execute) Type() ActionType {
 	return ActionExecute
 }
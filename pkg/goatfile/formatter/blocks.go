@@ -0,0 +1,391 @@
+package formatter
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/studio-b12/goat/pkg/goatfile"
+	"github.com/studio-b12/goat/pkg/goatfile/ast"
+)
+
+const indent = "  "
+
+func (t *printer) requestLines(req *ast.Request, comments []ast.Comment) []string {
+	head := req.Head.Method + " " + formatPath(req.Head.Url)
+	lines := []string{withComment(head, takeComment(&comments, req.Pos.Line))}
+
+	blocks := t.blockLines(req.Blocks, req.BlockPos, comments)
+	if len(blocks) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, blocks...)
+	}
+
+	return lines
+}
+
+// blockComments holds the comments which are
+// assigned to a single request block.
+type blockComments struct {
+	leading  []ast.Comment
+	header   *ast.Comment
+	entries  map[int][]ast.Comment // leading comments by entry index
+	trailing map[int]*ast.Comment  // same line comments by entry index
+}
+
+// blockLines returns the lines of the given request blocks
+// in canonical order with the given comments placed at
+// the blocks and entries they are positioned at.
+//
+// Comments can not be placed between a raw block and the
+// following block because they would become part of the
+// raw block's content. Those are moved in front of the
+// first block.
+func (t *printer) blockLines(blocks []ast.RequestBlock, blockPos []ast.Pos, comments []ast.Comment) []string {
+	assigned, rest := assignBlockComments(blocks, blockPos, comments)
+
+	order := blockOrder(blocks)
+
+	var hoisted []ast.Comment
+	for k := 1; k < len(order); k++ {
+		if isRawBlock(blocks[order[k-1]]) {
+			hoisted = append(hoisted, assigned[order[k]].leading...)
+			assigned[order[k]].leading = nil
+		}
+	}
+	if len(order) > 0 && isRawBlock(blocks[order[len(order)-1]]) {
+		hoisted = append(hoisted, rest...)
+		rest = nil
+	}
+
+	var lines []string
+	for k, i := range order {
+		if k > 0 && !isOpenRawBlock(blocks[order[k-1]]) {
+			lines = append(lines, "")
+		}
+		lines = append(lines, commentLines(assigned[i].leading, "")...)
+		if k == 0 {
+			lines = append(lines, commentLines(hoisted, "")...)
+		}
+		lines = append(lines, formatBlock(blocks[i], assigned[i])...)
+	}
+
+	if len(blocks) == 0 && len(rest) > 0 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, commentLines(rest, "")...)
+
+	return lines
+}
+
+// blockOrder returns the indices of the given
+// blocks in canonical block order.
+func blockOrder(blocks []ast.RequestBlock) []int {
+	order := make([]int, len(blocks))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return blockRank(blocks[a]) - blockRank(blocks[b])
+	})
+	return order
+}
+
+// blocksEndOpen returns true when the last of the given
+// blocks in canonical order is an open raw block.
+func blocksEndOpen(blocks []ast.RequestBlock) bool {
+	order := blockOrder(blocks)
+	return len(order) > 0 && isOpenRawBlock(blocks[order[len(order)-1]])
+}
+
+// assignBlockComments assigns the given comments to the
+// blocks by their line positions. Comments which are
+// positioned after the last block are returned as rest.
+func assignBlockComments(
+	blocks []ast.RequestBlock,
+	blockPos []ast.Pos,
+	comments []ast.Comment,
+) (assigned []blockComments, rest []ast.Comment) {
+	assigned = make([]blockComments, len(blocks))
+	if len(blockPos) != len(blocks) {
+		return assigned, comments
+	}
+
+	for i, block := range blocks {
+		bc := &assigned[i]
+		headerLine := blockPos[i].Line
+		entryLines := blockEntryLines(block)
+
+		bc.leading = takeCommentsBefore(&comments, headerLine)
+		bc.header = takeComment(&comments, headerLine)
+
+		// Comments between the last entry and the next block
+		// header are taken as leading comments of the next
+		// block in the next iteration.
+		bc.entries = make(map[int][]ast.Comment)
+		bc.trailing = make(map[int]*ast.Comment)
+		for j, line := range entryLines {
+			if c := takeComment(&comments, line); c != nil {
+				bc.trailing[j] = c
+			}
+			bc.entries[j] = takeCommentsBefore(&comments, line)
+		}
+	}
+
+	return assigned, comments
+}
+
+// blockRank returns the position of the
+// block type in the canonical block order.
+func blockRank(block ast.RequestBlock) int {
+	switch block.(type) {
+	case ast.RequestOptions:
+		return 0
+	case ast.RequestHeader:
+		return 1
+	case ast.RequestQueryParams:
+		return 2
+	case ast.RequestAuth:
+		return 3
+	case ast.RequestBody, ast.FormData, ast.FormUrlEncoded:
+		return 4
+	case ast.RequestPreScript:
+		return 5
+	case ast.RequestScript:
+		return 6
+	}
+	return 7
+}
+
+func isRawBlock(block ast.RequestBlock) bool {
+	switch block.(type) {
+	case ast.RequestBody, ast.RequestPreScript, ast.RequestScript:
+		return true
+	}
+	return false
+}
+
+// isOpenRawBlock returns true when the given block is a raw
+// block with text content not ending with a line break. The
+// line break following the printed text is parsed as part
+// of the content, so an open block must directly be followed
+// by the next block header or delimiter.
+func isOpenRawBlock(block ast.RequestBlock) bool {
+	var content ast.DataContent
+	switch b := block.(type) {
+	case ast.RequestBody:
+		content = b.DataContent
+	case ast.RequestPreScript:
+		content = b.DataContent
+	case ast.RequestScript:
+		content = b.DataContent
+	}
+	text, ok := content.(ast.TextBlock)
+	return ok && text.Content != "" && !strings.HasSuffix(text.Content, "\n")
+}
+
+func blockEntryLines(block ast.RequestBlock) []int {
+	var lines []int
+	switch b := block.(type) {
+	case ast.RequestHeader:
+		for _, kv := range b.KVList {
+			lines = append(lines, kv.Pos.Line)
+		}
+	case ast.RequestOptions:
+		lines = kvLines(b.KVList)
+	case ast.RequestQueryParams:
+		lines = kvLines(b.KVList)
+	case ast.RequestAuth:
+		lines = kvLines(b.KVList)
+	case ast.FormData:
+		lines = kvLines(b.KVList)
+	case ast.FormUrlEncoded:
+		lines = kvLines(b.KVList)
+	}
+	return lines
+}
+
+func kvLines(kvs ast.KVList[any]) []int {
+	lines := make([]int, 0, len(kvs))
+	for _, kv := range kvs {
+		lines = append(lines, kv.Pos.Line)
+	}
+	return lines
+}
+
+func formatBlock(block ast.RequestBlock, bc blockComments) []string {
+	switch b := block.(type) {
+	case ast.RequestOptions:
+		return kvBlockLines("Options", b.KVList, bc)
+	case ast.RequestQueryParams:
+		return kvBlockLines("QueryParams", b.KVList, bc)
+	case ast.RequestAuth:
+		return kvBlockLines("Auth", b.KVList, bc)
+	case ast.FormData:
+		return kvBlockLines("FormData", b.KVList, bc)
+	case ast.FormUrlEncoded:
+		return kvBlockLines("FormUrlEncoded", b.KVList, bc)
+	case ast.RequestHeader:
+		// Header values are aligned to the longest key.
+		width := 0
+		for _, kv := range b.KVList {
+			width = max(width, len(kv.Key))
+		}
+		lines := []string{withComment("[Header]", bc.header)}
+		for i, kv := range b.KVList {
+			lines = append(lines, commentLines(bc.entries[i], "")...)
+			key := kv.Key + ":" + strings.Repeat(" ", width-len(kv.Key))
+			lines = append(lines, withComment(key+" "+kv.Value, bc.trailing[i]))
+		}
+		return lines
+	case ast.RequestBody:
+		return rawBlockLines("Body", b.DataContent, bc)
+	case ast.RequestPreScript:
+		return rawBlockLines("PreScript", b.DataContent, bc)
+	case ast.RequestScript:
+		return rawBlockLines("Script", b.DataContent, bc)
+	}
+	return nil
+}
+
+func kvBlockLines(name string, kvs ast.KVList[any], bc blockComments) []string {
+	lines := []string{withComment("["+name+"]", bc.header)}
+	for i, kv := range kvs {
+		lines = append(lines, commentLines(bc.entries[i], "")...)
+		lines = append(lines, withComment(kv.Key+" = "+formatValue(kv.Value), bc.trailing[i]))
+	}
+	return lines
+}
+
+func rawBlockLines(name string, content ast.DataContent, bc blockComments) []string {
+	lines := []string{withComment("["+name+"]", bc.header)}
+
+	switch c := content.(type) {
+	case ast.TextBlock:
+		// The content is printed byte for byte. The line break
+		// following the printed text is parsed as part of the
+		// content, unless the block is open.
+		text := strings.TrimSuffix(c.Content, "\n")
+		if needsEscape(text) {
+			text = "```\n" + text + "\n```"
+		}
+		lines = append(lines, text)
+	case ast.FileDescriptor:
+		lines = append(lines, formatFileDescriptor(c))
+	case ast.RawDescriptor:
+		lines = append(lines, formatRawDescriptor(c))
+	}
+
+	return lines
+}
+
+// needsEscape returns true when the given raw block
+// content would not be parsed as is or would be altered
+// by editors and must be wrapped in an escape block.
+func needsEscape(text string) bool {
+	return text == "" ||
+		strings.HasPrefix(text, "\n") ||
+		strings.TrimRight(text, " \t\n") != text ||
+		strings.HasPrefix(text, "@") ||
+		strings.HasPrefix(text, "$") ||
+		strings.Contains(text, "\n[") ||
+		strings.Contains(text, "\n---") ||
+		strings.Contains(text, "\n###")
+}
+
+func (t *printer) executeLines(exec *ast.Execute, comments []ast.Comment) []string {
+	head := "execute " + formatPath(exec.Path)
+
+	opening := takeComment(&comments, exec.Pos.Line)
+
+	if len(exec.Parameters) == 0 && len(exec.Returns.KVList) == 0 {
+		return append([]string{withComment(head, opening)}, commentLines(comments, "")...)
+	}
+
+	lines := []string{withComment(head+" (", opening)}
+
+	for _, kv := range exec.Parameters {
+		lines = append(lines, commentLines(takeCommentsBefore(&comments, kv.Pos.Line), indent)...)
+		lines = append(lines, withComment(indent+kv.Key+" = "+formatValue(kv.Value),
+			takeComment(&comments, kv.Pos.Line)))
+	}
+
+	if len(exec.Returns.KVList) == 0 {
+		lines = append(lines, ")")
+		return append(lines, commentLines(comments, "")...)
+	}
+
+	lines = append(lines, commentLines(takeCommentsBefore(&comments, exec.Returns.KVList[0].Pos.Line), indent)...)
+	lines = append(lines, ") return (")
+	for _, kv := range exec.Returns.KVList {
+		lines = append(lines, indent+kv.Key+" as "+kv.Value)
+	}
+	lines = append(lines, ")")
+
+	return append(lines, commentLines(comments, "")...)
+}
+
+// formatPath returns the given path or URL
+// quoted if it contains whitespace.
+func formatPath(v string) string {
+	if v == "" || strings.ContainsAny(v, " \t") || strings.ContainsAny(v[:1], `"'`) {
+		return quote(v)
+	}
+	return v
+}
+
+func quote(v string) string {
+	if strings.Contains(v, `"`) {
+		return "'" + v + "'"
+	}
+	return `"` + v + `"`
+}
+
+func formatValue(v any) string {
+	switch vt := v.(type) {
+	case string:
+		return quote(vt)
+	case int64:
+		return strconv.FormatInt(vt, 10)
+	case float64:
+		s := strconv.FormatFloat(vt, 'f', -1, 64)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	case bool:
+		return strconv.FormatBool(vt)
+	case goatfile.ParameterValue:
+		return "{{" + string(vt) + "}}"
+	case ast.FileDescriptor:
+		return formatFileDescriptor(vt)
+	case ast.RawDescriptor:
+		return formatRawDescriptor(vt)
+	case []any:
+		elems := make([]string, 0, len(vt))
+		for _, e := range vt {
+			elems = append(elems, formatValue(e))
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
+
+func formatFileDescriptor(fd ast.FileDescriptor) string {
+	return "@" + formatDescriptor(fd.Path, fd.ContentType)
+}
+
+func formatRawDescriptor(rd ast.RawDescriptor) string {
+	return "$" + formatDescriptor(rd.VarName, rd.ContentType)
+}
+
+func formatDescriptor(name, contentType string) string {
+	if strings.ContainsAny(name, " \t:") {
+		name = quote(name)
+	}
+	if contentType == "" {
+		return name
+	}
+	return name + ":" + formatPath(contentType)
+}
@@ -0,0 +1,371 @@
+// Package formatter implements printing Goatfile
+// ASTs in canonical form.
+package formatter
+
+import (
+	"io"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/studio-b12/goat/pkg/goatfile"
+	"github.com/studio-b12/goat/pkg/goatfile/ast"
+)
+
+// Format parses the given raw Goatfile and returns
+// it in canonical form. Comments are preserved.
+func Format(raw string) (string, error) {
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+
+	gf, err := goatfile.NewParser(strings.NewReader(raw), "").Parse()
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	err = Print(&sb, gf)
+	return sb.String(), err
+}
+
+// Print writes the given Goatfile AST in canonical
+// form to w.
+//
+// Comments are placed by the line positions of the
+// nodes in the AST. When the AST has not been produced
+// by the parser, the positions may be left empty if
+// the AST does not contain any comments.
+func Print(w io.Writer, gf *ast.Goatfile) error {
+	p := newPrinter(gf)
+	_, err := io.WriteString(w, p.print(collectNodes(gf)))
+	return err
+}
+
+// node is a top level element of a Goatfile.
+type node struct {
+	pos ast.Pos
+	v   any
+}
+
+// collectNodes returns the imports, actions and sections
+// of the Goatfile and the actions of the sections in
+// order of their appearance.
+func collectNodes(gf *ast.Goatfile) []node {
+	var nodes []node
+
+	for _, imp := range gf.Imports {
+		nodes = append(nodes, node{pos: imp.Pos, v: imp})
+	}
+	for _, act := range gf.Actions {
+		nodes = append(nodes, node{pos: actionPos(act), v: act})
+	}
+
+	// Actions of sections are kept directly after
+	// their section to preserve the order when
+	// positions are not set.
+	sectionNodes := make([][]node, 0, len(gf.Sections))
+	for _, sect := range gf.Sections {
+		var (
+			pos     ast.Pos
+			actions []ast.Action
+		)
+		switch s := sect.(type) {
+		case ast.SectionDefaults:
+			pos = s.Pos
+		case ast.SectionSetup:
+			pos, actions = s.Pos, s.Actions
+		case ast.SectionTests:
+			pos, actions = s.Pos, s.Actions
+		case ast.SectionTeardown:
+			pos, actions = s.Pos, s.Actions
+		}
+
+		sn := []node{{pos: pos, v: sect}}
+		for _, act := range actions {
+			sn = append(sn, node{pos: actionPos(act), v: act})
+		}
+		sectionNodes = append(sectionNodes, sn)
+	}
+
+	slices.SortStableFunc(nodes, func(a, b node) int {
+		return comparePos(a.pos, b.pos)
+	})
+	slices.SortStableFunc(sectionNodes, func(a, b []node) int {
+		return comparePos(a[0].pos, b[0].pos)
+	})
+
+	// Top level actions and imports can also follow
+	// a section (for example after the defaults section),
+	// so both lists are merged by their position.
+	merged := make([]node, 0, len(nodes)+len(sectionNodes))
+	for _, sn := range sectionNodes {
+		for len(nodes) > 0 && comparePos(nodes[0].pos, sn[0].pos) <= 0 {
+			merged = append(merged, nodes[0])
+			nodes = nodes[1:]
+		}
+		merged = append(merged, sn...)
+	}
+	merged = append(merged, nodes...)
+
+	return merged
+}
+
+func comparePos(a, b ast.Pos) int {
+	if a.Line != b.Line {
+		return a.Line - b.Line
+	}
+	return a.Pos - b.Pos
+}
+
+func actionPos(act ast.Action) ast.Pos {
+	switch a := act.(type) {
+	case *ast.Request:
+		return a.Pos
+	case *ast.Execute:
+		return a.Pos
+	case ast.LogSection:
+		return a.Pos
+	}
+	return ast.Pos{}
+}
+
+// chunk is a part of the output which is separated
+// from the previous chunk by an empty line or, if
+// tight is set, by a single line break.
+type chunk struct {
+	lines []string
+	tight bool
+}
+
+type printer struct {
+	comments   []ast.Comment
+	delimiters []int
+}
+
+func newPrinter(gf *ast.Goatfile) *printer {
+	var p printer
+
+	p.comments = slices.Clone(gf.Comments)
+	slices.SortStableFunc(p.comments, func(a, b ast.Comment) int {
+		return comparePos(a.Pos, b.Pos)
+	})
+
+	for _, d := range gf.Delimiters {
+		p.delimiters = append(p.delimiters, d.Pos.Line)
+	}
+	slices.Sort(p.delimiters)
+
+	return &p
+}
+
+func (t *printer) print(nodes []node) string {
+	var (
+		chunks []chunk
+		open   bool
+	)
+
+	for i, n := range nodes {
+		next := math.MaxInt
+		if i < len(nodes)-1 {
+			next = nodes[i+1].pos.Line
+		}
+
+		// Comments between the node and the next
+		// delimiter belong to the node. Comments
+		// after the delimiter belong to the next node.
+		end := next
+		for _, d := range t.delimiters {
+			if d > n.pos.Line && d < end {
+				end = d
+				break
+			}
+		}
+
+		leading := t.leadingLines(n.pos.Line)
+		_, isImport := n.v.(ast.Import)
+		_, prevIsImport := nodeValue(nodes, i-1).(ast.Import)
+		tight := isImport && prevIsImport && len(leading) == 0
+
+		lines := append(leading, t.nodeLines(n, end)...)
+		chunks = append(chunks, chunk{lines: lines, tight: tight})
+
+		open = endsOpen(n.v)
+		if needsDelimiter(n.v) && (i < len(nodes)-1 || len(t.comments) > 0) {
+			chunks = append(chunks, chunk{lines: []string{"---"}, tight: open})
+			open = false
+		}
+	}
+
+	if len(t.comments) > 0 {
+		chunks = append(chunks, chunk{lines: t.leadingLines(math.MaxInt)})
+	}
+
+	var sb strings.Builder
+	for i, c := range chunks {
+		if i > 0 {
+			if c.tight {
+				sb.WriteString("\n")
+			} else {
+				sb.WriteString("\n\n")
+			}
+		}
+		sb.WriteString(strings.Join(c.lines, "\n"))
+	}
+	// A trailing line break would become part of the
+	// content of an open raw block at the end.
+	if sb.Len() > 0 && !open {
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+func nodeValue(nodes []node, i int) any {
+	if i < 0 || i >= len(nodes) {
+		return nil
+	}
+	return nodes[i].v
+}
+
+// endsOpen returns true when the printed node
+// ends with an open raw block.
+func endsOpen(v any) bool {
+	switch v := v.(type) {
+	case *ast.Request:
+		return blocksEndOpen(v.Blocks)
+	case ast.SectionDefaults:
+		return blocksEndOpen(v.Request.Blocks)
+	}
+	return false
+}
+
+func needsDelimiter(v any) bool {
+	switch v.(type) {
+	case *ast.Request, *ast.Execute, ast.SectionDefaults:
+		return true
+	}
+	return false
+}
+
+func (t *printer) nodeLines(n node, end int) []string {
+	switch v := n.v.(type) {
+	case ast.Import:
+		return []string{withComment("use "+formatPath(v.Path), t.takeLine(n.pos.Line))}
+	case ast.LogSection:
+		return []string{"##### " + v.Content}
+	case *ast.Execute:
+		return t.executeLines(v, t.takeBefore(end))
+	case *ast.Request:
+		return t.requestLines(v, t.takeBefore(end))
+	case ast.SectionDefaults:
+		lines := []string{"### Defaults"}
+		blocks := t.blockLines(v.Request.Blocks, v.Request.BlockPos, t.takeBefore(end))
+		if len(blocks) > 0 {
+			lines = append(lines, "")
+			lines = append(lines, blocks...)
+		}
+		return lines
+	case ast.SectionSetup:
+		return []string{"### Setup"}
+	case ast.SectionTests:
+		return []string{"### Tests"}
+	case ast.SectionTeardown:
+		return []string{"### Teardown"}
+	}
+	return nil
+}
+
+// takeBefore removes and returns all remaining
+// comments positioned before the given line.
+func (t *printer) takeBefore(line int) []ast.Comment {
+	i := 0
+	for i < len(t.comments) && t.comments[i].Pos.Line < line {
+		i++
+	}
+	taken := t.comments[:i:i]
+	t.comments = t.comments[i:]
+	return taken
+}
+
+// takeLine removes and returns the remaining
+// comment at the given line, if existent.
+func (t *printer) takeLine(line int) *ast.Comment {
+	for i, c := range t.comments {
+		if c.Pos.Line == line {
+			t.comments = slices.Delete(t.comments, i, i+1)
+			return &c
+		}
+	}
+	return nil
+}
+
+// leadingLines returns the lines of all remaining
+// comments before the given line. Empty lines between
+// comments and between the last comment and the given
+// line are collapsed to a single empty line.
+func (t *printer) leadingLines(line int) []string {
+	comments := t.takeBefore(line)
+
+	var lines []string
+	for i, c := range comments {
+		if i > 0 && c.Pos.Line-comments[i-1].Pos.Line > 1 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, formatComment(c))
+	}
+	if len(comments) > 0 && line != math.MaxInt && line-comments[len(comments)-1].Pos.Line > 1 {
+		lines = append(lines, "")
+	}
+
+	return lines
+}
+
+func formatComment(c ast.Comment) string {
+	if c.Content == "" || strings.HasPrefix(c.Content, "/") {
+		return "//" + c.Content
+	}
+	return "// " + c.Content
+}
+
+func withComment(line string, c *ast.Comment) string {
+	if c == nil {
+		return line
+	}
+	return line + " " + formatComment(*c)
+}
+
+func commentLines(comments []ast.Comment, indent string) []string {
+	lines := make([]string, 0, len(comments))
+	for _, c := range comments {
+		lines = append(lines, indent+formatComment(c))
+	}
+	return lines
+}
+
+// takeComment removes and returns the comment at the
+// given line from the list of comments, if existent.
+func takeComment(comments *[]ast.Comment, line int) *ast.Comment {
+	for i, c := range *comments {
+		if c.Pos.Line == line {
+			*comments = slices.Delete(*comments, i, i+1)
+			return &c
+		}
+	}
+	return nil
+}
+
+// takeCommentsBefore removes and returns all comments
+// positioned before the given line from the list of
+// comments.
+func takeCommentsBefore(comments *[]ast.Comment, line int) []ast.Comment {
+	var taken []ast.Comment
+	rest := (*comments)[:0:0]
+	for _, c := range *comments {
+		if c.Pos.Line < line {
+			taken = append(taken, c)
+		} else {
+			rest = append(rest, c)
+		}
+	}
+	*comments = rest
+	return taken
+}
@@ -0,0 +1,362 @@
+package formatter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-b12/goat/pkg/goatfile"
+	"github.com/studio-b12/goat/pkg/goatfile/ast"
+)
+
+func TestFormat(t *testing.T) {
+	t.Run("request", func(t *testing.T) {
+		const raw = "  GET   https://example.com   // head\n" +
+			"[Script]\n" +
+			"assert(true);\n" +
+			"[header]\n" +
+			"   X-Foo:    bar\n" +
+			"Content-Type:application/json // type\n" +
+			"[ options]\n" +
+			"  cookiejar   =   \"foo\"   // trailing\n" +
+			"  delay=1.5\n" +
+			"  arr = [1, 2,'x', {{ .p }}]\n" +
+			"  file = @'some file.txt':text/plain\n"
+
+		const expected = "GET https://example.com // head\n" +
+			"\n" +
+			"[Options]\n" +
+			"cookiejar = \"foo\" // trailing\n" +
+			"delay = 1.5\n" +
+			"arr = [1, 2, \"x\", {{ .p }}]\n" +
+			"file = @\"some file.txt\":text/plain\n" +
+			"\n" +
+			"[Header]\n" +
+			"X-Foo:        bar\n" +
+			"Content-Type: application/json // type\n" +
+			"\n" +
+			"[Script]\n" +
+			"assert(true);"
+
+		res, err := Format(raw)
+		assert.Nil(t, err, err)
+		assert.Equal(t, expected, res)
+	})
+
+	t.Run("sections", func(t *testing.T) {
+		const raw = "use a\n" +
+			"use b\n" +
+			"### defaults\n" +
+			"[Header]\n" +
+			"A: b\n" +
+			"### setup\n" +
+			"GET https://example.com/1\n" +
+			"--------\n" +
+			"execute ./c (a=1 b=\"2\") return (c as d)\n" +
+			"### Tests\n" +
+			"#####   Log section\n" +
+			"GET https://example.com/2\n" +
+			"---\n"
+
+		const expected = "use a\n" +
+			"use b\n" +
+			"\n" +
+			"### Defaults\n" +
+			"\n" +
+			"[Header]\n" +
+			"A: b\n" +
+			"\n" +
+			"---\n" +
+			"\n" +
+			"### Setup\n" +
+			"\n" +
+			"GET https://example.com/1\n" +
+			"\n" +
+			"---\n" +
+			"\n" +
+			"execute ./c (\n" +
+			"  a = 1\n" +
+			"  b = \"2\"\n" +
+			") return (\n" +
+			"  c as d\n" +
+			")\n" +
+			"\n" +
+			"---\n" +
+			"\n" +
+			"### Tests\n" +
+			"\n" +
+			"##### Log section\n" +
+			"\n" +
+			"GET https://example.com/2\n"
+
+		res, err := Format(raw)
+		assert.Nil(t, err, err)
+		assert.Equal(t, expected, res)
+	})
+
+	t.Run("comments", func(t *testing.T) {
+		const raw = "// file comment\n" +
+			"\n" +
+			"\n" +
+			"// request comment\n" +
+			"GET https://example.com/1\n" +
+			"[Body]\n" +
+			"hello\n" +
+			"// script comment\n" +
+			"[Script]\n" +
+			"assert(true);\n" +
+			"[Options]\n" +
+			"// entry comment\n" +
+			"delay = 1\n" +
+			"// trailing comment\n" +
+			"---\n" +
+			"// next comment\n" +
+			"GET https://example.com/2\n" +
+			"---\n" +
+			"// end comment\n"
+
+		const expected = "// file comment\n" +
+			"\n" +
+			"// request comment\n" +
+			"GET https://example.com/1\n" +
+			"\n" +
+			"// trailing comment\n" +
+			"[Options]\n" +
+			"// entry comment\n" +
+			"delay = 1\n" +
+			"\n" +
+			"[Body]\n" +
+			"hello\n" +
+			"// script comment\n" +
+			"[Script]\n" +
+			"assert(true);\n" +
+			"---\n" +
+			"\n" +
+			"// next comment\n" +
+			"GET https://example.com/2\n" +
+			"\n" +
+			"---\n" +
+			"\n" +
+			"// end comment\n"
+
+		res, err := Format(raw)
+		assert.Nil(t, err, err)
+		assert.Equal(t, expected, res)
+	})
+
+	t.Run("comments-after-raw-block", func(t *testing.T) {
+		const raw = "GET https://example.com\n" +
+			"[Script]\n" +
+			"assert(true);\n" +
+			"[Header]\n" +
+			"A: b\n" +
+			"// header comment\n"
+
+		const expected = "GET https://example.com\n" +
+			"\n" +
+			"// header comment\n" +
+			"[Header]\n" +
+			"A: b\n" +
+			"\n" +
+			"[Script]\n" +
+			"assert(true);"
+
+		res, err := Format(raw)
+		assert.Nil(t, err, err)
+		assert.Equal(t, expected, res)
+	})
+
+	t.Run("escape-raw-content", func(t *testing.T) {
+		const raw = "GET https://example.com\n" +
+			"[Body]\n" +
+			"```\n" +
+			"@not a file\n" +
+			"[not a block]\n" +
+			"```\n"
+
+		const expected = "GET https://example.com\n" +
+			"\n" +
+			"[Body]\n" +
+			"```\n" +
+			"@not a file\n" +
+			"[not a block]\n" +
+			"```\n"
+
+		res, err := Format(raw)
+		assert.Nil(t, err, err)
+		assert.Equal(t, expected, res)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := Format("GET https://example.com\n[Foo]\n")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestFormat_Idempotent(t *testing.T) {
+	const raw = "use  ../a   // import\n" +
+		"// comment\n" +
+		"### Defaults\n" +
+		"[Options]  // options\n" +
+		"  cookiejar = 'foo'\n" +
+		"[Script]\n" +
+		"assert(response.StatusCode === 200);\n" +
+		"----\n" +
+		"### Setup\n" +
+		"POST {{.instance}}/login\n" +
+		"[Body]\n" +
+		"@body.json\n" +
+		"[PreScript]\n" +
+		"var a = 1;\n" +
+		"\n" +
+		"\n" +
+		"[QueryParams]\n" +
+		"// page\n" +
+		"page = {{.page}}\n" +
+		"[Header]\n" +
+		"Accept: application/json\n" +
+		"// id\n" +
+		"X-Request-Id:    {{.id}} // trailing\n" +
+		"### Tests\n" +
+		"execute ./b (\n" +
+		"  // param\n" +
+		"  a = [1, 2.0, true]\n" +
+		")\n" +
+		"GET https://example.com\n" +
+		"[FormData]\n" +
+		"file = $data:application/json\n"
+
+	first, err := Format(raw)
+	assert.Nil(t, err, err)
+
+	second, err := Format(first)
+	assert.Nil(t, err, err)
+	assert.Equal(t, first, second)
+
+	assert.Contains(t, first, "Accept:       application/json\n// id\nX-Request-Id: {{.id}} // trailing\n")
+	assert.Equal(t, strings.Count(raw, "//"), strings.Count(first, "//"))
+}
+
+func TestFormat_RawContent(t *testing.T) {
+	// rawContents returns the contents of the raw blocks
+	// by request index and block type, because the order
+	// of the blocks is changed by formatting.
+	rawContents := func(t *testing.T, raw string) map[string]ast.DataContent {
+		gf, err := goatfile.NewParser(strings.NewReader(raw), "").Parse()
+		require.Nil(t, err, err)
+
+		contents := make(map[string]ast.DataContent)
+		for i, act := range gf.Actions {
+			for _, block := range act.(*ast.Request).Blocks {
+				key := fmt.Sprintf("%d %T", i, block)
+				switch b := block.(type) {
+				case ast.RequestBody:
+					contents[key] = b.DataContent
+				case ast.RequestPreScript:
+					contents[key] = b.DataContent
+				case ast.RequestScript:
+					contents[key] = b.DataContent
+				}
+			}
+		}
+		return contents
+	}
+
+	cases := map[string]string{
+		"escaped-whitespace": "GET https://example.com\n" +
+			"[Body]\n" +
+			"```\n" +
+			"\n" +
+			"line1  \n" +
+			"\n" +
+			"```\n",
+		"leading-empty-line": "GET https://example.com\n" +
+			"[Body]\n" +
+			"\n" +
+			"hello\n",
+		"trailing-empty-lines": "GET https://example.com\n" +
+			"[PreScript]\n" +
+			"var a = 1;\n" +
+			"\n" +
+			"\n" +
+			"[Header]\n" +
+			"A: b\n",
+		"no-trailing-line-break": "GET https://example.com\n" +
+			"[Script]\n" +
+			"assert(true);\n" +
+			"[Body]\n" +
+			"hello\n" +
+			"---\n" +
+			"GET https://example.com\n" +
+			"[Body]\n" +
+			"  indented  \n" +
+			"[Options]\n" +
+			"delay = 1\n",
+		"empty-line": "GET https://example.com\n" +
+			"[Body]\n" +
+			"```\n" +
+			"\n" +
+			"```\n" +
+			"---\n" +
+			"GET https://example.com\n",
+	}
+
+	for name, raw := range cases {
+		t.Run(name, func(t *testing.T) {
+			formatted, err := Format(raw)
+			require.Nil(t, err, err)
+
+			assert.Equal(t, rawContents(t, raw), rawContents(t, formatted))
+
+			second, err := Format(formatted)
+			require.Nil(t, err, err)
+			assert.Equal(t, formatted, second)
+		})
+	}
+}
+
+func TestPrint(t *testing.T) {
+	// ASTs created without the parser do
+	// not contain any positions.
+	gf := &ast.Goatfile{
+		Imports: []ast.Import{{Path: "a"}},
+		Sections: []ast.Section{
+			ast.SectionTests{Actions: []ast.Action{
+				&ast.Request{
+					Head: ast.RequestHead{Method: "GET", Url: "https://example.com"},
+					Blocks: []ast.RequestBlock{
+						ast.RequestScript{DataContent: ast.TextBlock{Content: "assert(true);\n"}},
+						ast.RequestHeader{HeaderEntries: ast.HeaderEntries{KVList: ast.KVList[string]{
+							{Key: "A", Value: "b"},
+						}}},
+					},
+				},
+				&ast.Execute{Path: "b"},
+			}},
+		},
+	}
+
+	var sb strings.Builder
+	err := Print(&sb, gf)
+	assert.Nil(t, err, err)
+
+	const expected = "use a\n" +
+		"\n" +
+		"### Tests\n" +
+		"\n" +
+		"GET https://example.com\n" +
+		"\n" +
+		"[Header]\n" +
+		"A: b\n" +
+		"\n" +
+		"[Script]\n" +
+		"assert(true);\n" +
+		"\n" +
+		"---\n" +
+		"\n" +
+		"execute b\n"
+
+	assert.Equal(t, expected, sb.String())
+}
@@ -37,6 +37,20 @@ const (
 	optionNameFormUrlEncoded = optionName("formurlencoded")
 )
 
+// BlockNames contains the names of all request
+// blocks in their canonical spelling.
+var BlockNames = []string{
+	"Options",
+	"Header",
+	"QueryParams",
+	"Auth",
+	"Body",
+	"FormData",
+	"FormUrlEncoded",
+	"PreScript",
+	"Script",
+}
+
 // Goatfile holds all sections and
 // their requests.
 type Goatfile struct {
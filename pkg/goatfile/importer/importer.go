@@ -0,0 +1,159 @@
+// Package importer implements generating Goatfiles
+// from the API descriptions of other tools.
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/studio-b12/goat/pkg/goatfile/ast"
+)
+
+// File is a Goatfile generated by an importer.
+type File struct {
+	// Name is the file name of the Goatfile
+	// without extension.
+	Name     string
+	Goatfile *ast.Goatfile
+}
+
+// Options holds the configuration of an import.
+type Options struct {
+	// Split generates a Goatfile for each top level
+	// group of requests instead of a single Goatfile
+	// containing all groups as log sections.
+	Split bool
+}
+
+// builder assembles a Goatfile AST containing a
+// single tests section.
+//
+// Because comments are placed by their line when
+// printing the AST, each node is positioned on its
+// own line in the order it has been added.
+type builder struct {
+	gf      ast.Goatfile
+	section ast.SectionTests
+	line    int
+}
+
+func newBuilder(comments ...string) *builder {
+	var t builder
+	for _, c := range comments {
+		t.comment(c)
+	}
+	if len(comments) > 0 {
+		// Separate the comments from the section.
+		t.next()
+	}
+	t.section.Pos = t.next()
+	return &t
+}
+
+func (t *builder) next() ast.Pos {
+	t.line++
+	return ast.Pos{Line: t.line}
+}
+
+func (t *builder) comment(content string) {
+	t.gf.Comments = append(t.gf.Comments, ast.Comment{Pos: t.next(), Content: content})
+}
+
+// delimit separates the following comments from the
+// previously added action.
+func (t *builder) delimit() {
+	t.gf.Delimiters = append(t.gf.Delimiters, ast.Delimiter{Pos: t.next()})
+}
+
+func (t *builder) logSection(content string) {
+	t.delimit()
+	t.section.Actions = append(t.section.Actions, ast.LogSection{Pos: t.next(), Content: content})
+}
+
+// lastLogSection returns the content of the last added
+// action if it is a log section.
+func (t *builder) lastLogSection() string {
+	if len(t.section.Actions) == 0 {
+		return ""
+	}
+	ls, _ := t.section.Actions[len(t.section.Actions)-1].(ast.LogSection)
+	return ls.Content
+}
+
+func (t *builder) request(req *ast.Request, comments ...string) {
+	t.delimit()
+	for _, c := range comments {
+		t.comment(c)
+	}
+	req.Pos = t.next()
+	t.section.Actions = append(t.section.Actions, req)
+}
+
+func (t *builder) empty() bool {
+	return len(t.section.Actions) == 0
+}
+
+func (t *builder) goatfile() *ast.Goatfile {
+	gf := t.gf
+	gf.Sections = []ast.Section{t.section}
+	return &gf
+}
+
+var nonAlphaNumRx = regexp.MustCompile(`[^a-z0-9]+`)
+
+// fileName returns a file name without extension
+// derived from the given name.
+func fileName(name string) string {
+	name = strings.Trim(nonAlphaNumRx.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if name == "" {
+		return "goatfile"
+	}
+	return name
+}
+
+// uniqueFileNames makes the names of the given files
+// unique by appending a counter to duplicate names.
+func uniqueFileNames(files []File) {
+	seen := make(map[string]int)
+	for i, f := range files {
+		seen[f.Name]++
+		if n := seen[f.Name]; n > 1 {
+			files[i].Name = fmt.Sprintf("%s-%d", f.Name, n)
+		}
+	}
+}
+
+var identRx = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// paramTemplate returns a template inserting the
+// parameter with the given name.
+func paramTemplate(name string) string {
+	if identRx.MatchString(name) {
+		return "{{." + name + "}}"
+	}
+	return fmt.Sprintf("{{index . %q}}", name)
+}
+
+// textBody returns a body block with the given content.
+// The content is terminated with a line break so that the
+// following block is separated like in formatted Goatfiles.
+func textBody(content string) ast.RequestBody {
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return ast.RequestBody{DataContent: ast.TextBlock{Content: content}}
+}
+
+func header(kvs ...ast.KV[string]) ast.RequestHeader {
+	return ast.RequestHeader{HeaderEntries: ast.HeaderEntries{KVList: kvs}}
+}
+
+func hasHeader(kvs ast.KVList[string], key string) bool {
+	for _, kv := range kvs {
+		if strings.EqualFold(kv.Key, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,597 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/studio-b12/goat/pkg/errs"
+	"github.com/studio-b12/goat/pkg/goatfile/ast"
+	"github.com/studio-b12/goat/pkg/set"
+	"github.com/zekrotja/rogu/log"
+	"gopkg.in/yaml.v3"
+)
+
+// orderedMap is a YAML mapping which keeps
+// the order of its entries.
+type orderedMap[T any] []orderedMapEntry[T]
+
+type orderedMapEntry[T any] struct {
+	Key   string
+	Value T
+}
+
+func (t *orderedMap[T]) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping", node.Line)
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var v T
+		if err := node.Content[i+1].Decode(&v); err != nil {
+			return err
+		}
+		*t = append(*t, orderedMapEntry[T]{Key: node.Content[i].Value, Value: v})
+	}
+
+	return nil
+}
+
+type openAPISpec struct {
+	OpenAPI string `yaml:"openapi"`
+	Swagger string `yaml:"swagger"`
+	Info    struct {
+		Title string `yaml:"title"`
+	} `yaml:"info"`
+	Servers []struct {
+		URL string `yaml:"url"`
+	} `yaml:"servers"`
+	Tags []struct {
+		Name string `yaml:"name"`
+	} `yaml:"tags"`
+	Paths      orderedMap[openAPIPathItem] `yaml:"paths"`
+	Security   []map[string][]string       `yaml:"security"`
+	Components struct {
+		Schemas         map[string]any                   `yaml:"schemas"`
+		Parameters      map[string]openAPIParameter      `yaml:"parameters"`
+		RequestBodies   map[string]openAPIRequestBody    `yaml:"requestBodies"`
+		SecuritySchemes map[string]openAPISecurityScheme `yaml:"securitySchemes"`
+	} `yaml:"components"`
+}
+
+type openAPIPathItem struct {
+	Parameters []openAPIParameter `yaml:"parameters"`
+	Get        *openAPIOperation  `yaml:"get"`
+	Put        *openAPIOperation  `yaml:"put"`
+	Post       *openAPIOperation  `yaml:"post"`
+	Delete     *openAPIOperation  `yaml:"delete"`
+	Options    *openAPIOperation  `yaml:"options"`
+	Head       *openAPIOperation  `yaml:"head"`
+	Patch      *openAPIOperation  `yaml:"patch"`
+	Trace      *openAPIOperation  `yaml:"trace"`
+}
+
+func (t openAPIPathItem) operations() []orderedMapEntry[*openAPIOperation] {
+	ops := []orderedMapEntry[*openAPIOperation]{
+		{"GET", t.Get}, {"POST", t.Post}, {"PUT", t.Put}, {"PATCH", t.Patch},
+		{"DELETE", t.Delete}, {"HEAD", t.Head}, {"OPTIONS", t.Options}, {"TRACE", t.Trace},
+	}
+	return slices.DeleteFunc(ops, func(op orderedMapEntry[*openAPIOperation]) bool {
+		return op.Value == nil
+	})
+}
+
+type openAPIOperation struct {
+	Summary     string                 `yaml:"summary"`
+	OperationID string                 `yaml:"operationId"`
+	Tags        []string               `yaml:"tags"`
+	Deprecated  bool                   `yaml:"deprecated"`
+	Parameters  []openAPIParameter     `yaml:"parameters"`
+	RequestBody *openAPIRequestBody    `yaml:"requestBody"`
+	Responses   orderedMap[any]        `yaml:"responses"`
+	Security    *[]map[string][]string `yaml:"security"`
+}
+
+type openAPIParameter struct {
+	Ref      string         `yaml:"$ref"`
+	Name     string         `yaml:"name"`
+	In       string         `yaml:"in"`
+	Required bool           `yaml:"required"`
+	Schema   map[string]any `yaml:"schema"`
+	Example  any            `yaml:"example"`
+}
+
+type openAPIRequestBody struct {
+	Ref     string                       `yaml:"$ref"`
+	Content orderedMap[openAPIMediaType] `yaml:"content"`
+}
+
+type openAPIMediaType struct {
+	Schema   map[string]any             `yaml:"schema"`
+	Example  any                        `yaml:"example"`
+	Examples orderedMap[map[string]any] `yaml:"examples"`
+}
+
+type openAPISecurityScheme struct {
+	Type   string `yaml:"type"`
+	Scheme string `yaml:"scheme"`
+	Name   string `yaml:"name"`
+	In     string `yaml:"in"`
+}
+
+// OpenAPI generates Goatfiles from the OpenAPI 3
+// specification in YAML or JSON format read from r.
+//
+// A request is generated for each operation. The base
+// URL of the requests is taken from the parameter
+// 'instance'. Path parameters as well as required query
+// and header parameters without example values are
+// taken from the parameters of the same name. Request
+// bodies are generated from the examples or schemas of
+// the operations and each request asserts the expected
+// success status code.
+//
+// Operations are grouped by their first tag into log
+// sections. When opts.Split is set, each tag is generated
+// as separate file and untagged operations are generated
+// into a file named after the API.
+func OpenAPI(r io.Reader, opts Options) ([]File, error) {
+	var spec openAPISpec
+	if err := yaml.NewDecoder(r).Decode(&spec); err != nil {
+		return nil, errs.WithPrefix("failed decoding OpenAPI specification:", err)
+	}
+
+	if spec.Swagger != "" {
+		return nil, errors.New("Swagger 2.0 specifications are not supported, " +
+			"please convert the specification to OpenAPI 3 first")
+	}
+	if !strings.HasPrefix(spec.OpenAPI, "3.") {
+		return nil, errors.New("the given file is not an OpenAPI 3 specification")
+	}
+
+	var (
+		untagged []openAPIRequest
+		tags     []string
+		tagged   = make(map[string][]openAPIRequest)
+	)
+	for _, tag := range spec.Tags {
+		tags = append(tags, tag.Name)
+	}
+
+	for _, path := range spec.Paths {
+		for _, op := range path.Value.operations() {
+			req := spec.request(path.Key, op.Key, path.Value, op.Value)
+			if len(op.Value.Tags) == 0 {
+				untagged = append(untagged, req)
+				continue
+			}
+			tag := op.Value.Tags[0]
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+			tagged[tag] = append(tagged[tag], req)
+		}
+	}
+
+	origin := []string{fmt.Sprintf("Imported from OpenAPI specification %q", spec.Info.Title)}
+	if len(spec.Servers) > 0 {
+		origin = append(origin,
+			fmt.Sprintf("Set the parameter 'instance' to the base URL of the API (e.g. %s).", spec.Servers[0].URL))
+	} else {
+		origin = append(origin, "Set the parameter 'instance' to the base URL of the API.")
+	}
+
+	if !opts.Split {
+		b := newBuilder(origin...)
+		addOpenAPIRequests(b, untagged)
+		for _, tag := range tags {
+			if len(tagged[tag]) > 0 {
+				b.logSection(tag)
+				addOpenAPIRequests(b, tagged[tag])
+			}
+		}
+		return []File{{Name: fileName(spec.Info.Title), Goatfile: b.goatfile()}}, nil
+	}
+
+	var files []File
+
+	if len(untagged) > 0 {
+		b := newBuilder(origin...)
+		addOpenAPIRequests(b, untagged)
+		files = append(files, File{Name: fileName(spec.Info.Title), Goatfile: b.goatfile()})
+	}
+	for _, tag := range tags {
+		if len(tagged[tag]) == 0 {
+			continue
+		}
+		b := newBuilder(append([]string{fmt.Sprintf("%s (tag %q)", origin[0], tag)}, origin[1:]...)...)
+		addOpenAPIRequests(b, tagged[tag])
+		files = append(files, File{Name: fileName(tag), Goatfile: b.goatfile()})
+	}
+
+	uniqueFileNames(files)
+
+	return files, nil
+}
+
+type openAPIRequest struct {
+	req      *ast.Request
+	comments []string
+}
+
+func addOpenAPIRequests(b *builder, reqs []openAPIRequest) {
+	for _, r := range reqs {
+		b.request(r.req, r.comments...)
+	}
+}
+
+var openAPIPathParamRx = regexp.MustCompile(`\{([^{}]+)\}`)
+
+func (t *openAPISpec) request(path, method string, item openAPIPathItem, op *openAPIOperation) openAPIRequest {
+	var comments []string
+	if op.Summary != "" {
+		comments = append(comments, op.Summary)
+	} else if op.OperationID != "" {
+		comments = append(comments, op.OperationID)
+	}
+	if op.Deprecated {
+		comments = append(comments, "Deprecated")
+	}
+	warn := func(format string, args ...any) {
+		msg := fmt.Sprintf(format, args...)
+		log.Warn().Field("operation", method+" "+path).Msg(msg)
+		comments = append(comments, "WARNING: "+msg)
+	}
+
+	url := openAPIPathParamRx.ReplaceAllStringFunc(path, func(m string) string {
+		return paramTemplate(m[1 : len(m)-1])
+	})
+
+	req := &ast.Request{
+		Head: ast.RequestHead{Method: method, Url: "{{.instance}}" + url},
+	}
+
+	var (
+		headers ast.KVList[string]
+		query   ast.KVList[any]
+	)
+
+	for _, param := range t.parameters(item.Parameters, op.Parameters) {
+		if !param.Required {
+			continue
+		}
+		value := t.parameterValue(param)
+		switch param.In {
+		case "query":
+			query = append(query, ast.KV[any]{Key: param.Name, Value: formValue(value)})
+		case "header":
+			headers = append(headers, ast.KV[string]{Key: param.Name, Value: fmt.Sprint(value)})
+		}
+	}
+
+	security := t.Security
+	if op.Security != nil {
+		security = *op.Security
+	}
+	if auth, authHeaders, authQuery, ok := t.auth(security); ok {
+		if len(auth) > 0 {
+			req.Blocks = append(req.Blocks, ast.RequestAuth{KVList: auth})
+		}
+		headers = append(headers, authHeaders...)
+		query = append(query, authQuery...)
+	} else {
+		warn("security requirement could not be translated")
+	}
+
+	if op.RequestBody != nil {
+		body, contentType, ok := t.body(*op.RequestBody)
+		if !ok {
+			warn("request body could not be translated")
+		}
+		if body != nil {
+			req.Blocks = append(req.Blocks, body)
+		}
+		if contentType != "" && !hasHeader(headers, "Content-Type") {
+			headers = append(headers, ast.KV[string]{Key: "Content-Type", Value: contentType})
+		}
+	}
+
+	if len(headers) > 0 {
+		req.Blocks = append(req.Blocks, header(headers...))
+	}
+	if len(query) > 0 {
+		req.Blocks = append(req.Blocks, ast.RequestQueryParams{KVList: query})
+	}
+
+	if script := openAPIStatusAssertion(op.Responses); script != "" {
+		req.Blocks = append(req.Blocks, ast.RequestScript{DataContent: ast.TextBlock{Content: script + "\n"}})
+	}
+
+	return openAPIRequest{req: req, comments: comments}
+}
+
+// parameters returns the resolved parameters of the path
+// item overridden by the parameters of the operation.
+func (t *openAPISpec) parameters(pathParams, opParams []openAPIParameter) []openAPIParameter {
+	var params []openAPIParameter
+	for _, p := range append(slices.Clone(pathParams), opParams...) {
+		if p.Ref != "" {
+			p = t.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")]
+		}
+		i := slices.IndexFunc(params, func(q openAPIParameter) bool {
+			return q.Name == p.Name && q.In == p.In
+		})
+		if i == -1 {
+			params = append(params, p)
+		} else {
+			params[i] = p
+		}
+	}
+	return params
+}
+
+func (t *openAPISpec) parameterValue(param openAPIParameter) any {
+	if param.Example != nil {
+		return param.Example
+	}
+	for _, key := range []string{"example", "default"} {
+		if v, ok := param.Schema[key]; ok && v != nil {
+			return v
+		}
+	}
+	return paramTemplate(param.Name)
+}
+
+func (t *openAPISpec) auth(security []map[string][]string) (
+	kvs ast.KVList[any], headers ast.KVList[string], query ast.KVList[any], ok bool,
+) {
+	if len(security) == 0 || len(security[0]) == 0 {
+		return nil, nil, nil, true
+	}
+
+	names := make([]string, 0, len(security[0]))
+	for name := range security[0] {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		scheme, found := t.Components.SecuritySchemes[name]
+		if !found {
+			continue
+		}
+
+		switch {
+		case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+			kvs = append(kvs,
+				ast.KV[any]{Key: "username", Value: paramTemplate("username")},
+				ast.KV[any]{Key: "password", Value: paramTemplate("password")})
+		case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "bearer"),
+			scheme.Type == "oauth2", scheme.Type == "openIdConnect":
+			kvs = append(kvs,
+				ast.KV[any]{Key: "type", Value: "bearer"},
+				ast.KV[any]{Key: "token", Value: paramTemplate("token")})
+		case scheme.Type == "apiKey" && scheme.In == "header":
+			headers = append(headers, ast.KV[string]{Key: scheme.Name, Value: paramTemplate(name)})
+		case scheme.Type == "apiKey" && scheme.In == "query":
+			query = append(query, ast.KV[any]{Key: scheme.Name, Value: paramTemplate(name)})
+		default:
+			continue
+		}
+
+		return kvs, headers, query, true
+	}
+
+	return nil, nil, nil, false
+}
+
+func (t *openAPISpec) body(body openAPIRequestBody) (block ast.RequestBlock, contentType string, ok bool) {
+	if body.Ref != "" {
+		body = t.Components.RequestBodies[strings.TrimPrefix(body.Ref, "#/components/requestBodies/")]
+	}
+
+	for _, content := range body.Content {
+		mediaType := content.Key
+		media := content.Value
+
+		switch {
+		case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+			data, err := json.MarshalIndent(t.mediaExample(media), "", "  ")
+			if err != nil {
+				return nil, "", false
+			}
+			return textBody(string(data)), mediaType, true
+
+		case mediaType == "application/x-www-form-urlencoded":
+			return ast.FormUrlEncoded{KVList: t.formFields(media, false)}, "", true
+
+		case mediaType == "multipart/form-data":
+			return ast.FormData{KVList: t.formFields(media, true)}, "", true
+		}
+	}
+
+	return nil, "", len(body.Content) == 0
+}
+
+func (t *openAPISpec) mediaExample(media openAPIMediaType) any {
+	if media.Example != nil {
+		return media.Example
+	}
+	if len(media.Examples) > 0 {
+		if v, ok := media.Examples[0].Value["value"]; ok {
+			return v
+		}
+	}
+	return t.sample(media.Schema, make(set.Set[string]))
+}
+
+// formFields returns the properties of the media type's
+// schema as form fields. Binary properties are set to
+// file descriptors if files are allowed.
+func (t *openAPISpec) formFields(media openAPIMediaType, files bool) ast.KVList[any] {
+	schema := t.resolveSchema(media.Schema, make(set.Set[string]))
+	example, _ := t.mediaExample(media).(map[string]any)
+	props, _ := schema["properties"].(map[string]any)
+
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var kvs ast.KVList[any]
+	for _, name := range names {
+		prop, _ := props[name].(map[string]any)
+		if files && prop["format"] == "binary" {
+			kvs = append(kvs, ast.KV[any]{Key: name, Value: ast.FileDescriptor{Path: name}})
+			continue
+		}
+		var value any = paramTemplate(name)
+		if v, ok := example[name]; ok && v != nil {
+			value = formValue(v)
+		}
+		kvs = append(kvs, ast.KV[any]{Key: name, Value: value})
+	}
+
+	return kvs
+}
+
+func formValue(v any) any {
+	switch vt := v.(type) {
+	case int:
+		return int64(vt)
+	case float64, bool, string:
+		return vt
+	}
+	return fmt.Sprint(v)
+}
+
+func (t *openAPISpec) resolveSchema(schema map[string]any, seen set.Set[string]) map[string]any {
+	ref, ok := schema["$ref"].(string)
+	if !ok {
+		return schema
+	}
+	name := strings.TrimPrefix(ref, "#/components/schemas/")
+	if !seen.Add(name) {
+		return nil
+	}
+	resolved, _ := t.Components.Schemas[name].(map[string]any)
+	return t.resolveSchema(resolved, seen)
+}
+
+// sample returns an example value of the given schema.
+func (t *openAPISpec) sample(schema map[string]any, seen set.Set[string]) any {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		if !seen.Add(name) {
+			return nil
+		}
+		defer seen.Remove(name)
+		resolved, _ := t.Components.Schemas[name].(map[string]any)
+		return t.sample(resolved, seen)
+	}
+
+	for _, key := range []string{"example", "default", "const"} {
+		if v, ok := schema[key]; ok {
+			return v
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		return enum[0]
+	}
+
+	if all, ok := schema["allOf"].([]any); ok {
+		res := make(map[string]any)
+		for _, s := range all {
+			sub, _ := s.(map[string]any)
+			if obj, ok := t.sample(sub, seen).(map[string]any); ok {
+				for k, v := range obj {
+					res[k] = v
+				}
+			}
+		}
+		return res
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if variants, ok := schema[key].([]any); ok && len(variants) > 0 {
+			sub, _ := variants[0].(map[string]any)
+			return t.sample(sub, seen)
+		}
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		res := make(map[string]any)
+		props, _ := schema["properties"].(map[string]any)
+		for name, p := range props {
+			prop, _ := p.(map[string]any)
+			if readOnly, _ := prop["readOnly"].(bool); readOnly {
+				continue
+			}
+			res[name] = t.sample(prop, seen)
+		}
+		return res
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		if item := t.sample(items, seen); item != nil {
+			return []any{item}
+		}
+		return []any{}
+	case "string":
+		switch schema["format"] {
+		case "date-time":
+			return "1970-01-01T00:00:00Z"
+		case "date":
+			return "1970-01-01"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		}
+		return "string"
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	}
+
+	return nil
+}
+
+func schemaType(schema map[string]any) string {
+	switch typ := schema["type"].(type) {
+	case string:
+		return typ
+	case []any:
+		// OpenAPI 3.1 allows multiple types.
+		for _, t := range typ {
+			if s, ok := t.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	return ""
+}
+
+// openAPIStatusAssertion returns a script asserting the
+// first success status code of the given responses.
+func openAPIStatusAssertion(responses orderedMap[any]) string {
+	for _, resp := range responses {
+		code := strings.ToUpper(resp.Key)
+		switch {
+		case code == "2XX":
+			return "assert(response.StatusCode >= 200 && response.StatusCode < 300, " +
+				"`Status code was ${response.StatusCode}`);"
+		case len(code) == 3 && code[0] == '2':
+			return fmt.Sprintf("assert_eq(response.StatusCode, %s, \"invalid status code\");", code)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,209 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/studio-b12/goat/pkg/set"
+)
+
+const testSpec = `
+openapi: 3.0.3
+info:
+  title: Pet Store
+servers:
+  - url: https://petstore.example.com/v1
+security:
+  - bearerAuth: []
+tags:
+  - name: pets
+paths:
+  /health:
+    get:
+      security: []
+      responses:
+        "200":
+          description: ok
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        schema: {type: integer}
+    get:
+      summary: Get a pet
+      tags: [pets]
+      parameters:
+        - $ref: '#/components/parameters/Verbose'
+        - name: X-Tenant
+          in: header
+          required: true
+          schema: {type: string}
+        - name: optional
+          in: query
+          schema: {type: string}
+      responses:
+        200: {description: ok}
+    put:
+      operationId: updatePet
+      tags: [pets]
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        2XX: {description: ok}
+  /pets:
+    post:
+      tags: [store]
+      security:
+        - apiKey: []
+      requestBody:
+        content:
+          multipart/form-data:
+            schema:
+              type: object
+              properties:
+                name: {type: string, example: Rex}
+                photo: {type: string, format: binary}
+      responses:
+        "201": {description: created}
+components:
+  parameters:
+    Verbose:
+      name: verbose
+      in: query
+      required: true
+      schema: {type: boolean, default: false}
+  securitySchemes:
+    bearerAuth: {type: http, scheme: bearer}
+    apiKey: {type: apiKey, in: header, name: X-Api-Key}
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id: {type: integer, readOnly: true}
+        name: {type: string, example: Rex}
+        tags: {type: array, items: {type: string}}
+        owner: {$ref: '#/components/schemas/Owner'}
+    Owner:
+      properties:
+        pets: {type: array, items: {$ref: '#/components/schemas/Pet'}}
+`
+
+func TestOpenAPI(t *testing.T) {
+	files, err := OpenAPI(strings.NewReader(testSpec), Options{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, 1, len(files))
+	assert.Equal(t, "pet-store", files[0].Name)
+
+	const expected = `// Imported from OpenAPI specification "Pet Store"
+// Set the parameter 'instance' to the base URL of the API (e.g. https://petstore.example.com/v1).
+
+### Tests
+
+GET {{.instance}}/health
+
+[Script]
+assert_eq(response.StatusCode, 200, "invalid status code");
+
+---
+
+##### pets
+
+// Get a pet
+GET {{.instance}}/pets/{{.petId}}
+
+[Header]
+X-Tenant: {{index . "X-Tenant"}}
+
+[QueryParams]
+verbose = false
+
+[Auth]
+type = "bearer"
+token = "{{.token}}"
+
+[Script]
+assert_eq(response.StatusCode, 200, "invalid status code");
+
+---
+
+// updatePet
+PUT {{.instance}}/pets/{{.petId}}
+
+[Header]
+Content-Type: application/json
+
+[Auth]
+type = "bearer"
+token = "{{.token}}"
+
+[Body]
+{
+  "name": "Rex",
+  "owner": {
+    "pets": []
+  },
+  "tags": [
+    "string"
+  ]
+}
+
+[Script]
+assert(response.StatusCode >= 200 && response.StatusCode < 300, ` + "`Status code was ${response.StatusCode}`" + `);
+
+---
+
+##### store
+
+POST {{.instance}}/pets
+
+[Header]
+X-Api-Key: {{.apiKey}}
+
+[FormData]
+name = "Rex"
+photo = @photo
+
+[Script]
+assert_eq(response.StatusCode, 201, "invalid status code");
+`
+
+	res := printGoatfile(t, files[0])
+	assert.Equal(t, expected, res)
+}
+
+func TestOpenAPI_Split(t *testing.T) {
+	files, err := OpenAPI(strings.NewReader(testSpec), Options{Split: true})
+	assert.Nil(t, err, err)
+
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.Name)
+		printGoatfile(t, f)
+	}
+	assert.Equal(t, []string{"pet-store", "pets", "store"}, names)
+}
+
+func TestOpenAPI_Invalid(t *testing.T) {
+	_, err := OpenAPI(strings.NewReader(`swagger: "2.0"`), Options{})
+	assert.NotNil(t, err)
+
+	_, err = OpenAPI(strings.NewReader(`foo: bar`), Options{})
+	assert.NotNil(t, err)
+}
+
+func TestOpenAPISample(t *testing.T) {
+	var spec openAPISpec
+
+	assert.Equal(t, "a", spec.sample(map[string]any{"type": "string", "enum": []any{"a", "b"}}, make(set.Set[string])))
+	assert.Equal(t, "string", spec.sample(map[string]any{"type": []any{"null", "string"}}, make(set.Set[string])))
+	assert.Equal(t, map[string]any{"a": 0, "b": false}, spec.sample(map[string]any{"allOf": []any{
+		map[string]any{"properties": map[string]any{"a": map[string]any{"type": "integer"}}},
+		map[string]any{"properties": map[string]any{"b": map[string]any{"type": "boolean"}}},
+	}}, make(set.Set[string])))
+	assert.Nil(t, spec.sample(map[string]any{}, make(set.Set[string])))
+}
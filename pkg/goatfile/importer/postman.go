@@ -0,0 +1,614 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/studio-b12/goat/pkg/errs"
+	"github.com/studio-b12/goat/pkg/goatfile/ast"
+	"github.com/zekrotja/rogu/log"
+)
+
+type postmanCollection struct {
+	Info struct {
+		Name   string `json:"name"`
+		Schema string `json:"schema"`
+	} `json:"info"`
+	Item  []postmanItem  `json:"item"`
+	Event []postmanEvent `json:"event"`
+	Auth  *postmanAuth   `json:"auth"`
+}
+
+type postmanItem struct {
+	Name    string          `json:"name"`
+	Item    []postmanItem   `json:"item"`
+	Request *postmanRequest `json:"request"`
+	Event   []postmanEvent  `json:"event"`
+	Auth    *postmanAuth    `json:"auth"`
+}
+
+func (t postmanItem) isFolder() bool {
+	return t.Request == nil
+}
+
+type postmanRequest struct {
+	Method string       `json:"method"`
+	Header []postmanKV  `json:"header"`
+	URL    postmanURL   `json:"url"`
+	Body   *postmanBody `json:"body"`
+	Auth   *postmanAuth `json:"auth"`
+}
+
+func (t *postmanRequest) UnmarshalJSON(data []byte) error {
+	// A request can also be defined by its URL only.
+	var url string
+	if json.Unmarshal(data, &url) == nil {
+		*t = postmanRequest{Method: "GET", URL: postmanURL{Raw: url}}
+		return nil
+	}
+
+	type plain postmanRequest
+	return json.Unmarshal(data, (*plain)(t))
+}
+
+type postmanURL struct {
+	Raw      string      `json:"raw"`
+	Variable []postmanKV `json:"variable"`
+}
+
+func (t *postmanURL) UnmarshalJSON(data []byte) error {
+	if json.Unmarshal(data, &t.Raw) == nil {
+		return nil
+	}
+
+	type plain postmanURL
+	return json.Unmarshal(data, (*plain)(t))
+}
+
+type postmanKV struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+	Type     string `json:"type"`
+	Src      any    `json:"src"`
+}
+
+type postmanBody struct {
+	Mode       string      `json:"mode"`
+	Disabled   bool        `json:"disabled"`
+	Raw        string      `json:"raw"`
+	URLEncoded []postmanKV `json:"urlencoded"`
+	FormData   []postmanKV `json:"formdata"`
+	File       struct {
+		Src string `json:"src"`
+	} `json:"file"`
+	GraphQL struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables"`
+	} `json:"graphql"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type postmanAuth struct {
+	Type   string
+	Params map[string]string
+}
+
+func (t *postmanAuth) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(raw["type"], &t.Type); err != nil {
+		return err
+	}
+
+	t.Params = make(map[string]string)
+
+	params, ok := raw[t.Type]
+	if !ok {
+		return nil
+	}
+
+	// Collections of schema version 2.1 contain a list
+	// of key-value pairs while version 2.0 contains an
+	// object.
+	var list []struct {
+		Key   string `json:"key"`
+		Value any    `json:"value"`
+	}
+	if json.Unmarshal(params, &list) == nil {
+		for _, kv := range list {
+			t.Params[kv.Key] = fmt.Sprint(kv.Value)
+		}
+		return nil
+	}
+
+	var obj map[string]any
+	if err := json.Unmarshal(params, &obj); err != nil {
+		return err
+	}
+	for k, v := range obj {
+		t.Params[k] = fmt.Sprint(v)
+	}
+
+	return nil
+}
+
+type postmanEvent struct {
+	Listen   string `json:"listen"`
+	Disabled bool   `json:"disabled"`
+	Script   struct {
+		Exec json.RawMessage `json:"exec"`
+	} `json:"script"`
+}
+
+func (t postmanEvent) source() string {
+	var lines []string
+	if json.Unmarshal(t.Script.Exec, &lines) == nil {
+		return strings.Join(lines, "\n")
+	}
+
+	var src string
+	json.Unmarshal(t.Script.Exec, &src)
+	return src
+}
+
+// postmanScope holds the settings of the collection
+// and folders which are inherited by requests.
+type postmanScope struct {
+	auth   *postmanAuth
+	events []postmanEvent
+}
+
+func (t postmanScope) with(item postmanItem) postmanScope {
+	if item.Auth != nil && item.Auth.Type != "inherit" {
+		t.auth = item.Auth
+	}
+	t.events = append(slices.Clone(t.events), item.Event...)
+	return t
+}
+
+// Postman generates Goatfiles from the Postman collection
+// of schema version 2.0 or 2.1 read from r.
+//
+// Requests in folders are preceded by a log section
+// containing the folder path. When opts.Split is set,
+// each top level folder is generated as separate file
+// and requests outside of folders are generated into
+// a file named after the collection.
+//
+// Pre-request and test scripts, including those defined
+// on the collection and folders, are translated into
+// [PreScript] and [Script] blocks of the requests. Calls
+// to pm.* APIs which can not be translated are preceded
+// by a warning comment.
+func Postman(r io.Reader, opts Options) ([]File, error) {
+	var coll postmanCollection
+	if err := json.NewDecoder(r).Decode(&coll); err != nil {
+		return nil, errs.WithPrefix("failed decoding Postman collection:", err)
+	}
+
+	if coll.Info.Schema == "" && coll.Item == nil {
+		return nil, errors.New("the given file is not a Postman collection")
+	}
+	if coll.Info.Schema != "" && !strings.Contains(coll.Info.Schema, "/v2.") {
+		return nil, fmt.Errorf("unsupported Postman collection schema: %s", coll.Info.Schema)
+	}
+
+	scope := postmanScope{auth: coll.Auth, events: coll.Event}
+	origin := fmt.Sprintf("Imported from Postman collection %q", coll.Info.Name)
+
+	if !opts.Split {
+		b := newBuilder(origin)
+		addPostmanItems(b, coll.Item, scope, nil)
+		return []File{{Name: fileName(coll.Info.Name), Goatfile: b.goatfile()}}, nil
+	}
+
+	var files []File
+
+	root := newBuilder(origin)
+	for _, item := range coll.Item {
+		if !item.isFolder() {
+			addPostmanRequest(root, item, scope)
+			continue
+		}
+		b := newBuilder(fmt.Sprintf("%s (folder %q)", origin, item.Name))
+		addPostmanItems(b, item.Item, scope.with(item), nil)
+		files = append(files, File{Name: fileName(item.Name), Goatfile: b.goatfile()})
+	}
+	if !root.empty() {
+		files = append([]File{{Name: fileName(coll.Info.Name), Goatfile: root.goatfile()}}, files...)
+	}
+
+	uniqueFileNames(files)
+
+	return files, nil
+}
+
+func addPostmanItems(b *builder, items []postmanItem, scope postmanScope, folders []string) {
+	// The log section of the folder must be repeated
+	// when requests follow a sub folder.
+	inSubFolder := true
+
+	for _, item := range items {
+		if item.isFolder() {
+			path := append(slices.Clone(folders), item.Name)
+			b.logSection(strings.Join(path, " / "))
+			addPostmanItems(b, item.Item, scope.with(item), path)
+			inSubFolder = true
+			continue
+		}
+
+		if inSubFolder && len(folders) > 0 && b.lastLogSection() != strings.Join(folders, " / ") {
+			b.logSection(strings.Join(folders, " / "))
+		}
+		inSubFolder = false
+
+		addPostmanRequest(b, item, scope)
+	}
+}
+
+func addPostmanRequest(b *builder, item postmanItem, scope postmanScope) {
+	req := item.Request
+	scope = scope.with(postmanItem{Event: item.Event, Auth: req.Auth})
+
+	comments := []string{item.Name}
+	warn := func(format string, args ...any) {
+		msg := fmt.Sprintf(format, args...)
+		log.Warn().Field("request", item.Name).Msg(msg)
+		comments = append(comments, "WARNING: "+msg)
+	}
+
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = "GET"
+	}
+
+	res := &ast.Request{
+		Head: ast.RequestHead{Method: method, Url: postmanURLString(req.URL)},
+	}
+
+	var headers ast.KVList[string]
+	for _, h := range req.Header {
+		if h.Disabled {
+			continue
+		}
+		headers = append(headers, ast.KV[string]{Key: h.Key, Value: translatePostmanTemplates(h.Value)})
+	}
+
+	var query ast.KVList[any]
+	if scope.auth != nil {
+		auth, authHeaders, authQuery, ok := postmanAuthBlock(scope.auth)
+		if !ok {
+			warn("auth type %q could not be translated", scope.auth.Type)
+		}
+		if len(auth) > 0 {
+			res.Blocks = append(res.Blocks, ast.RequestAuth{KVList: auth})
+		}
+		headers = append(headers, authHeaders...)
+		query = append(query, authQuery...)
+	}
+
+	if req.Body != nil && !req.Body.Disabled {
+		body, contentType, ok := postmanBodyBlock(req.Body)
+		if !ok {
+			warn("body mode %q could not be translated", req.Body.Mode)
+		}
+		if body != nil {
+			res.Blocks = append(res.Blocks, body)
+		}
+		if contentType != "" && !hasHeader(headers, "Content-Type") {
+			headers = append(headers, ast.KV[string]{Key: "Content-Type", Value: contentType})
+		}
+	}
+
+	if len(headers) > 0 {
+		res.Blocks = append(res.Blocks, header(headers...))
+	}
+	if len(query) > 0 {
+		res.Blocks = append(res.Blocks, ast.RequestQueryParams{KVList: query})
+	}
+
+	if script, n := postmanScript(scope.events, "prerequest"); script != "" {
+		if n > 0 {
+			warn("%d line(s) of the pre-request script could not be translated", n)
+		}
+		res.Blocks = append(res.Blocks, ast.RequestPreScript{DataContent: ast.TextBlock{Content: script + "\n"}})
+	}
+	if script, n := postmanScript(scope.events, "test"); script != "" {
+		if n > 0 {
+			warn("%d line(s) of the test script could not be translated", n)
+		}
+		res.Blocks = append(res.Blocks, ast.RequestScript{DataContent: ast.TextBlock{Content: script + "\n"}})
+	}
+
+	b.request(res, comments...)
+}
+
+func postmanURLString(u postmanURL) string {
+	raw := u.Raw
+
+	for _, v := range u.Variable {
+		// Empty path variables are replaced with Postman variables
+		// of the same name, which are translated to templates below.
+		value := v.Value
+		if value == "" {
+			value = "{{" + v.Key + "}}"
+		}
+		raw = regexp.MustCompile(`/:`+regexp.QuoteMeta(v.Key)+`([/?#]|$)`).
+			ReplaceAllStringFunc(raw, func(m string) string {
+				return "/" + value + m[len(v.Key)+2:]
+			})
+	}
+
+	raw = translatePostmanTemplates(raw)
+	if !strings.Contains(raw, "://") && !strings.HasPrefix(raw, "{{") {
+		raw = "http://" + raw
+	}
+
+	return raw
+}
+
+func postmanAuthBlock(auth *postmanAuth) (
+	kvs ast.KVList[any], headers ast.KVList[string], query ast.KVList[any], ok bool,
+) {
+	param := func(key string) string {
+		return translatePostmanTemplates(auth.Params[key])
+	}
+
+	switch auth.Type {
+	case "noauth":
+	case "basic":
+		kvs = ast.KVList[any]{
+			{Key: "username", Value: param("username")},
+			{Key: "password", Value: param("password")},
+		}
+	case "bearer":
+		kvs = ast.KVList[any]{
+			{Key: "type", Value: "bearer"},
+			{Key: "token", Value: param("token")},
+		}
+	case "apikey":
+		if auth.Params["in"] == "query" {
+			query = ast.KVList[any]{{Key: param("key"), Value: param("value")}}
+		} else {
+			headers = ast.KVList[string]{{Key: param("key"), Value: param("value")}}
+		}
+	default:
+		return nil, nil, nil, false
+	}
+
+	return kvs, headers, query, true
+}
+
+func postmanBodyBlock(body *postmanBody) (block ast.RequestBlock, contentType string, ok bool) {
+	switch body.Mode {
+	case "raw":
+		switch body.Options.Raw.Language {
+		case "json":
+			contentType = "application/json"
+		case "xml":
+			contentType = "application/xml"
+		}
+		if strings.TrimSpace(body.Raw) == "" {
+			return nil, "", true
+		}
+		return textBody(translatePostmanTemplates(body.Raw)),
+			contentType, true
+
+	case "urlencoded":
+		var kvs ast.KVList[any]
+		for _, kv := range body.URLEncoded {
+			if !kv.Disabled {
+				kvs = append(kvs, ast.KV[any]{Key: kv.Key, Value: translatePostmanTemplates(kv.Value)})
+			}
+		}
+		return ast.FormUrlEncoded{KVList: kvs}, "", true
+
+	case "formdata":
+		var kvs ast.KVList[any]
+		for _, kv := range body.FormData {
+			if kv.Disabled {
+				continue
+			}
+			if kv.Type == "file" {
+				kvs = append(kvs, ast.KV[any]{Key: kv.Key, Value: ast.FileDescriptor{Path: postmanFileSrc(kv.Src)}})
+			} else {
+				kvs = append(kvs, ast.KV[any]{Key: kv.Key, Value: translatePostmanTemplates(kv.Value)})
+			}
+		}
+		return ast.FormData{KVList: kvs}, "", true
+
+	case "file":
+		if body.File.Src == "" {
+			return nil, "", true
+		}
+		return ast.RequestBody{DataContent: ast.FileDescriptor{Path: body.File.Src}}, "", true
+
+	case "graphql":
+		gql := map[string]any{"query": body.GraphQL.Query}
+		if vars := strings.TrimSpace(body.GraphQL.Variables); vars != "" {
+			gql["variables"] = json.RawMessage(vars)
+		}
+		data, err := json.MarshalIndent(gql, "", "  ")
+		if err != nil {
+			return nil, "", false
+		}
+		return textBody(translatePostmanTemplates(string(data))),
+			"application/json", true
+	}
+
+	return nil, "", false
+}
+
+func postmanFileSrc(src any) string {
+	switch s := src.(type) {
+	case string:
+		return s
+	case []any:
+		if len(s) > 0 {
+			return fmt.Sprint(s[0])
+		}
+	}
+	return "file"
+}
+
+// postmanScript returns the translated scripts of the
+// given events listening on the given event joined
+// together and the number of untranslated lines.
+func postmanScript(events []postmanEvent, listen string) (string, int) {
+	var (
+		scripts      []string
+		untranslated int
+	)
+
+	for _, e := range events {
+		if e.Listen != listen || e.Disabled {
+			continue
+		}
+		src := strings.TrimSpace(e.source())
+		if src == "" {
+			continue
+		}
+		script, n := translatePostmanScript(src)
+		scripts = append(scripts, script)
+		untranslated += n
+	}
+
+	return strings.Join(scripts, "\n\n"), untranslated
+}
+
+var postmanVarRx = regexp.MustCompile(`\{\{\s*([^{}\s]+)\s*\}\}`)
+
+// postmanDynamicVars maps dynamic variables of Postman
+// to equivalent template builtins.
+var postmanDynamicVars = map[string]string{
+	"$timestamp":          "{{timestamp}}",
+	"$isoTimestamp":       `{{timestamp "RFC3339"}}`,
+	"$randomInt":          "{{randomInt 1001}}",
+	"$randomAlphaNumeric": "{{randomString 1}}",
+}
+
+// translatePostmanTemplates replaces the Postman variables
+// in v with templates inserting the parameters of the
+// same name.
+func translatePostmanTemplates(v string) string {
+	return postmanVarRx.ReplaceAllStringFunc(v, func(m string) string {
+		name := postmanVarRx.FindStringSubmatch(m)[1]
+		if tmpl, ok := postmanDynamicVars[name]; ok {
+			return tmpl
+		}
+		return paramTemplate(name)
+	})
+}
+
+const jsIdent = `[A-Za-z_$][A-Za-z0-9_$]*`
+
+var (
+	pmVariablesGetRx = regexp.MustCompile(
+		`pm\.(?:environment|variables|collectionVariables|globals|iterationData)\.get\(\s*(?:"(` +
+			jsIdent + `)"|'(` + jsIdent + `)')\s*\)`)
+	pmVariablesSetRx = regexp.MustCompile(
+		`^(\s*)pm\.(?:environment|variables|collectionVariables|globals)\.set\(\s*(?:"(` +
+			jsIdent + `)"|'(` + jsIdent + `)')\s*,\s*(.+?)\s*\)\s*;?\s*$`)
+	pmHeaderGetRx  = regexp.MustCompile(`pm\.response\.headers\.get\(\s*(?:"([^"]+)"|'([^']+)')\s*\)`)
+	pmStatusRx     = regexp.MustCompile(`pm\.response\.to\.have\.status\(\s*(\d+)\s*\)`)
+	pmExpectEqRx   = regexp.MustCompile(`^(\s*)pm\.expect\((.+)\)\.to\.(?:eql|equal|deep\.equal|be\.eql|be\.equal)\((.+)\)\s*;?\s*$`)
+	pmExpectBoolRx = regexp.MustCompile(`^(\s*)pm\.expect\((.+)\)\.to\.be\.(true|false|ok)\s*;?\s*$`)
+	pmTestRx       = regexp.MustCompile(`^(\s*)pm\.test\(\s*(?:"(.*)"|'(.*)')\s*,\s*(?:function\s*\(\s*\)|\(\s*\)\s*=>)\s*\{\s*$`)
+	pmUsageRx      = regexp.MustCompile(`\b(?:pm|postman)\.`)
+)
+
+var pmReplacer = strings.NewReplacer(
+	"pm.response.json()", "response.Body",
+	"pm.response.code", "response.StatusCode",
+	"console.log(", "println(",
+	"console.info(", "info(",
+	"console.warn(", "warn(",
+	"console.error(", "error(",
+)
+
+// translatePostmanScript translates the commonly used
+// pm.* APIs in the given script into the equivalent
+// Goat builtins and state variables. Lines which still
+// use pm.* APIs afterwards are preceded by a warning
+// comment. The number of those lines is returned.
+func translatePostmanScript(script string) (string, int) {
+	lines := strings.Split(script, "\n")
+
+	for i, line := range lines {
+		if m := pmTestRx.FindStringSubmatch(line); m != nil {
+			// Tests are translated into immediately invoked
+			// functions, so the closing line of the test
+			// must be found.
+			closing := slices.IndexFunc(lines[i+1:], func(l string) bool {
+				return strings.TrimRight(l, " \t") == m[1]+"});"
+			})
+			if closing != -1 {
+				lines[i] = m[1] + "// " + m[2] + m[3] + "\n" + m[1] + "(function () {"
+				lines[i+1+closing] = m[1] + "})();"
+			}
+			continue
+		}
+
+		if m := pmVariablesSetRx.FindStringSubmatch(line); m != nil {
+			line = m[1] + m[2] + m[3] + " = " + m[4] + ";"
+		}
+		if m := pmExpectEqRx.FindStringSubmatch(line); m != nil {
+			line = m[1] + "assert_eq(" + m[2] + ", " + m[3] + ");"
+		}
+		if m := pmExpectBoolRx.FindStringSubmatch(line); m != nil {
+			if m[3] == "ok" {
+				line = m[1] + "assert(" + m[2] + ");"
+			} else {
+				line = m[1] + "assert_eq(" + m[2] + ", " + m[3] + ");"
+			}
+		}
+
+		line = replaceSubmatch(pmVariablesGetRx, line, func(m []string) string {
+			return m[1] + m[2]
+		})
+		line = replaceSubmatch(pmHeaderGetRx, line, func(m []string) string {
+			return fmt.Sprintf("response.Header[%q][0]", textproto.CanonicalMIMEHeaderKey(m[1]+m[2]))
+		})
+		line = replaceSubmatch(pmStatusRx, line, func(m []string) string {
+			return "assert_eq(response.StatusCode, " + m[1] + ")"
+		})
+		line = pmReplacer.Replace(line)
+
+		lines[i] = line
+	}
+
+	var (
+		res          []string
+		untranslated int
+	)
+	for _, line := range strings.Split(strings.Join(lines, "\n"), "\n") {
+		if pmUsageRx.MatchString(line) {
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			res = append(res, indent+"// WARNING: the following Postman API usage could not be translated")
+			untranslated++
+		}
+		res = append(res, line)
+	}
+
+	return strings.Join(res, "\n"), untranslated
+}
+
+func replaceSubmatch(rx *regexp.Regexp, s string, repl func(m []string) string) string {
+	return rx.ReplaceAllStringFunc(s, func(m string) string {
+		return repl(rx.FindStringSubmatch(m))
+	})
+}
@@ -0,0 +1,243 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/studio-b12/goat/pkg/goatfile"
+	"github.com/studio-b12/goat/pkg/goatfile/formatter"
+)
+
+const testCollection = `{
+  "info": {
+    "name": "Test API",
+    "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+  },
+  "auth": {"type": "bearer", "bearer": [{"key": "token", "value": "{{token}}"}]},
+  "item": [
+    {
+      "name": "Login",
+      "request": {
+        "method": "post",
+        "auth": {"type": "noauth"},
+        "header": [
+          {"key": "X-Request-Id", "value": "{{$timestamp}}"},
+          {"key": "X-Disabled", "value": "foo", "disabled": true}
+        ],
+        "url": {"raw": "{{base-url}}/login"},
+        "body": {
+          "mode": "raw",
+          "raw": "{\"user\": \"{{user}}\"}",
+          "options": {"raw": {"language": "json"}}
+        }
+      },
+      "event": [{"listen": "test", "script": {"exec": [
+        "pm.environment.set(\"token\", pm.response.json().token);"
+      ]}}]
+    },
+    {
+      "name": "Users",
+      "item": [
+        {
+          "name": "Get user",
+          "request": {
+            "method": "GET",
+            "url": {
+              "raw": "{{base-url}}/users/:id?full=true",
+              "variable": [{"key": "id", "value": "{{userId}}"}]
+            }
+          }
+        },
+        {
+          "name": "Admin",
+          "auth": {"type": "basic", "basic": [
+            {"key": "username", "value": "admin"},
+            {"key": "password", "value": "{{password}}"}
+          ]},
+          "item": [
+            {
+              "name": "Upload",
+              "request": {
+                "method": "POST",
+                "url": "{{base-url}}/upload",
+                "body": {"mode": "formdata", "formdata": [
+                  {"key": "file", "type": "file", "src": "avatar.png"},
+                  {"key": "name", "value": "avatar", "type": "text"}
+                ]}
+              }
+            }
+          ]
+        },
+        {
+          "name": "Delete user",
+          "request": {"method": "DELETE", "url": "{{base-url}}/users/1", "auth": {"type": "digest"}}
+        }
+      ]
+    }
+  ]
+}`
+
+func TestPostman(t *testing.T) {
+	files, err := Postman(strings.NewReader(testCollection), Options{})
+	assert.Nil(t, err, err)
+	assert.Equal(t, 1, len(files))
+	assert.Equal(t, "test-api", files[0].Name)
+
+	const expected = `// Imported from Postman collection "Test API"
+
+### Tests
+
+// Login
+POST '{{index . "base-url"}}/login'
+
+[Header]
+X-Request-Id: {{timestamp}}
+Content-Type: application/json
+
+[Body]
+{"user": "{{.user}}"}
+
+[Script]
+token = response.Body.token;
+
+---
+
+##### Users
+
+// Get user
+GET '{{index . "base-url"}}/users/{{.userId}}?full=true'
+
+[Auth]
+type = "bearer"
+token = "{{.token}}"
+
+---
+
+##### Users / Admin
+
+// Upload
+POST '{{index . "base-url"}}/upload'
+
+[Auth]
+username = "admin"
+password = "{{.password}}"
+
+[FormData]
+file = @avatar.png
+name = "avatar"
+
+---
+
+##### Users
+
+// Delete user
+// WARNING: auth type "digest" could not be translated
+DELETE '{{index . "base-url"}}/users/1'
+`
+
+	res := printGoatfile(t, files[0])
+	assert.Equal(t, expected, res)
+}
+
+func TestPostman_Split(t *testing.T) {
+	files, err := Postman(strings.NewReader(testCollection), Options{Split: true})
+	assert.Nil(t, err, err)
+
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.Name)
+		printGoatfile(t, f)
+	}
+	assert.Equal(t, []string{"test-api", "users"}, names)
+}
+
+func TestPostman_Invalid(t *testing.T) {
+	_, err := Postman(strings.NewReader(`{"foo": "bar"}`), Options{})
+	assert.NotNil(t, err)
+
+	_, err = Postman(strings.NewReader(
+		`{"info": {"schema": "https://schema.getpostman.com/json/collection/v1.0.0/collection.json"}}`), Options{})
+	assert.NotNil(t, err)
+}
+
+func TestTranslatePostmanScript(t *testing.T) {
+	t.Run("translated", func(t *testing.T) {
+		const script = "pm.test(\"status is ok\", function () {\n" +
+			"    pm.response.to.have.status(200);\n" +
+			"    const body = pm.response.json();\n" +
+			"    pm.expect(body.id).to.eql(pm.environment.get('userId'));\n" +
+			"    pm.expect(body.active).to.be.true;\n" +
+			"    pm.expect(pm.response.headers.get('content-type')).to.include('json');\n" +
+			"});\n" +
+			"pm.collectionVariables.set('etag', pm.response.headers.get(\"etag\"));\n" +
+			"console.log(pm.response.code);"
+
+		const expected = "// status is ok\n" +
+			"(function () {\n" +
+			"    assert_eq(response.StatusCode, 200);\n" +
+			"    const body = response.Body;\n" +
+			"    assert_eq(body.id, userId);\n" +
+			"    assert_eq(body.active, true);\n" +
+			"    // WARNING: the following Postman API usage could not be translated\n" +
+			"    pm.expect(response.Header[\"Content-Type\"][0]).to.include('json');\n" +
+			"})();\n" +
+			"etag = response.Header[\"Etag\"][0];\n" +
+			"println(response.StatusCode);"
+
+		res, n := translatePostmanScript(script)
+		assert.Equal(t, expected, res)
+		assert.Equal(t, 1, n)
+	})
+
+	t.Run("unclosed-test", func(t *testing.T) {
+		const script = "pm.test('foo', () => {\n" +
+			"  assert(true); });"
+
+		res, n := translatePostmanScript(script)
+		assert.Equal(t, "// WARNING: the following Postman API usage could not be translated\n"+script, res)
+		assert.Equal(t, 1, n)
+	})
+}
+
+func TestTranslatePostmanTemplates(t *testing.T) {
+	assert.Equal(t, `{{.foo}}/{{index . "foo-bar"}}`, translatePostmanTemplates("{{foo}}/{{ foo-bar }}"))
+	assert.Equal(t, `{{randomInt 1001}} {{index . "$guid"}}`, translatePostmanTemplates("{{$randomInt}} {{$guid}}"))
+	assert.Equal(t, "no templates", translatePostmanTemplates("no templates"))
+}
+
+func TestPostmanURLString(t *testing.T) {
+	assert.Equal(t, "https://example.com/users/{{.id}}/posts/42?full=true",
+		postmanURLString(postmanURL{
+			Raw: "https://example.com/users/:id/posts/:postId?full=true",
+			Variable: []postmanKV{
+				{Key: "id"},
+				{Key: "postId", Value: "42"},
+			},
+		}))
+
+	assert.Equal(t, `{{index . "base-url"}}/items/{{index . "item-id"}}`,
+		postmanURLString(postmanURL{
+			Raw:      "{{base-url}}/items/:item-id",
+			Variable: []postmanKV{{Key: "item-id"}},
+		}))
+}
+
+// printGoatfile prints the given file and asserts
+// that the result is a valid, formatted Goatfile.
+func printGoatfile(t *testing.T, f File) string {
+	t.Helper()
+
+	var sb strings.Builder
+	err := formatter.Print(&sb, f.Goatfile)
+	assert.Nil(t, err, err)
+
+	_, err = goatfile.Unmarshal(sb.String(), "")
+	assert.Nil(t, err, err)
+
+	formatted, err := formatter.Format(sb.String())
+	assert.Nil(t, err, err)
+	assert.Equal(t, formatted, sb.String(), "import output is not formatted")
+
+	return sb.String()
+}
@@ -1,6 +1,7 @@
 package goatfile
 
 import (
+	"encoding/json"
 	"fmt"
 )
 
@@ -21,3 +22,19 @@ func (t ParameterValue) ApplyTemplate(params any) (any, error) {
 	v, _, err := NewParser(b, "").parseValue()
 	return v, err
 }
+
+// Evaluate evaluates the template expression of the value
+// with the passed params and returns the resulting value
+// as JSON-compatible value instead of parsing its printed
+// representation. This allows to obtain lists and objects
+// from the params.
+func (t ParameterValue) Evaluate(params any) (any, error) {
+	b, err := ApplyTemplateBuf(fmt.Sprintf("{{json (%s)}}", t), params)
+	if err != nil {
+		return nil, err
+	}
+
+	var v any
+	err = json.Unmarshal(b.Bytes(), &v)
+	return v, err
+}
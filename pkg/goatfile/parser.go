@@ -17,6 +17,7 @@ type Parser struct {
 	fileDir string
 	s       *scanner
 	prevPos readerPos
+	rawPos  ast.Pos // start of the last parsed raw content
 	buf     struct {
 		tok token  // last read token
 		lit string // last read literal
@@ -34,11 +35,8 @@ func NewParser(r io.Reader, fileDir string) *Parser {
 }
 
 // Parse parses a Goatfile from the specified source.
-func (t *Parser) Parse() (*ast.Goatfile, error) {
-	var (
-		err error
-		gf  ast.Goatfile
-	)
+func (t *Parser) Parse() (_ *ast.Goatfile, err error) {
+	var gf ast.Goatfile
 
 	defer func() {
 		err = t.wrapErr(err)
@@ -88,6 +86,7 @@ func (t *Parser) Parse() (*ast.Goatfile, error) {
 			if err != nil {
 				return nil, err
 			}
+			exec.Pos = pos
 			gf.Actions = append(gf.Actions, exec)
 			gf.Comments = append(gf.Comments, comms...)
 
@@ -316,6 +315,7 @@ func (t *Parser) parseSection() (sect ast.Section, comments []ast.Comment, delim
 			if err != nil {
 				return nil, nil, nil, err
 			}
+			exec.Pos = pos
 			actions = append(actions, exec)
 			comments = append(comments, comms...)
 			continue
@@ -390,11 +390,14 @@ loop:
 			comments = append(comments, ast.Comment{Pos: pos, Content: lit})
 
 		case tokBLOCKSTART:
+			t.rawPos = ast.Pos{}
 			block, comms, err := t.parseBlock()
 			if err != nil {
 				return nil, nil, err
 			}
 			req.Blocks = append(req.Blocks, block)
+			req.BlockPos = append(req.BlockPos, pos)
+			req.ContentPos = append(req.ContentPos, t.rawPos)
 			comments = append(comments, comms...)
 
 		case tokWS, tokLF:
@@ -430,11 +433,14 @@ loop:
 			comments = append(comments, ast.Comment{Pos: pos, Content: lit})
 
 		case tokBLOCKSTART:
+			t.rawPos = ast.Pos{}
 			block, comms, err := t.parseBlock()
 			if err != nil {
 				return nil, nil, err
 			}
 			req.Blocks = append(req.Blocks, block)
+			req.BlockPos = append(req.BlockPos, pos)
+			req.ContentPos = append(req.ContentPos, t.rawPos)
 			comments = append(comments, comms...)
 
 		case tokWS, tokLF:
@@ -657,6 +663,7 @@ func (t *Parser) parseRaw() (ast.DataContent, error) {
 	}
 
 	t.s.unread()
+	t.rawPos = t.astPos()
 
 	for {
 		if !inEscape {
@@ -719,6 +726,9 @@ func (t *Parser) parseRaw() (ast.DataContent, error) {
 			} else {
 				out.Truncate(out.Len() - 4)
 			}
+			if out.Len() == 0 {
+				t.rawPos = t.astPos()
+			}
 			continue
 		}
 
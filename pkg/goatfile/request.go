@@ -29,6 +29,15 @@ type Request struct {
 	PreScript Data
 	Script    Data
 
+	// PreScriptOrigin and ScriptOrigin contain
+	// the locations the scripts are defined at.
+	PreScriptOrigin engine.Origin
+	ScriptOrigin    engine.Origin
+
+	// UntilOrigin contains the location of
+	// the until option, if specified.
+	UntilOrigin engine.Origin
+
 	Path    string
 	PosLine int
 
@@ -61,12 +70,13 @@ func RequestFromAst(req *ast.Request, path string) (t *Request, err error) {
 
 	var additionalHeader http.Header
 
-	for _, block := range req.Blocks {
+	for i, block := range req.Blocks {
 		switch b := block.(type) {
 		case ast.RequestHeader:
 			t.Header = b.HeaderEntries.ToMultiMap()
 		case ast.RequestOptions:
 			t.Options = b.KVList.ToMap()
+			t.UntilOrigin = optionOrigin(b.KVList, "until", path)
 		case ast.RequestQueryParams:
 			t.QueryParams = b.KVList.ToMap()
 		case ast.RequestAuth:
@@ -75,8 +85,10 @@ func RequestFromAst(req *ast.Request, path string) (t *Request, err error) {
 			t.Body, additionalHeader, err = DataFromAst(b.DataContent, path)
 		case ast.RequestPreScript:
 			t.PreScript, _, err = DataFromAst(b.DataContent, path)
+			t.PreScriptOrigin = scriptOrigin(t.PreScript, req.ContentPos, i, path)
 		case ast.RequestScript:
 			t.Script, _, err = DataFromAst(b.DataContent, path)
+			t.ScriptOrigin = scriptOrigin(t.Script, req.ContentPos, i, path)
 		case ast.FormData:
 			t.Body, additionalHeader, err = DataFromAst(b, path)
 		case ast.FormUrlEncoded:
@@ -102,6 +114,8 @@ func PartialRequestFromAst(req ast.PartialRequest, path string) (t *Request, err
 
 	fullReq.Pos = req.Pos
 	fullReq.Blocks = req.Blocks
+	fullReq.BlockPos = req.BlockPos
+	fullReq.ContentPos = req.ContentPos
 
 	return RequestFromAst(&fullReq, path)
 }
@@ -279,9 +293,9 @@ func (t *Request) InsertRawDataIntoFormData(state engine.State) error {
 	return nil
 }
 
-// ToHttpRequest returns a *http.Request built from the
-// given Reuqest.
-func (t *Request) ToHttpRequest() (*http.Request, error) {
+// URL returns the URI of the Request with the
+// query parameters applied.
+func (t *Request) URL() (*url.URL, error) {
 	uri, err := url.Parse(t.URI)
 	if err != nil {
 		return nil, errs.WithPrefix("failed parsing URI:", err)
@@ -301,6 +315,17 @@ func (t *Request) ToHttpRequest() (*http.Request, error) {
 
 	uri.RawQuery = query.Encode()
 
+	return uri, nil
+}
+
+// ToHttpRequest returns a *http.Request built from the
+// given Reuqest.
+func (t *Request) ToHttpRequest() (*http.Request, error) {
+	uri, err := t.URL()
+	if err != nil {
+		return nil, err
+	}
+
 	var body io.Reader
 
 	bodyReader, err := t.Body.Reader()
@@ -342,6 +367,10 @@ func (t *Request) Merge(with *Request) {
 	}
 
 	if len(with.Options) > 0 {
+		_, hasUntil := t.Options["until"]
+		if _, ok := with.Options["until"]; ok && !hasUntil {
+			t.UntilOrigin = with.UntilOrigin
+		}
 		t.Options = mergeMaps(t.Options, with.Options)
 	}
 
@@ -355,21 +384,74 @@ func (t *Request) Merge(with *Request) {
 
 	if IsNoContent(t.PreScript) && !IsNoContent(with.PreScript) {
 		t.PreScript = with.PreScript
+		t.PreScriptOrigin = with.PreScriptOrigin
 	}
 
 	if IsNoContent(t.Script) && !IsNoContent(with.Script) {
 		t.Script = with.Script
+		t.ScriptOrigin = with.ScriptOrigin
 	}
 }
 
+// Copy returns a deep copy of the request, so that
+// the copy can be substituted with parameters
+// independently of the original request.
+func (t *Request) Copy() *Request {
+	c := *t
+
+	c.Header = t.Header.Clone()
+	c.QueryParams = copyMap(t.QueryParams)
+	c.Options = copyMap(t.Options)
+	c.Auth = copyMap(t.Auth)
+
+	switch body := t.Body.(type) {
+	case FormData:
+		body.fields = copyMap(body.fields)
+		c.Body = body
+	case FormUrlEncoded:
+		body.fields = copyMap(body.fields)
+		c.Body = body
+	}
+
+	return &c
+}
+
 func (t *Request) String() string {
 	return fmt.Sprintf("%s %s", t.Method, t.URI)
 }
 
+// scriptOrigin returns the origin of the given script
+// data defined in the block with the given index of a
+// request in the Goatfile at path.
+func scriptOrigin(data Data, contentPos []ast.Pos, i int, path string) engine.Origin {
+	switch d := data.(type) {
+	case StringContent:
+		if i < len(contentPos) {
+			return engine.Origin{File: path, Line: contentPos[i].Line + 1}
+		}
+	case FileContent:
+		if pth, err := d.FilePath(); err == nil {
+			return engine.Origin{File: pth, Line: 1}
+		}
+	}
+	return engine.Origin{}
+}
+
 func toString(v any) string {
 	return fmt.Sprintf("%v", v)
 }
 
+// optionOrigin returns the origin of the value of the
+// option with the given key in the Goatfile at path.
+func optionOrigin(options ast.KVList[any], key, path string) engine.Origin {
+	for _, kv := range options {
+		if kv.Key == key {
+			return engine.Origin{File: path, Line: kv.Pos.Line + 1}
+		}
+	}
+	return engine.Origin{}
+}
+
 func mergeMaps[TK comparable, TV any](src, base map[TK]TV) map[TK]TV {
 	new := map[TK]TV{}
 	for key, val := range base {
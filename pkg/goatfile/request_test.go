@@ -336,3 +336,26 @@ func TestMerge_request(t *testing.T) {
 		assert.Equal(t, NoContent{}, req.Script)
 	})
 }
+
+func TestCopy_request(t *testing.T) {
+	req := newRequest()
+	req.URI = "http://localhost/{{.id}}"
+	req.Header.Add("foo", "{{.id}}")
+	req.Options = map[string]any{
+		"list": []any{"{{.id}}"},
+		"map":  map[string]any{"foo": "{{.id}}"},
+	}
+
+	c := req.Copy()
+	err := c.SubstituteWithParams(map[string]any{"id": 1})
+	assert.Nil(t, err, err)
+
+	assert.Equal(t, "http://localhost/1", c.URI)
+	assert.Equal(t, "1", c.Header.Get("foo"))
+	assert.Equal(t, []any{"1"}, c.Options["list"])
+
+	assert.Equal(t, "http://localhost/{{.id}}", req.URI)
+	assert.Equal(t, "{{.id}}", req.Header.Get("foo"))
+	assert.Equal(t, []any{"{{.id}}"}, req.Options["list"])
+	assert.Equal(t, map[string]any{"foo": "{{.id}}"}, req.Options["map"])
+}
@@ -11,23 +11,22 @@ import (
 	"hash"
 	"io"
 	"math/rand"
+	"slices"
 	"strconv"
 	"strings"
 	"text/template"
 	"time"
 )
 
-var rng = rand.New(rand.NewSource(time.Now().UnixNano()))
-
 var builtinFuncsMap = template.FuncMap{
 	"base64":            builtin_base64,
 	"base64Url":         builtin_base64Url,
 	"base64Unpadded":    builtin_base64Unpadded,
 	"base64UrlUnpadded": builtin_base64UrlUnpadded,
-	"md5":               builtin_hasher(md5.New()),
-	"sha1":              builtin_hasher(sha1.New()),
-	"sha256":            builtin_hasher(sha256.New()),
-	"sha512":            builtin_hasher(sha512.New()),
+	"md5":               builtin_hasher(md5.New),
+	"sha1":              builtin_hasher(sha1.New),
+	"sha256":            builtin_hasher(sha256.New),
+	"sha512":            builtin_hasher(sha512.New),
 	"randomString":      builtin_randomString,
 	"randomInt":         builtin_randomInt,
 	"timestamp":         builtin_timestamp,
@@ -36,6 +35,18 @@ var builtinFuncsMap = template.FuncMap{
 	"formatTimestamp":   builtin_formatTimestamp,
 }
 
+// BuiltinFuncNames returns the names of all
+// functions available in templates in
+// alphabetical order.
+func BuiltinFuncNames() []string {
+	names := make([]string, 0, len(builtinFuncsMap))
+	for name := range builtinFuncsMap {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
 var dateFormats = map[string]string{
 	"ANSIC":       time.ANSIC,
 	"UNIXDATE":    time.UnixDate,
@@ -80,10 +91,10 @@ func builtin_base64UrlUnpadded(v string) string {
 	return base64.RawURLEncoding.EncodeToString([]byte(v))
 }
 
-func builtin_hasher(hsh hash.Hash) func(string) string {
+func builtin_hasher(newHash func() hash.Hash) func(string) string {
 	return func(s string) string {
+		hsh := newHash()
 		io.WriteString(hsh, s)
-		defer hsh.Reset()
 		return fmt.Sprintf("%x", hsh.Sum(nil))
 	}
 }
@@ -99,7 +110,7 @@ func builtin_randomString(lnOpt ...int) string {
 
 	buf := make([]byte, ln)
 	for i := 0; i < ln; i++ {
-		buf[i] = charSet[rng.Intn(len(charSet))]
+		buf[i] = charSet[rand.Intn(len(charSet))]
 	}
 
 	return string(buf)
@@ -107,10 +118,10 @@ func builtin_randomString(lnOpt ...int) string {
 
 func builtin_randomInt(nOpt ...int) int {
 	if len(nOpt) != 0 {
-		return rng.Intn(nOpt[0])
+		return rand.Intn(nOpt[0])
 	}
 
-	return rng.Int()
+	return rand.Int()
 }
 
 func builtin_timestamp(formatOpt ...string) string {
@@ -17,10 +17,7 @@ import (
 // If a key in the template is not present in the params,
 // an error will be returned.
 func ApplyTemplateBuf(raw string, params any) (*bytes.Buffer, error) {
-	tmpl, err := template.New("").
-		Funcs(builtinFuncsMap).
-		Option("missingkey=error").
-		Parse(raw)
+	tmpl, err := parseTemplate(raw)
 	if err != nil {
 		return nil, errs.WithPrefix("parsing template failed:", err)
 	}
@@ -34,6 +31,21 @@ func ApplyTemplateBuf(raw string, params any) (*bytes.Buffer, error) {
 	return &out, err
 }
 
+// ValidateTemplate parses the given raw string as a template
+// without applying any parameters and returns an error when
+// the template is invalid.
+func ValidateTemplate(raw string) error {
+	_, err := parseTemplate(raw)
+	return err
+}
+
+func parseTemplate(raw string) (*template.Template, error) {
+	return template.New("").
+		Funcs(builtinFuncsMap).
+		Option("missingkey=error").
+		Parse(raw)
+}
+
 // ApplyTemplate parses the given raw string as a template
 // and applies the given values in params onto it returning
 // the result as string.
@@ -101,6 +113,34 @@ func ApplyTemplateToMap(m map[string]any, params any) (err error) {
 	return nil
 }
 
+// copyMap returns a deep copy of the given map
+// including nested maps and arrays.
+func copyMap(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]any, len(m))
+	for k, v := range m {
+		c[k] = copyValue(v)
+	}
+	return c
+}
+
+func copyValue(v any) any {
+	switch vt := v.(type) {
+	case map[string]any:
+		return copyMap(vt)
+	case []any:
+		c := make([]any, len(vt))
+		for i, e := range vt {
+			c[i] = copyValue(e)
+		}
+		return c
+	default:
+		return v
+	}
+}
+
 // Extend takes a file path and adds the given extension
 // to it if the path does not end with any file extension.
 func Extend(v string, ext string) string {
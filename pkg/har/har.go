@@ -0,0 +1,197 @@
+// Package har implements the HTTP Archive (HAR)
+// format in version 1.2.
+//
+// See http://www.softwareishard.com/blog/har-12-spec/
+// for the specification.
+package har
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Version is the version of the HAR format.
+const Version = "1.2"
+
+// HAR is the root object of an HTTP Archive.
+type HAR struct {
+	Log Log `json:"log"`
+}
+
+// New returns a new empty HAR created
+// by the given creator.
+func New(creator Creator) *HAR {
+	return &HAR{Log: Log{
+		Version: Version,
+		Creator: creator,
+		Entries: []Entry{},
+	}}
+}
+
+// Add appends the given entries to the log.
+func (t *HAR) Add(entries ...Entry) {
+	t.Log.Entries = append(t.Log.Entries, entries...)
+}
+
+type Log struct {
+	Version string  `json:"version"`
+	Creator Creator `json:"creator"`
+	Entries []Entry `json:"entries"`
+}
+
+type Creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type Entry struct {
+	StartedDateTime time.Time `json:"startedDateTime"`
+	// Time is the total time of the
+	// request in milliseconds.
+	Time     float64  `json:"time"`
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+	Cache    Cache    `json:"cache"`
+	Timings  Timings  `json:"timings"`
+	Comment  string   `json:"comment,omitempty"`
+}
+
+type Request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	QueryString []NameValue `json:"queryString"`
+	PostData    *PostData   `json:"postData,omitempty"`
+	HeadersSize int64       `json:"headersSize"`
+	BodySize    int64       `json:"bodySize"`
+}
+
+type Response struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Cookies     []Cookie    `json:"cookies"`
+	Headers     []NameValue `json:"headers"`
+	Content     Content     `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int64       `json:"headersSize"`
+	BodySize    int64       `json:"bodySize"`
+}
+
+type Cookie struct {
+	Name     string     `json:"name"`
+	Value    string     `json:"value"`
+	Path     string     `json:"path,omitempty"`
+	Domain   string     `json:"domain,omitempty"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	HTTPOnly bool       `json:"httpOnly,omitempty"`
+	Secure   bool       `json:"secure,omitempty"`
+}
+
+type NameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type PostData struct {
+	MimeType string  `json:"mimeType"`
+	Params   []Param `json:"params,omitempty"`
+	Text     string  `json:"text"`
+	Comment  string  `json:"comment,omitempty"`
+}
+
+type Param struct {
+	Name        string `json:"name"`
+	Value       string `json:"value,omitempty"`
+	FileName    string `json:"fileName,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+}
+
+type Content struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+type Cache struct{}
+
+// Timings contains the durations of the phases
+// of a request in milliseconds. Blocked, DNS,
+// Connect and SSL are -1 when they do not apply
+// to the request.
+type Timings struct {
+	Blocked float64 `json:"blocked,omitempty"`
+	DNS     float64 `json:"dns,omitempty"`
+	Connect float64 `json:"connect,omitempty"`
+	SSL     float64 `json:"ssl,omitempty"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// Cookies returns the given cookies as
+// list of HAR cookies.
+func Cookies(cookies []*http.Cookie) []Cookie {
+	res := make([]Cookie, 0, len(cookies))
+	for _, c := range cookies {
+		hc := Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			HTTPOnly: c.HttpOnly,
+			Secure:   c.Secure,
+		}
+		if !c.Expires.IsZero() {
+			expires := c.Expires
+			hc.Expires = &expires
+		}
+		res = append(res, hc)
+	}
+	return res
+}
+
+// Headers returns the given header as list
+// of name-value pairs ordered by name.
+func Headers(header http.Header) []NameValue {
+	nvs := []NameValue{}
+	for name, values := range header {
+		for _, v := range values {
+			nvs = append(nvs, NameValue{Name: name, Value: v})
+		}
+	}
+	slices.SortStableFunc(nvs, func(a, b NameValue) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return nvs
+}
+
+// QueryString returns the query parameters of the
+// given URL as list of name-value pairs ordered
+// by name.
+func QueryString(u *url.URL) []NameValue {
+	query := u.Query()
+	nvs := []NameValue{}
+	for name, values := range query {
+		for _, v := range values {
+			nvs = append(nvs, NameValue{Name: name, Value: v})
+		}
+	}
+	slices.SortStableFunc(nvs, func(a, b NameValue) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return nvs
+}
+
+// Milliseconds returns the given duration in
+// fractional milliseconds.
+func Milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,281 @@
+// Package lint implements static checks of Goatfiles
+// and all Goatfiles imported or executed by them.
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/studio-b12/goat/pkg/errs"
+	"github.com/studio-b12/goat/pkg/executor"
+	"github.com/studio-b12/goat/pkg/goatfile"
+	"github.com/studio-b12/goat/pkg/goatfile/ast"
+	"github.com/studio-b12/goat/pkg/set"
+)
+
+// Diagnostic describes a problem found in a Goatfile.
+// Line and Column start counting at 1.
+type Diagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+func (t Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", t.File, t.Line, t.Column, t.Message)
+}
+
+// Lint checks the Goatfiles at the given locations and
+// all Goatfiles imported or executed by them and returns
+// the found problems ordered by file and position.
+//
+// Directories are searched recursively for Goatfiles
+// using the same rules as on execution. Files which
+// are only reachable via imports or execute statements
+// are checked as well.
+func Lint(fSys fs.FS, locations []string) ([]Diagnostic, error) {
+	l := linter{
+		fs:     fSys,
+		files:  make(map[string]*file),
+		linted: make(set.Set[string]),
+	}
+
+	for _, location := range locations {
+		err := fs.WalkDir(fSys, location, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && p != location && strings.HasPrefix(d.Name(), "_") {
+				return fs.SkipDir
+			}
+			if d.IsDir() ||
+				p != location && (path.Ext(p) != "."+goatfile.FileExtension || strings.HasPrefix(d.Name(), "_")) {
+				return nil
+			}
+			l.lintFile(p)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return l.result(), nil
+}
+
+type file struct {
+	ast *ast.Goatfile
+	err error
+}
+
+type linter struct {
+	fs     fs.FS
+	files  map[string]*file
+	linted set.Set[string]
+	diags  []Diagnostic
+}
+
+// load reads and parses the Goatfile at the given
+// path. The results are cached by path.
+func (t *linter) load(pth string) *file {
+	if f, ok := t.files[pth]; ok {
+		return f
+	}
+
+	f := new(file)
+	t.files[pth] = f
+
+	raw, err := fs.ReadFile(t.fs, pth)
+	if err != nil {
+		f.err = err
+		return f
+	}
+
+	raw = []byte(strings.ReplaceAll(string(raw), "\r\n", "\n"))
+	f.ast, f.err = goatfile.NewParser(strings.NewReader(string(raw)), pth).Parse()
+
+	return f
+}
+
+func (t *linter) report(pth string, pos ast.Pos, format string, args ...any) {
+	t.diags = append(t.diags, Diagnostic{
+		File:    pth,
+		Line:    pos.Line + 1,
+		Column:  pos.LinePos + 1,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (t *linter) result() []Diagnostic {
+	slices.SortFunc(t.diags, func(a, b Diagnostic) int {
+		if c := strings.Compare(a.File, b.File); c != 0 {
+			return c
+		}
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column - b.Column
+		}
+		return strings.Compare(a.Message, b.Message)
+	})
+	return slices.Compact(t.diags)
+}
+
+func (t *linter) lintFile(pth string) {
+	if !t.linted.Add(pth) {
+		return
+	}
+
+	f := t.load(pth)
+	if f.err != nil {
+		if pErr, ok := errs.As[goatfile.ParseError](f.err); ok {
+			t.report(pth, ast.Pos{Line: pErr.Line, LinePos: pErr.LinePos}, "%s", pErr.Inner.Error())
+		} else {
+			t.report(pth, ast.Pos{}, "%s", f.err.Error())
+		}
+		return
+	}
+
+	t.lintImports(pth, f.ast, set.Set[string]{pth: {}})
+
+	for _, act := range f.ast.Actions {
+		t.lintAction(pth, act)
+	}
+
+	for _, sect := range f.ast.Sections {
+		switch s := sect.(type) {
+		case ast.SectionDefaults:
+			t.lintBlocks(pth, s.Request.Blocks)
+		case ast.SectionSetup:
+			t.lintActions(pth, s.Actions)
+		case ast.SectionTests:
+			t.lintActions(pth, s.Actions)
+		case ast.SectionTeardown:
+			t.lintActions(pth, s.Actions)
+		}
+	}
+}
+
+// lintImports checks the imports of the given Goatfile
+// and recursively the imports of the imported Goatfiles.
+// Because every Goatfile can only be imported once in an
+// import tree, already imported files are reported.
+func (t *linter) lintImports(pth string, gf *ast.Goatfile, imported set.Set[string]) {
+	for _, imp := range gf.Imports {
+		impPath := resolve(pth, imp.Path)
+
+		if !imported.Add(impPath) {
+			t.report(pth, imp.Pos, "duplicate import: %s has already been imported", impPath)
+			continue
+		}
+
+		f := t.load(impPath)
+		if f.err != nil {
+			if errors.Is(f.err, fs.ErrNotExist) {
+				t.report(pth, imp.Pos, "imported file %s does not exist", impPath)
+				continue
+			}
+			if _, ok := errs.As[goatfile.ParseError](f.err); !ok {
+				t.report(pth, imp.Pos, "failed following import %s: %s", impPath, f.err.Error())
+				continue
+			}
+		}
+
+		t.lintFile(impPath)
+
+		if f.ast != nil {
+			t.lintImports(impPath, f.ast, imported)
+		}
+	}
+}
+
+func (t *linter) lintActions(pth string, actions []ast.Action) {
+	for _, act := range actions {
+		t.lintAction(pth, act)
+	}
+}
+
+func (t *linter) lintAction(pth string, act ast.Action) {
+	switch a := act.(type) {
+	case *ast.Request:
+		if err := goatfile.ValidateTemplate(a.Head.Url); err != nil {
+			t.report(pth, a.Pos, "invalid template in request URI: %s", err.Error())
+		}
+		t.lintBlocks(pth, a.Blocks)
+	case *ast.Execute:
+		t.lintExecute(pth, a)
+	}
+}
+
+func (t *linter) lintBlocks(pth string, blocks []ast.RequestBlock) {
+	for _, block := range blocks {
+		switch b := block.(type) {
+		case ast.RequestHeader:
+			for _, kv := range b.KVList {
+				if err := goatfile.ValidateTemplate(kv.Value); err != nil {
+					t.report(pth, kv.Pos, "invalid template in header %s: %s", kv.Key, err.Error())
+				}
+			}
+		case ast.RequestOptions:
+			for _, kv := range b.KVList {
+				if !slices.Contains(executor.OptionKeys, kv.Key) {
+					t.report(pth, kv.Pos, "unknown option: %s", kv.Key)
+				}
+			}
+		case ast.RequestAuth:
+			for _, kv := range b.KVList {
+				if !slices.Contains(executor.AuthOptionKeys, kv.Key) {
+					t.report(pth, kv.Pos, "unknown auth key: %s", kv.Key)
+				}
+			}
+		}
+	}
+}
+
+func (t *linter) lintExecute(pth string, exec *ast.Execute) {
+	target := resolve(pth, exec.Path)
+
+	f := t.load(target)
+	if f.err != nil {
+		if errors.Is(f.err, fs.ErrNotExist) {
+			t.report(pth, exec.Pos, "execute target %s does not exist", target)
+			return
+		}
+		if _, ok := errs.As[goatfile.ParseError](f.err); !ok {
+			t.report(pth, exec.Pos, "failed reading execute target %s: %s", target, f.err.Error())
+			return
+		}
+	}
+
+	t.lintFile(target)
+
+	if f.ast == nil || len(exec.Returns.KVList) == 0 {
+		return
+	}
+
+	vars, ok := t.setVariables(target, f.ast, make(set.Set[string]))
+	if !ok {
+		return
+	}
+	for _, kv := range exec.Parameters {
+		vars.Add(kv.Key)
+	}
+
+	for _, kv := range exec.Returns.KVList {
+		if !vars.Contains(kv.Key) {
+			t.report(pth, kv.Pos, "returned variable %s is never set in %s", kv.Key, target)
+		}
+	}
+}
+
+// resolve returns the path of the Goatfile referenced
+// by ref relative to the Goatfile at pth.
+func resolve(pth, ref string) string {
+	return goatfile.Extend(path.Join(path.Dir(pth), ref), goatfile.FileExtension)
+}
@@ -0,0 +1,105 @@
+package lint
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLint(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		fSys := fstest.MapFS{
+			"a.goat": {Data: []byte("use _lib/b\n\n" +
+				"GET {{.instance}}/foo\n\n" +
+				"[Options]\ncookiejar = \"a\"\n\n" +
+				"[Auth]\nusername = \"a\"\npassword = \"b\"\n\n" +
+				"[Header]\nX-Foo: {{ .bar }}\n\n" +
+				"---\n\n" +
+				"execute _lib/c (a = 1) return (a as a b as b response as r)\n")},
+			"_lib/b.goat": {Data: []byte("GET https://example.com\n")},
+			"_lib/c.goat": {Data: []byte("GET https://example.com\n\n[Script]\nvar b = {{.a}};\n")},
+		}
+
+		diags, err := Lint(fSys, []string{"."})
+		assert.Nil(t, err, err)
+		assert.Empty(t, diags)
+	})
+
+	t.Run("parse-error", func(t *testing.T) {
+		fSys := fstest.MapFS{
+			"a.goat": {Data: []byte("GET https://example.com\n\n[Foo]\n")},
+		}
+
+		diags, err := Lint(fSys, []string{"a.goat"})
+		assert.Nil(t, err, err)
+		assert.Equal(t, []Diagnostic{
+			{File: "a.goat", Line: 3, Column: 6, Message: "invalid block header ('Foo')"},
+		}, diags)
+	})
+
+	t.Run("problems", func(t *testing.T) {
+		fSys := fstest.MapFS{
+			"a.goat": {Data: []byte("use b\nuse b\n\n" +
+				"GET {{.instance}/foo\n\n" +
+				"[Options]\ncookijar = \"a\"\n\n" +
+				"[Auth]\nuser = \"a\"\n\n" +
+				"[Header]\nX-Foo: {{ .bar\n\n" +
+				"---\n\n" +
+				"execute c () return (b as b d as d)\n\n" +
+				"---\n\n" +
+				"execute missing\n")},
+			"b.goat": {Data: []byte("GET https://example.com\n")},
+			"c.goat": {Data: []byte("GET https://example.com\n\n[Script]\nvar b = 1;\nlet d = 2;\n")},
+		}
+
+		diags, err := Lint(fSys, []string{"a.goat"})
+		assert.Nil(t, err, err)
+
+		messages := make([]string, 0, len(diags))
+		for _, d := range diags {
+			assert.Equal(t, "a.goat", d.File)
+			messages = append(messages, d.Message)
+		}
+
+		assert.Equal(t, []string{
+			"duplicate import: b.goat has already been imported",
+			"invalid template in request URI: template: :1: bad character U+007D '}'",
+			"unknown option: cookijar",
+			"unknown auth key: user",
+			"invalid template in header X-Foo: template: :1: unclosed action",
+			"returned variable d is never set in c.goat",
+			"execute target missing.goat does not exist",
+		}, messages)
+	})
+
+	t.Run("imported-files", func(t *testing.T) {
+		fSys := fstest.MapFS{
+			"a.goat":       {Data: []byte("use _lib/b\nuse _lib/missing\n")},
+			"_lib/b.goat":  {Data: []byte("GET https://example.com\n\n[Options]\nfoo = 1\n")},
+			"_lib/_c.goat": {Data: []byte("GET https://example.com\n\n[Foo]\n")},
+		}
+
+		diags, err := Lint(fSys, []string{"."})
+		assert.Nil(t, err, err)
+		assert.Equal(t, []Diagnostic{
+			{File: "_lib/b.goat", Line: 4, Column: 1, Message: "unknown option: foo"},
+			{File: "a.goat", Line: 2, Column: 4, Message: "imported file _lib/missing.goat does not exist"},
+		}, diags)
+	})
+	t.Run("script-imports", func(t *testing.T) {
+		fSys := fstest.MapFS{
+			"a.goat": {Data: []byte("execute b () return (c as c d as d e as e f as f g as g)\n")},
+			"b.goat": {Data: []byte("GET https://example.com\n\n[Script]\n" +
+				"import c, { x as d } from \"./_lib/util.js\";\n" +
+				"import * as e from \"./_lib/data.json\";\n" +
+				"var f = d(c);\n")},
+		}
+
+		diags, err := Lint(fSys, []string{"a.goat"})
+		assert.Nil(t, err, err)
+		assert.Equal(t, []Diagnostic{
+			{File: "a.goat", Line: 1, Column: 49, Message: "returned variable g is never set in b.goat"},
+		}, diags)
+	})
+}
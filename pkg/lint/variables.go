@@ -0,0 +1,177 @@
+package lint
+
+import (
+	"reflect"
+	"regexp"
+
+	jsast "github.com/dop251/goja/ast"
+	jsparser "github.com/dop251/goja/parser"
+	"github.com/studio-b12/goat/pkg/engine"
+	"github.com/studio-b12/goat/pkg/goatfile/ast"
+	"github.com/studio-b12/goat/pkg/set"
+)
+
+// responseVariable is the name of the variable which
+// is set to the response after each request.
+const responseVariable = "response"
+
+var templateRx = regexp.MustCompile(`\{\{.*?\}\}`)
+
+var jsAstPkgPath = reflect.TypeOf(jsast.Program{}).PkgPath()
+
+// setVariables returns the names of all variables which
+// may be set in the state of the given Goatfile or its
+// imports after its execution.
+//
+// Because the variables are collected from the scripts,
+// false is returned if any script could not be parsed.
+func (t *linter) setVariables(pth string, gf *ast.Goatfile, visited set.Set[string]) (set.Set[string], bool) {
+	vars := make(set.Set[string])
+
+	if !visited.Add(pth) {
+		return vars, true
+	}
+
+	for _, imp := range gf.Imports {
+		impPath := resolve(pth, imp.Path)
+		f := t.load(impPath)
+		if f.ast == nil {
+			return nil, false
+		}
+		impVars, ok := t.setVariables(impPath, f.ast, visited)
+		if !ok {
+			return nil, false
+		}
+		for v := range impVars {
+			vars.Add(v)
+		}
+	}
+
+	actions := append([]ast.Action{}, gf.Actions...)
+	for _, sect := range gf.Sections {
+		switch s := sect.(type) {
+		case ast.SectionDefaults:
+			if !addScriptVariables(vars, s.Request.Blocks) {
+				return nil, false
+			}
+		case ast.SectionSetup:
+			actions = append(actions, s.Actions...)
+		case ast.SectionTests:
+			actions = append(actions, s.Actions...)
+		case ast.SectionTeardown:
+			actions = append(actions, s.Actions...)
+		}
+	}
+
+	for _, act := range actions {
+		switch a := act.(type) {
+		case *ast.Request:
+			vars.Add(responseVariable)
+			if !addScriptVariables(vars, a.Blocks) {
+				return nil, false
+			}
+		case *ast.Execute:
+			for _, kv := range a.Returns.KVList {
+				vars.Add(kv.Value)
+			}
+		}
+	}
+
+	return vars, true
+}
+
+func addScriptVariables(vars set.Set[string], blocks []ast.RequestBlock) bool {
+	for _, block := range blocks {
+		var content ast.DataContent
+		switch b := block.(type) {
+		case ast.RequestPreScript:
+			content = b.DataContent
+		case ast.RequestScript:
+			content = b.DataContent
+		default:
+			continue
+		}
+
+		text, ok := content.(ast.TextBlock)
+		if !ok {
+			continue
+		}
+
+		if !addGlobalVariables(vars, text.Content) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// addGlobalVariables adds the names of all variables declared
+// with var on top level and all identifiers values are
+// assigned to in the given script to vars. Variables declared
+// with let or const are not added because they are not
+// captured in the state after the script execution.
+//
+// Names bound by import declarations are added as well
+// because imports are translated to var declarations.
+// Templates in the script are replaced before parsing.
+func addGlobalVariables(vars set.Set[string], script string) bool {
+	script = templateRx.ReplaceAllString(script, "0")
+
+	imports, script := engine.ParseImports(script)
+	for _, imp := range imports {
+		for _, name := range imp.Names {
+			vars.Add(name)
+		}
+	}
+
+	prog, err := jsparser.ParseFile(nil, "", script, 0)
+	if err != nil {
+		return false
+	}
+
+	for _, decl := range prog.DeclarationList {
+		for _, b := range decl.List {
+			if id, ok := b.Target.(*jsast.Identifier); ok {
+				vars.Add(id.Name.String())
+			}
+		}
+	}
+
+	walkJsAst(reflect.ValueOf(prog), func(n any) {
+		if assign, ok := n.(*jsast.AssignExpression); ok {
+			if id, ok := assign.Left.(*jsast.Identifier); ok {
+				vars.Add(id.Name.String())
+			}
+		}
+	})
+
+	return true
+}
+
+// walkJsAst calls fn with every node of the given
+// JavaScript AST.
+func walkJsAst(v reflect.Value, fn func(n any)) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || v.Elem().Kind() != reflect.Struct || v.Elem().Type().PkgPath() != jsAstPkgPath {
+			return
+		}
+		fn(v.Interface())
+		walkJsAst(v.Elem(), fn)
+	case reflect.Interface:
+		if !v.IsNil() {
+			walkJsAst(v.Elem(), fn)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			walkJsAst(v.Index(i), fn)
+		}
+	case reflect.Struct:
+		if v.Type().PkgPath() != jsAstPkgPath {
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			walkJsAst(v.Field(i), fn)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package lsp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/studio-b12/goat/pkg/executor"
+	"github.com/studio-b12/goat/pkg/goatfile"
+)
+
+// sectionNames contains the names of all
+// sections in their canonical spelling.
+var sectionNames = []string{"Defaults", "Setup", "Tests", "Teardown"}
+
+var (
+	blockHeaderPattern = regexp.MustCompile(`^\s*\[\s*(\w+)\s*\]\s*(//.*)?$`)
+	requestHeadPattern = regexp.MustCompile(`^[A-Z]+[ \t]+[^=\s]`)
+	identPattern       = regexp.MustCompile(`\w*$`)
+)
+
+// completion returns the completion items at the
+// given position in the given document.
+func completion(text string, pos Position) []CompletionItem {
+	lines := strings.Split(text, "\n")
+	if pos.Line >= len(lines) {
+		return []CompletionItem{}
+	}
+
+	line := lines[pos.Line]
+	prefix := line[:byteOffset(line, pos.Character)]
+	trimmed := strings.TrimLeft(prefix, " \t")
+	indent := len(prefix) - len(trimmed)
+
+	// Template functions within {{ ... }}
+	if i := strings.LastIndex(prefix, "{{"); i != -1 && !strings.Contains(prefix[i:], "}}") {
+		word := identPattern.FindString(prefix)
+		start := len(prefix) - len(word)
+		if start > 0 && prefix[start-1] == '.' {
+			// Fields of the state can not be completed
+			// because they are only known at runtime.
+			return []CompletionItem{}
+		}
+		return items(goatfile.BuiltinFuncNames(), CompletionKindFunction, "template function",
+			rangeOnLine(pos.Line, line, start, len(prefix)), "%s")
+	}
+
+	// Section headers, but not log sections (#####)
+	if strings.HasPrefix(trimmed, "###") && !strings.HasPrefix(trimmed, "####") {
+		return items(sectionNames, CompletionKindKeyword, "section",
+			rangeOnLine(pos.Line, line, indent, len(prefix)), "### %s")
+	}
+
+	// Block headers
+	if strings.HasPrefix(trimmed, "[") && !strings.Contains(trimmed, "]") {
+		end := len(prefix)
+		if strings.HasPrefix(line[end:], "]") {
+			end++
+		}
+		return items(goatfile.BlockNames, CompletionKindKeyword, "request block",
+			rangeOnLine(pos.Line, line, indent, end), "[%s]")
+	}
+
+	// Keys of the options and auth blocks
+	if identPattern.FindString(trimmed) == trimmed {
+		rng := rangeOnLine(pos.Line, line, indent, len(prefix))
+		switch strings.ToLower(currentBlock(lines, pos.Line)) {
+		case "options":
+			return items(executor.OptionKeys, CompletionKindProperty, "option", rng, "%s")
+		case "auth":
+			return items(executor.AuthOptionKeys, CompletionKindProperty, "auth option", rng, "%s")
+		}
+	}
+
+	return []CompletionItem{}
+}
+
+// currentBlock returns the name of the request block
+// containing the given line or an empty string if the
+// line is not part of a block.
+func currentBlock(lines []string, line int) string {
+	for i := line - 1; i >= 0; i-- {
+		l := lines[i]
+		if m := blockHeaderPattern.FindStringSubmatch(l); m != nil {
+			return m[1]
+		}
+		trimmed := strings.TrimSpace(l)
+		if strings.HasPrefix(trimmed, "###") || strings.HasPrefix(trimmed, "---") ||
+			requestHeadPattern.MatchString(l) {
+			return ""
+		}
+	}
+	return ""
+}
+
+func items(labels []string, kind CompletionItemKind, detail string, rng Range, format string) []CompletionItem {
+	res := make([]CompletionItem, 0, len(labels))
+	for _, label := range labels {
+		res = append(res, CompletionItem{
+			Label:  label,
+			Kind:   kind,
+			Detail: detail,
+			TextEdit: &TextEdit{
+				Range:   rng,
+				NewText: fmt.Sprintf(format, label),
+			},
+		})
+	}
+	return res
+}
@@ -0,0 +1,40 @@
+package lsp
+
+import (
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/studio-b12/goat/pkg/goatfile"
+)
+
+var referencePattern = regexp.MustCompile(`^\s*(?:use|execute)\s+("[^"]*"|'[^']*'|[^\s(]+)`)
+
+// definition returns the location of the Goatfile
+// referenced by the use or execute statement at the
+// given position in the document at pth.
+func definition(text, pth string, pos Position) (Location, bool) {
+	lines := strings.Split(text, "\n")
+	if pos.Line >= len(lines) {
+		return Location{}, false
+	}
+
+	line := lines[pos.Line]
+	m := referencePattern.FindStringSubmatchIndex(line)
+	if m == nil || byteOffset(line, pos.Character) > m[3] {
+		return Location{}, false
+	}
+
+	ref := strings.Trim(line[m[2]:m[3]], `"'`)
+	if ref == "" {
+		return Location{}, false
+	}
+
+	target := goatfile.Extend(path.Join(path.Dir(pth), ref), goatfile.FileExtension)
+	if _, err := os.Stat(target); err != nil {
+		return Location{}, false
+	}
+
+	return Location{URI: pathToURI(target)}, true
+}
@@ -0,0 +1,86 @@
+package lsp
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/studio-b12/goat/pkg/lint"
+)
+
+// overlayFs implements fs.FS for the local file system
+// where the contents of files opened in the client are
+// taken from memory, so that unsaved changes are used.
+type overlayFs map[string]string
+
+var _ fs.FS = (overlayFs)(nil)
+
+func (t overlayFs) Open(name string) (fs.File, error) {
+	text, ok := t[name]
+	if !ok {
+		return os.Open(name)
+	}
+	return &memFile{Reader: strings.NewReader(text), name: path.Base(name)}, nil
+}
+
+// memFile implements fs.File and fs.FileInfo for
+// a file held in memory.
+type memFile struct {
+	*strings.Reader
+	name string
+}
+
+func (t *memFile) Stat() (fs.FileInfo, error) { return t, nil }
+func (t *memFile) Close() error               { return nil }
+func (t *memFile) Name() string               { return t.name }
+func (t *memFile) Mode() fs.FileMode          { return 0444 }
+func (t *memFile) ModTime() time.Time         { return time.Time{} }
+func (t *memFile) IsDir() bool                { return false }
+func (t *memFile) Sys() any                   { return nil }
+
+// diagnostics lints the Goatfile at pth with the given
+// text, where the texts of other opened Goatfiles are
+// taken from overlay, and returns the problems found
+// in the Goatfile itself.
+func diagnostics(overlay map[string]string, pth, text string) []Diagnostic {
+	fSys := overlayFs(overlay)
+	fSys[pth] = text
+
+	diags := []Diagnostic{}
+
+	found, err := lint.Lint(fSys, []string{pth})
+	if err != nil {
+		return append(diags, Diagnostic{
+			Severity: SeverityError,
+			Source:   "goat",
+			Message:  err.Error(),
+		})
+	}
+
+	lines := strings.Split(text, "\n")
+	for _, d := range found {
+		if d.File != pth {
+			continue
+		}
+
+		start := Position{Line: max(d.Line-1, 0), Character: max(d.Column-1, 0)}
+		end := start
+		if start.Line < len(lines) {
+			line := lines[start.Line]
+			offset := runeByteOffset(line, start.Character)
+			start.Character = characterOffset(line, offset)
+			end.Character = characterOffset(line, max(offset, len(line)))
+		}
+
+		diags = append(diags, Diagnostic{
+			Range:    Range{Start: start, End: end},
+			Severity: SeverityError,
+			Source:   "goat",
+			Message:  d.Message,
+		})
+	}
+
+	return diags
+}
@@ -0,0 +1,64 @@
+package lsp
+
+import "unicode/utf8"
+
+// The character offsets of LSP positions are counted
+// in UTF-16 code units, which is the default position
+// encoding of the protocol, while the server works on
+// byte offsets of the Go strings of the document lines.
+
+// byteOffset returns the byte offset in line of the
+// given character offset counted in UTF-16 code units.
+func byteOffset(line string, character int) int {
+	n := 0
+	for i, r := range line {
+		if n >= character {
+			return i
+		}
+		n += utf16Len(r)
+	}
+	return len(line)
+}
+
+// characterOffset returns the character offset counted
+// in UTF-16 code units of the given byte offset in line.
+func characterOffset(line string, offset int) int {
+	n := 0
+	for i, r := range line {
+		if i >= offset {
+			break
+		}
+		n += utf16Len(r)
+	}
+	return n
+}
+
+// runeByteOffset returns the byte offset in line of
+// the rune at the given rune index, as counted by the
+// Goatfile parser.
+func runeByteOffset(line string, index int) int {
+	for i := range line {
+		if index <= 0 {
+			return i
+		}
+		index--
+	}
+	return len(line)
+}
+
+func utf16Len(r rune) int {
+	if r > 0xFFFF && r <= utf8.MaxRune {
+		return 2
+	}
+	return 1
+}
+
+// rangeOnLine returns the range between the given
+// byte offsets start and end in text, which is the
+// content of the given line.
+func rangeOnLine(line int, text string, start, end int) Range {
+	return Range{
+		Start: Position{Line: line, Character: characterOffset(text, start)},
+		End:   Position{Line: line, Character: characterOffset(text, end)},
+	}
+}
@@ -0,0 +1,275 @@
+// Package lsp implements a language server for
+// Goatfiles speaking the Language Server Protocol
+// via JSON-RPC.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/studio-b12/goat/pkg/goatfile"
+	"github.com/studio-b12/goat/pkg/goatfile/ast"
+	"github.com/zekrotja/rogu/log"
+)
+
+var logger = log.Tagged("lsp")
+
+// document is a text document opened in the client.
+type document struct {
+	text string
+
+	// ast is the syntax tree of the last version
+	// of the document which could be parsed.
+	ast *ast.Goatfile
+}
+
+// Server is a language server for Goatfiles. All
+// requests are handled sequentially in the order
+// they are received.
+type Server struct {
+	version string
+
+	in  *bufio.Reader
+	out io.Writer
+
+	docs        map[string]*document
+	initialized bool
+	shutdown    bool
+}
+
+// NewServer returns a new Server reading requests
+// from r and writing responses to w. The given
+// version is reported to the client.
+func NewServer(r io.Reader, w io.Writer, version string) *Server {
+	return &Server{
+		version: version,
+		in:      bufio.NewReader(r),
+		out:     w,
+		docs:    make(map[string]*document),
+	}
+}
+
+// Run handles incoming messages until the client
+// sends the exit notification or the input is
+// closed.
+func (t *Server) Run() error {
+	for {
+		req, err := readMessage(t.in)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			var rErr *responseError
+			if errors.As(err, &rErr) {
+				if err = t.respond(nil, nil, rErr); err != nil {
+					return err
+				}
+				continue
+			}
+			return err
+		}
+
+		if req.Method == "exit" {
+			return nil
+		}
+
+		result, err := t.handle(req)
+		if req.ID == nil {
+			if err != nil {
+				logger.Error().Err(err).Field("method", req.Method).Msg("Handling notification failed")
+			}
+			continue
+		}
+
+		var rErr *responseError
+		if err != nil && !errors.As(err, &rErr) {
+			rErr = &responseError{Code: codeInternalError, Message: err.Error()}
+		}
+		if err = t.respond(req.ID, result, rErr); err != nil {
+			return err
+		}
+	}
+}
+
+func (t *Server) handle(req *request) (any, error) {
+	if !t.initialized && req.Method != "initialize" {
+		return nil, &responseError{Code: codeServerNotInitialized, Message: "server not initialized"}
+	}
+	if t.shutdown {
+		return nil, &responseError{Code: codeInvalidRequest, Message: "server is shutting down"}
+	}
+
+	switch req.Method {
+
+	case "initialize":
+		t.initialized = true
+		return InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync: textDocumentSyncFull,
+				CompletionProvider: CompletionOptions{
+					TriggerCharacters: []string{"[", "{", "#"},
+				},
+				DefinitionProvider:     true,
+				DocumentSymbolProvider: true,
+			},
+			ServerInfo: ServerInfo{Name: "goat", Version: t.version},
+		}, nil
+
+	case "shutdown":
+		t.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		return nil, t.update(params.TextDocument.URI, params.TextDocument.Text)
+
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+		return nil, t.update(params.TextDocument.URI, text)
+
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		delete(t.docs, params.TextDocument.URI)
+		return nil, t.notify("textDocument/publishDiagnostics",
+			PublishDiagnosticsParams{URI: params.TextDocument.URI, Diagnostics: []Diagnostic{}})
+
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := t.docs[params.TextDocument.URI]
+		if !ok {
+			return []CompletionItem{}, nil
+		}
+		return completion(doc.text, params.Position), nil
+
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := t.docs[params.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		loc, ok := definition(doc.text, uriToPath(params.TextDocument.URI), params.Position)
+		if !ok {
+			return nil, nil
+		}
+		return loc, nil
+
+	case "textDocument/documentSymbol":
+		var params DocumentSymbolParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := t.docs[params.TextDocument.URI]
+		if !ok || doc.ast == nil {
+			return []DocumentSymbol{}, nil
+		}
+		return documentSymbols(doc.ast, doc.text), nil
+
+	default:
+		if req.ID == nil || strings.HasPrefix(req.Method, "$/") {
+			// Unknown notifications are ignored.
+			return nil, nil
+		}
+		return nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+// update sets the text of the document with the given
+// URI and publishes the diagnostics of the document.
+func (t *Server) update(uri, text string) error {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	doc, ok := t.docs[uri]
+	if !ok {
+		doc = new(document)
+		t.docs[uri] = doc
+	}
+	doc.text = text
+
+	pth := uriToPath(uri)
+
+	gf, err := goatfile.NewParser(strings.NewReader(text), pth).Parse()
+	if err == nil {
+		doc.ast = gf
+	}
+
+	overlay := make(map[string]string, len(t.docs))
+	for u, d := range t.docs {
+		overlay[uriToPath(u)] = d.text
+	}
+
+	return t.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: diagnostics(overlay, pth, text),
+	})
+}
+
+func (t *Server) respond(id *json.RawMessage, result any, rErr *responseError) error {
+	res := response{JSONRPC: "2.0", ID: id, Error: rErr}
+	if rErr == nil {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		res.Result = data
+	}
+	return writeMessage(t.out, res)
+}
+
+func (t *Server) notify(method string, params any) error {
+	return writeMessage(t.out, notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func unmarshalParams(req *request, v any) error {
+	if err := json.Unmarshal(req.Params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// uriToPath returns the slash separated file
+// path of the given file URI.
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	pth := u.Path
+	// Windows paths are encoded like /C:/foo.
+	if len(pth) > 2 && pth[0] == '/' && pth[2] == ':' {
+		pth = pth[1:]
+	}
+	return filepath.ToSlash(pth)
+}
+
+// pathToURI returns the file URI of the given
+// slash separated file path.
+func pathToURI(pth string) string {
+	if !strings.HasPrefix(pth, "/") {
+		pth = "/" + pth
+	}
+	return (&url.URL{Scheme: "file", Path: pth}).String()
+}
@@ -0,0 +1,197 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func call(id int, method string, params any) map[string]any {
+	return map[string]any{"jsonrpc": "2.0", "id": id, "method": method, "params": params}
+}
+
+func notify(method string, params any) map[string]any {
+	return map[string]any{"jsonrpc": "2.0", "method": method, "params": params}
+}
+
+// run passes the given messages to a new Server and
+// returns the raw messages written by the server.
+func run(t *testing.T, msgs ...any) []json.RawMessage {
+	t.Helper()
+
+	var in bytes.Buffer
+	for _, msg := range msgs {
+		require.Nil(t, writeMessage(&in, msg))
+	}
+
+	var out bytes.Buffer
+	err := NewServer(&in, &out, "test").Run()
+	require.Nil(t, err, err)
+
+	var res []json.RawMessage
+	r := bufio.NewReader(&out)
+	for {
+		header, err := textproto.NewReader(r).ReadMIMEHeader()
+		if err == io.EOF {
+			return res
+		}
+		require.Nil(t, err, err)
+
+		length, err := strconv.Atoi(header.Get("Content-Length"))
+		require.Nil(t, err, err)
+
+		body := make([]byte, length)
+		_, err = io.ReadFull(r, body)
+		require.Nil(t, err, err)
+
+		res = append(res, body)
+	}
+}
+
+func TestServer(t *testing.T) {
+	dir := filepath.ToSlash(t.TempDir())
+	err := os.WriteFile(dir+"/lib.goat", []byte("GET https://example.com\n"), 0644)
+	require.Nil(t, err, err)
+
+	uri := pathToURI(dir + "/a.goat")
+	text := "use lib\n\n### Tests\n\nGET https://example.com\n\n[Options]\nfoo = 1\n"
+	doc := map[string]any{"uri": uri}
+
+	raw := run(t,
+		call(1, "initialize", map[string]any{}),
+		notify("initialized", map[string]any{}),
+		notify("textDocument/didOpen", map[string]any{
+			"textDocument": map[string]any{"uri": uri, "languageId": "goat", "version": 1, "text": text},
+		}),
+		call(2, "textDocument/definition", map[string]any{
+			"textDocument": doc,
+			"position":     Position{Line: 0, Character: 5},
+		}),
+		call(3, "textDocument/documentSymbol", map[string]any{"textDocument": doc}),
+		call(4, "textDocument/foo", nil),
+		call(5, "shutdown", nil),
+		notify("exit", nil),
+	)
+	require.Len(t, raw, 6)
+
+	var initRes struct {
+		Result InitializeResult `json:"result"`
+	}
+	require.Nil(t, json.Unmarshal(raw[0], &initRes))
+	assert.True(t, initRes.Result.Capabilities.DefinitionProvider)
+	assert.Equal(t, "test", initRes.Result.ServerInfo.Version)
+
+	var diags struct {
+		Method string                   `json:"method"`
+		Params PublishDiagnosticsParams `json:"params"`
+	}
+	require.Nil(t, json.Unmarshal(raw[1], &diags))
+	assert.Equal(t, "textDocument/publishDiagnostics", diags.Method)
+	require.Len(t, diags.Params.Diagnostics, 1)
+	assert.Equal(t, "unknown option: foo", diags.Params.Diagnostics[0].Message)
+	assert.Equal(t, Position{Line: 7, Character: 0}, diags.Params.Diagnostics[0].Range.Start)
+
+	var def struct {
+		Result Location `json:"result"`
+	}
+	require.Nil(t, json.Unmarshal(raw[2], &def))
+	assert.Equal(t, pathToURI(dir+"/lib.goat"), def.Result.URI)
+
+	var symbols struct {
+		Result []DocumentSymbol `json:"result"`
+	}
+	require.Nil(t, json.Unmarshal(raw[3], &symbols))
+	require.Len(t, symbols.Result, 1)
+	assert.Equal(t, "Tests", symbols.Result[0].Name)
+	assert.Equal(t, Range{Start: Position{Line: 2}, End: Position{Line: 7, Character: 7}}, symbols.Result[0].Range)
+	require.Len(t, symbols.Result[0].Children, 1)
+	assert.Equal(t, "GET https://example.com", symbols.Result[0].Children[0].Name)
+
+	var unknown struct {
+		Error responseError `json:"error"`
+	}
+	require.Nil(t, json.Unmarshal(raw[4], &unknown))
+	assert.Equal(t, codeMethodNotFound, unknown.Error.Code)
+
+	assert.JSONEq(t, `{"jsonrpc": "2.0", "id": 5, "result": null}`, string(raw[5]))
+}
+
+func TestServer_parseError(t *testing.T) {
+	uri := pathToURI(filepath.ToSlash(t.TempDir()) + "/a.goat")
+
+	raw := run(t,
+		call(1, "initialize", map[string]any{}),
+		notify("textDocument/didOpen", map[string]any{
+			"textDocument": map[string]any{"uri": uri, "text": "GET https://example.com\n\n[Foo]\n"},
+		}),
+	)
+	require.Len(t, raw, 2)
+
+	var diags struct {
+		Params PublishDiagnosticsParams `json:"params"`
+	}
+	require.Nil(t, json.Unmarshal(raw[1], &diags))
+	assert.Equal(t, []Diagnostic{{
+		Range:    Range{Start: Position{Line: 2, Character: 5}, End: Position{Line: 2, Character: 5}},
+		Severity: SeverityError,
+		Source:   "goat",
+		Message:  "invalid block header ('Foo')",
+	}}, diags.Params.Diagnostics)
+}
+
+func TestCompletion(t *testing.T) {
+	labels := func(items []CompletionItem) []string {
+		res := make([]string, 0, len(items))
+		for _, item := range items {
+			res = append(res, item.Label)
+		}
+		return res
+	}
+
+	text := "GET https://example.com/{{ bas\n\n[Opt]\n\n[Options]\ncook\n\n[Auth]\n\n### Te"
+
+	items := completion(text, Position{Line: 0, Character: 30})
+	assert.Contains(t, labels(items), "base64")
+	assert.Equal(t, Range{Start: Position{Character: 27}, End: Position{Character: 30}}, items[0].TextEdit.Range)
+
+	items = completion(text, Position{Line: 2, Character: 4})
+	assert.Contains(t, labels(items), "Options")
+	assert.Contains(t, labels(items), "FormData")
+	for _, item := range items {
+		if item.Label == "Options" {
+			assert.Equal(t, "[Options]", item.TextEdit.NewText)
+			assert.Equal(t, Range{Start: Position{Line: 2}, End: Position{Line: 2, Character: 5}}, item.TextEdit.Range)
+		}
+	}
+
+	assert.Contains(t, labels(completion(text, Position{Line: 5, Character: 4})), "cookiejar")
+	assert.Contains(t, labels(completion(text, Position{Line: 8, Character: 0})), "username")
+	assert.Contains(t, labels(completion(text, Position{Line: 9, Character: 6})), "Tests")
+	assert.Empty(t, completion(text, Position{Line: 1, Character: 0}))
+}
+
+func TestPositionEncoding(t *testing.T) {
+	// Character offsets are counted in UTF-16 code units, where
+	// 'ü' takes one and '😀' takes two units.
+	text := "GET https://example.com\n\n[Header]\nX-Name: Jürgen 😀 {{ bas\n\n[Options]\nschema = \"ü😀\" foo\n"
+
+	items := completion(text, Position{Line: 3, Character: 24})
+	require.NotEmpty(t, items)
+	assert.Equal(t, "base64", items[0].Label)
+	assert.Equal(t, Range{Start: Position{Line: 3, Character: 21}, End: Position{Line: 3, Character: 24}},
+		items[0].TextEdit.Range)
+
+	diags := diagnostics(map[string]string{}, "/test.goat", text)
+	require.Len(t, diags, 1)
+	assert.Equal(t, Range{Start: Position{Line: 6, Character: 18}, End: Position{Line: 6, Character: 18}},
+		diags[0].Range)
+}
@@ -0,0 +1,118 @@
+package lsp
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/studio-b12/goat/pkg/goatfile/ast"
+)
+
+// documentSymbols returns the sections, requests,
+// execute statements and log sections of the given
+// Goatfile as document symbols.
+func documentSymbols(gf *ast.Goatfile, text string) []DocumentSymbol {
+	lines := strings.Split(text, "\n")
+
+	symbols := actionSymbols(gf.Actions, lines)
+
+	for _, sect := range gf.Sections {
+		var (
+			name     string
+			pos      ast.Pos
+			children []DocumentSymbol
+		)
+
+		switch s := sect.(type) {
+		case ast.SectionDefaults:
+			name, pos = "Defaults", s.Pos
+		case ast.SectionSetup:
+			name, pos, children = "Setup", s.Pos, actionSymbols(s.Actions, lines)
+		case ast.SectionTests:
+			name, pos, children = "Tests", s.Pos, actionSymbols(s.Actions, lines)
+		case ast.SectionTeardown:
+			name, pos, children = "Teardown", s.Pos, actionSymbols(s.Actions, lines)
+		default:
+			continue
+		}
+
+		symbols = append(symbols, DocumentSymbol{
+			Name:     name,
+			Kind:     SymbolKindNamespace,
+			Range:    rangeOnLine(pos.Line, lines[pos.Line], 0, len(lines[pos.Line])),
+			Children: children,
+		})
+	}
+
+	slices.SortFunc(symbols, func(a, b DocumentSymbol) int {
+		return a.Range.Start.Line - b.Range.Start.Line
+	})
+
+	extendRanges(symbols, len(lines)-1, lines)
+
+	return symbols
+}
+
+func actionSymbols(actions []ast.Action, lines []string) []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+
+	for _, act := range actions {
+		var (
+			name string
+			kind SymbolKind
+			pos  ast.Pos
+		)
+
+		switch a := act.(type) {
+		case *ast.Request:
+			name, kind, pos = a.Head.Method+" "+a.Head.Url, SymbolKindFunction, a.Pos
+		case *ast.Execute:
+			name, kind, pos = "execute "+a.Path, SymbolKindModule, a.Pos
+		case ast.LogSection:
+			name, kind, pos = a.Content, SymbolKindString, a.Pos
+		default:
+			continue
+		}
+
+		start := runeByteOffset(lines[pos.Line], pos.LinePos)
+		symbols = append(symbols, DocumentSymbol{
+			Name:  name,
+			Kind:  kind,
+			Range: rangeOnLine(pos.Line, lines[pos.Line], start, start),
+		})
+	}
+
+	return symbols
+}
+
+// extendRanges sets the selection ranges of the given
+// symbols to their initial ranges and extends their
+// ranges up to the start of the next symbol, skipping
+// trailing empty lines and delimiters, or up to the
+// given last line.
+func extendRanges(symbols []DocumentSymbol, last int, lines []string) {
+	for i := range symbols {
+		s := &symbols[i]
+
+		end := last
+		if i < len(symbols)-1 {
+			end = symbols[i+1].Range.Start.Line - 1
+		}
+		for end > s.Range.Start.Line {
+			trimmed := strings.TrimSpace(lines[end])
+			if trimmed != "" && !strings.HasPrefix(trimmed, "---") {
+				break
+			}
+			end--
+		}
+
+		startLine := lines[s.Range.Start.Line]
+		s.SelectionRange = Range{
+			Start: s.Range.Start,
+			End:   Position{Line: s.Range.Start.Line, Character: characterOffset(startLine, len(startLine))},
+		}
+		s.Range.Start.Character = 0
+		s.Range.End = Position{Line: end, Character: characterOffset(lines[end], len(lines[end]))}
+
+		extendRanges(s.Children, end, lines)
+	}
+}
@@ -0,0 +1,134 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/studio-b12/goat/pkg/executor"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     junitSeconds     `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Assertions int             `xml:"assertions,attr,omitempty"`
+	Time       junitSeconds    `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name       string        `xml:"name,attr"`
+	ClassName  string        `xml:"classname,attr"`
+	File       string        `xml:"file,attr,omitempty"`
+	Line       int           `xml:"line,attr,omitempty"`
+	Assertions int           `xml:"assertions,attr,omitempty"`
+	Time       junitSeconds  `xml:"time,attr"`
+	Failure    *junitFailure `xml:"failure,omitempty"`
+	Skipped    *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Content string `xml:",chardata"`
+}
+
+// junitSeconds formats a duration as decimal
+// seconds like expected by JUnit consumers.
+type junitSeconds time.Duration
+
+func (t junitSeconds) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return xml.Attr{
+		Name:  name,
+		Value: fmt.Sprintf("%.3f", time.Duration(t).Seconds()),
+	}, nil
+}
+
+func (t *junitSeconds) UnmarshalXMLAttr(attr xml.Attr) error {
+	secs, err := strconv.ParseFloat(attr.Value, 64)
+	if err != nil {
+		return err
+	}
+	*t = junitSeconds(secs * float64(time.Second))
+	return nil
+}
+
+// JUnit writes the given result as JUnit XML
+// report to w. Each executed batch is written
+// as test suite and each executed request or
+// execute statement as test case.
+func JUnit(w io.Writer, res executor.Result) error {
+	var suites junitTestSuites
+
+	for _, batch := range res.Batches {
+		suite := junitTestSuite{
+			Name:      batch.Path,
+			Time:      junitSeconds(batch.Duration),
+			Timestamp: batch.Start.Format("2006-01-02T15:04:05"),
+		}
+
+		for _, act := range batch.Actions() {
+			tc := junitTestCase{
+				Name:       act.Name,
+				ClassName:  fmt.Sprintf("%s.%s", batch.Path, act.Section),
+				File:       act.Path,
+				Line:       act.Line,
+				Assertions: act.Checks.Passed + act.Checks.Failed,
+				Time:       junitSeconds(act.Duration),
+			}
+
+			if act.Failed() {
+				tc.Failure = &junitFailure{
+					Message: act.Err.Error(),
+					Type:    errorType(act.Err),
+					Content: errorChain(act.Err),
+				}
+				suite.Failures++
+			} else if act.Skipped {
+				tc.Skipped = &junitSkipped{Message: "skipped due to condition"}
+				suite.Skipped++
+			}
+
+			suite.Cases = append(suite.Cases, tc)
+			suite.Tests++
+			suite.Assertions += tc.Assertions
+		}
+
+		suites.Suites = append(suites.Suites, suite)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Time += suite.Time
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(suites)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,79 @@
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/studio-b12/goat/pkg/engine"
+	"github.com/studio-b12/goat/pkg/errs"
+	"github.com/studio-b12/goat/pkg/executor"
+	"github.com/studio-b12/goat/pkg/goatfile"
+)
+
+func TestJUnit(t *testing.T) {
+	inner := errors.New("assertion failed")
+
+	res := executor.Result{
+		Batches: []executor.BatchResult{
+			{
+				Path:     "tests/a.goat",
+				Start:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				Duration: 1500 * time.Millisecond,
+				Setup: executor.ResultSection{Actions: []executor.ActionResult{
+					{Name: "POST /login", Section: goatfile.SectionSetup, Path: "tests/a.goat", Line: 3, Duration: time.Second},
+				}},
+				Tests: executor.ResultSection{Actions: []executor.ActionResult{
+					{Name: "DELETE /user", Section: goatfile.SectionTests, Path: "tests/a.goat", Line: 8, Skipped: true},
+					{Name: "GET /user", Section: goatfile.SectionTests, Path: "tests/a.goat", Line: 12,
+						Duration: 500 * time.Millisecond, Err: errs.WithPrefix("script failed:", inner),
+						Checks: engine.Checks{Passed: 2, Failed: 1}},
+				}},
+			},
+			{
+				Path: "tests/b.goat",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := JUnit(&buf, res)
+	assert.Nil(t, err, err)
+
+	var suites junitTestSuites
+	err = xml.Unmarshal(buf.Bytes(), &suites)
+	assert.Nil(t, err, err)
+
+	assert.Equal(t, 3, suites.Tests)
+	assert.Equal(t, 1, suites.Failures)
+	assert.Equal(t, 2, len(suites.Suites))
+
+	suite := suites.Suites[0]
+	assert.Equal(t, "tests/a.goat", suite.Name)
+	assert.Equal(t, "2024-01-02T03:04:05", suite.Timestamp)
+	assert.Equal(t, 3, len(suite.Cases))
+	assert.Equal(t, 1, suite.Skipped)
+	assert.Equal(t, 3, suite.Assertions)
+
+	assert.Equal(t, "POST /login", suite.Cases[0].Name)
+	assert.Equal(t, "tests/a.goat.setup", suite.Cases[0].ClassName)
+	assert.Equal(t, 3, suite.Cases[0].Line)
+	assert.Nil(t, suite.Cases[0].Failure)
+
+	assert.NotNil(t, suite.Cases[1].Skipped)
+	assert.Nil(t, suite.Cases[1].Failure)
+
+	assert.Equal(t, "tests/a.goat.tests", suite.Cases[2].ClassName)
+	assert.Equal(t, 3, suite.Cases[2].Assertions)
+	assert.NotNil(t, suite.Cases[2].Failure)
+	assert.Equal(t, "script failed: assertion failed", suite.Cases[2].Failure.Message)
+	assert.Equal(t, "*errors.errorString", suite.Cases[2].Failure.Type)
+	assert.Equal(t, "script failed: assertion failed\n  assertion failed",
+		suite.Cases[2].Failure.Content)
+
+	assert.Equal(t, 0, len(suites.Suites[1].Cases))
+	assert.Contains(t, buf.String(), `time="1.500"`)
+}
@@ -0,0 +1,93 @@
+// Package report provides writers to export
+// execution results into report formats which
+// can be consumed by other tools like CI
+// pipelines.
+package report
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/studio-b12/goat/pkg/errs"
+	"github.com/studio-b12/goat/pkg/executor"
+)
+
+var ErrUnknownFormat = errors.New("unknown report format")
+
+// Writer writes the given result in a
+// specific report format to w.
+type Writer func(w io.Writer, res executor.Result) error
+
+var writers = map[string]Writer{
+	"junit": JUnit,
+}
+
+// WriteToFile takes a report specification in the
+// format <format>=<path> and writes the given result
+// in the specified format to the given file path.
+// Non-existent parent directories are created.
+func WriteToFile(spec string, res executor.Result) error {
+	format, pth, ok := strings.Cut(spec, "=")
+	if !ok || pth == "" {
+		return fmt.Errorf("invalid report specification '%s' (format must be <format>=<path>)", spec)
+	}
+
+	writer, ok := writers[strings.ToLower(format)]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+
+	err := os.MkdirAll(filepath.Dir(pth), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(pth)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return writer(f, res)
+}
+
+// errorType returns the type name of the
+// innermost error in the chain of err. Timeouts
+// are reported as executor.TimeoutError to tell
+// them apart from other failures.
+func errorType(err error) string {
+	if timeoutErr, ok := errs.As[executor.TimeoutError](err); ok {
+		return fmt.Sprintf("%T", timeoutErr)
+	}
+
+	for {
+		inner := errors.Unwrap(err)
+		if inner == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = inner
+	}
+}
+
+// errorChain returns the messages of all errors
+// in the chain of err, each on a new line. Layers
+// which do not add any information to the message
+// of their inner error are skipped.
+func errorChain(err error) string {
+	var lines []string
+
+	for err != nil {
+		msg := err.Error()
+		inner := errors.Unwrap(err)
+		if inner == nil || inner.Error() != msg {
+			lines = append(lines, strings.Repeat("  ", len(lines))+msg)
+		}
+		err = inner
+	}
+
+	return strings.Join(lines, "\n")
+}
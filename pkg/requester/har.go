@@ -0,0 +1,383 @@
+package requester
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/studio-b12/goat/pkg/har"
+)
+
+// Source is the position of a request in
+// the Goatfile it has been defined in.
+type Source struct {
+	Path    string
+	Line    int
+	Section string
+}
+
+func (t Source) String() string {
+	if t.Section == "" {
+		return fmt.Sprintf("%s:%d", t.Path, t.Line)
+	}
+	return fmt.Sprintf("%s:%d (%s)", t.Path, t.Line, t.Section)
+}
+
+type sourceKey struct{}
+
+// HARRecorder implements http.RoundTripper and records
+// all requests and responses passing through into a
+// HTTP Archive.
+//
+// Request and response bodies are recorded up to
+// MaxBodySize bytes. The remainder of larger bodies
+// is streamed through without being buffered. When
+// MaxBodySize is 0, bodies are recorded completely.
+type HARRecorder struct {
+	Transport   http.RoundTripper
+	MaxBodySize int
+
+	mtx     sync.Mutex
+	archive *har.HAR
+}
+
+var _ http.RoundTripper = (*HARRecorder)(nil)
+
+// NewHARRecorder returns a new HARRecorder wrapping the
+// given transport. When transport is nil,
+// http.DefaultTransport is used.
+func NewHARRecorder(transport http.RoundTripper, creator har.Creator, maxBodySize int) *HARRecorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	return &HARRecorder{
+		Transport:   transport,
+		MaxBodySize: maxBodySize,
+		archive:     har.New(creator),
+	}
+}
+
+func (t *HARRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var tr traceTimings
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), tr.clientTrace()))
+
+	var (
+		reqBody []byte
+		err     error
+	)
+	if req.Body != nil && req.Body != http.NoBody {
+		var complete bool
+		reqBody, req.Body, complete, err = t.readBody(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if complete {
+			req.GetBody = func() (io.ReadCloser, error) {
+				return io.NopCloser(bytes.NewReader(reqBody)), nil
+			}
+		}
+	}
+
+	start := time.Now()
+
+	res, err := t.Transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, body, _, err := t.readBody(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	res.Body = body
+	end := time.Now()
+
+	entry := har.Entry{
+		StartedDateTime: start,
+		Request:         t.harRequest(req, reqBody),
+		Response:        t.harResponse(res, resBody),
+		Timings:         tr.timings(start, end),
+	}
+	entry.Time = har.Milliseconds(end.Sub(start))
+	if src, ok := req.Context().Value(sourceKey{}).(Source); ok {
+		entry.Comment = src.String()
+	}
+
+	t.mtx.Lock()
+	t.archive.Add(entry)
+	t.mtx.Unlock()
+
+	return res, nil
+}
+
+// WriteTo writes the recorded archive as JSON to w.
+func (t *HARRecorder) WriteTo(w io.Writer) (int64, error) {
+	t.mtx.Lock()
+	data, err := json.MarshalIndent(t.archive, "", "  ")
+	t.mtx.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// WriteToFile writes the recorded archive as JSON
+// into the file at the given path.
+func (t *HARRecorder) WriteToFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = t.WriteTo(f)
+	return err
+}
+
+func (t *HARRecorder) harRequest(req *http.Request, body []byte) har.Request {
+	hreq := har.Request{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		HTTPVersion: req.Proto,
+		Cookies:     har.Cookies(req.Cookies()),
+		Headers:     har.Headers(req.Header),
+		QueryString: har.QueryString(req.URL),
+		HeadersSize: -1,
+		BodySize:    t.bodySize(body, req.ContentLength),
+	}
+
+	if body != nil {
+		text, truncated := t.truncate(body)
+		hreq.PostData = &har.PostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     string(text),
+		}
+		if truncated {
+			hreq.PostData.Comment = truncatedComment(len(text), hreq.BodySize)
+		}
+	}
+
+	return hreq
+}
+
+func (t *HARRecorder) harResponse(res *http.Response, body []byte) har.Response {
+	size := t.bodySize(body, res.ContentLength)
+	hres := har.Response{
+		Status:      res.StatusCode,
+		StatusText:  http.StatusText(res.StatusCode),
+		HTTPVersion: res.Proto,
+		Cookies:     har.Cookies(res.Cookies()),
+		Headers:     har.Headers(res.Header),
+		RedirectURL: res.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    size,
+		Content: har.Content{
+			Size:     size,
+			MimeType: res.Header.Get("Content-Type"),
+		},
+	}
+
+	text, truncated := t.truncate(body)
+	if utf8.Valid(text) {
+		hres.Content.Text = string(text)
+	} else {
+		hres.Content.Text = base64.StdEncoding.EncodeToString(text)
+		hres.Content.Encoding = "base64"
+	}
+	if truncated {
+		hres.Content.Comment = truncatedComment(len(text), size)
+	}
+
+	return hres
+}
+
+// readBody reads the given body up to MaxBodySize+1
+// bytes, so that truncation can be detected, and
+// returns the read bytes as well as a reader yielding
+// the complete body. The remainder of the body is not
+// read but streamed from the returned reader. complete
+// is true when the body has been read completely.
+func (t *HARRecorder) readBody(body io.ReadCloser) (data []byte, r io.ReadCloser, complete bool, err error) {
+	var lr io.Reader = body
+	if t.MaxBodySize > 0 {
+		lr = io.LimitReader(body, int64(t.MaxBodySize)+1)
+	}
+
+	data, err = io.ReadAll(lr)
+	if err != nil {
+		body.Close()
+		return nil, nil, false, err
+	}
+
+	if t.MaxBodySize <= 0 || len(data) <= t.MaxBodySize {
+		body.Close()
+		return data, io.NopCloser(bytes.NewReader(data)), true, nil
+	}
+
+	r = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), body), body}
+
+	return data, r, false, nil
+}
+
+// bodySize returns the size of the given recorded
+// body. When the body has been truncated, the given
+// content length is returned, which is -1 when the
+// size is unknown.
+func (t *HARRecorder) bodySize(body []byte, contentLength int64) int64 {
+	if _, truncated := t.truncate(body); truncated {
+		return contentLength
+	}
+	return int64(len(body))
+}
+
+// truncate returns the given body cut to
+// MaxBodySize and whether it has been cut.
+func (t *HARRecorder) truncate(body []byte) ([]byte, bool) {
+	if t.MaxBodySize <= 0 || len(body) <= t.MaxBodySize {
+		return body, false
+	}
+	return body[:t.MaxBodySize], true
+}
+
+func truncatedComment(recorded int, size int64) string {
+	if size < 0 {
+		return fmt.Sprintf("body truncated to %d bytes", recorded)
+	}
+	return fmt.Sprintf("body truncated to %d of %d bytes", recorded, size)
+}
+
+// withSource returns a copy of ctx carrying
+// the given source.
+func withSource(ctx context.Context, src Source) context.Context {
+	return context.WithValue(ctx, sourceKey{}, src)
+}
+
+// traceTimings records the points in time of the
+// phases of a request.
+type traceTimings struct {
+	mtx sync.Mutex
+
+	gotConn      time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+}
+
+func (t *traceTimings) clientTrace() *httptrace.ClientTrace {
+	set := func(f func()) {
+		t.mtx.Lock()
+		defer t.mtx.Unlock()
+		f()
+	}
+
+	return &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) {
+			set(func() { t.gotConn = time.Now() })
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			set(func() { t.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			set(func() { t.dnsDone = time.Now() })
+		},
+		ConnectStart: func(string, string) {
+			// Multiple connections might be dialed in
+			// parallel, so only the first start counts.
+			set(func() {
+				if t.connectStart.IsZero() {
+					t.connectStart = time.Now()
+				}
+			})
+		},
+		ConnectDone: func(string, string, error) {
+			set(func() { t.connectDone = time.Now() })
+		},
+		TLSHandshakeStart: func() {
+			set(func() { t.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			set(func() { t.tlsDone = time.Now() })
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			set(func() { t.wroteRequest = time.Now() })
+		},
+		GotFirstResponseByte: func() {
+			set(func() { t.firstByte = time.Now() })
+		},
+	}
+}
+
+// timings returns the HAR timings of a request
+// started at start and finished at end.
+func (t *traceTimings) timings(start, end time.Time) har.Timings {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	span := func(from, to time.Time) float64 {
+		if from.IsZero() || to.IsZero() || to.Before(from) {
+			return -1
+		}
+		return har.Milliseconds(to.Sub(from))
+	}
+
+	res := har.Timings{
+		DNS:     span(t.dnsStart, t.dnsDone),
+		Connect: span(t.connectStart, t.connectDone),
+		SSL:     span(t.tlsStart, t.tlsDone),
+	}
+
+	// The connect time includes the TLS handshake.
+	if res.SSL > 0 && res.Connect >= 0 {
+		res.Connect += res.SSL
+	}
+
+	gotConn := t.gotConn
+	if gotConn.IsZero() {
+		gotConn = start
+	}
+	res.Blocked = har.Milliseconds(gotConn.Sub(start))
+	for _, d := range []float64{res.DNS, res.Connect} {
+		if d > 0 {
+			res.Blocked -= d
+		}
+	}
+	if res.Blocked < 0 {
+		res.Blocked = 0
+	}
+
+	wroteRequest := t.wroteRequest
+	if wroteRequest.IsZero() {
+		wroteRequest = gotConn
+	}
+	firstByte := t.firstByte
+	if firstByte.IsZero() {
+		firstByte = wroteRequest
+	}
+
+	res.Send = har.Milliseconds(wroteRequest.Sub(gotConn))
+	res.Wait = har.Milliseconds(firstByte.Sub(wroteRequest))
+	res.Receive = har.Milliseconds(end.Sub(firstByte))
+
+	return res
+}
@@ -0,0 +1,129 @@
+package requester
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-b12/goat/pkg/har"
+)
+
+func TestHARRecorder(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+			http.Redirect(w, r, "/home", http.StatusFound)
+		default:
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write(append([]byte("echo: "), body...))
+		}
+	}))
+	defer srv.Close()
+
+	rec := NewHARRecorder(nil, har.Creator{Name: "goat", Version: "test"}, 8)
+	r := NewHttpWithCookies(func(client *http.Client) {
+		client.Transport = rec
+	})
+
+	opts, err := OptionsFromMap(nil)
+	require.Nil(t, err, err)
+	opts.Source = Source{Path: "test.goat", Line: 3, Section: "tests"}
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/login?a=b", nil)
+	require.Nil(t, err, err)
+	_, err = r.Do(req, opts)
+	require.Nil(t, err, err)
+
+	opts.Source.Line = 7
+	req, err = http.NewRequest(http.MethodPost, srv.URL+"/echo", strings.NewReader("hello world"))
+	require.Nil(t, err, err)
+	res, err := r.Do(req, opts)
+	require.Nil(t, err, err)
+
+	// The response body must still be readable
+	// after it has been recorded.
+	body, err := io.ReadAll(res.Body)
+	require.Nil(t, err, err)
+	assert.Equal(t, "echo: hello world", string(body))
+
+	var buf bytes.Buffer
+	_, err = rec.WriteTo(&buf)
+	require.Nil(t, err, err)
+
+	var archive har.HAR
+	err = json.Unmarshal(buf.Bytes(), &archive)
+	require.Nil(t, err, err)
+
+	entries := archive.Log.Entries
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, "test.goat:3 (tests)", entries[0].Comment)
+	assert.Equal(t, http.StatusFound, entries[0].Response.Status)
+	assert.Equal(t, "/home", entries[0].Response.RedirectURL)
+	assert.Equal(t, []har.NameValue{{Name: "a", Value: "b"}}, entries[0].Request.QueryString)
+	require.Len(t, entries[0].Response.Cookies, 1)
+	assert.Equal(t, "session", entries[0].Response.Cookies[0].Name)
+
+	assert.Equal(t, "test.goat:3 (tests)", entries[1].Comment)
+	assert.Equal(t, srv.URL+"/home", entries[1].Request.URL)
+	assert.Equal(t, []har.Cookie{{Name: "session", Value: "abc"}}, entries[1].Request.Cookies)
+
+	assert.Equal(t, "test.goat:7 (tests)", entries[2].Comment)
+	assert.Equal(t, []har.Cookie{{Name: "session", Value: "abc"}}, entries[2].Request.Cookies)
+	require.NotNil(t, entries[2].Request.PostData)
+	assert.Equal(t, "hello wo", entries[2].Request.PostData.Text)
+	assert.Equal(t, int64(11), entries[2].Request.BodySize)
+	assert.Equal(t, "echo: he", entries[2].Response.Content.Text)
+	assert.Equal(t, int64(17), entries[2].Response.Content.Size)
+	assert.Equal(t, "body truncated to 8 of 17 bytes", entries[2].Response.Content.Comment)
+	assert.GreaterOrEqual(t, entries[2].Timings.Wait, 0.0)
+}
+
+func TestHARRecorder_streaming(t *testing.T) {
+	release := make(chan struct{})
+	var timedOut atomic.Bool
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, strings.Repeat("a", 16))
+		w.(http.Flusher).Flush()
+		// The rest of the body is only sent after the
+		// response has been passed to the caller.
+		select {
+		case <-release:
+		case <-time.After(5 * time.Second):
+			timedOut.Store(true)
+		}
+		io.WriteString(w, strings.Repeat("b", 16))
+	}))
+	defer srv.Close()
+
+	rec := NewHARRecorder(nil, har.Creator{Name: "goat", Version: "test"}, 8)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.Nil(t, err, err)
+	res, err := rec.RoundTrip(req)
+	require.Nil(t, err, err)
+	defer res.Body.Close()
+
+	close(release)
+	body, err := io.ReadAll(res.Body)
+	require.Nil(t, err, err)
+	assert.False(t, timedOut.Load())
+	assert.Equal(t, strings.Repeat("a", 16)+strings.Repeat("b", 16), string(body))
+
+	entries := rec.archive.Log.Entries
+	require.Len(t, entries, 1)
+	assert.Equal(t, "aaaaaaaa", entries[0].Response.Content.Text)
+	assert.Equal(t, int64(-1), entries[0].Response.Content.Size)
+	assert.Equal(t, "body truncated to 8 bytes", entries[0].Response.Content.Comment)
+}
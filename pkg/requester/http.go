@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"sync"
 
 	"github.com/zekrotja/rogu/log"
 )
@@ -32,8 +33,17 @@ func (t noGetWrapper) Cookies(u *url.URL) []*http.Cookie { return nil }
 // between and manipulate the behavior
 // of cookie handling.
 type HttpWithCookies struct {
-	client     *http.Client
-	cookieJars map[any]http.CookieJar
+	client *http.Client
+
+	jarMtx     sync.Mutex
+	cookieJars map[cookieJarKey]http.CookieJar
+}
+
+// cookieJarKey identifies a cookie jar by its
+// namespace and the key from the request options.
+type cookieJarKey struct {
+	namespace string
+	key       any
 }
 
 var _ Requester = (*HttpWithCookies)(nil)
@@ -44,16 +54,16 @@ var _ Requester = (*HttpWithCookies)(nil)
 func NewHttpWithCookies(cfg func(client *http.Client)) *HttpWithCookies {
 	var t HttpWithCookies
 
-	t.client = http.DefaultClient
+	t.client = &http.Client{}
 
 	cfg(t.client)
 
-	t.cookieJars = make(map[any]http.CookieJar)
+	t.cookieJars = make(map[cookieJarKey]http.CookieJar)
 
 	return &t
 }
 
-func (t HttpWithCookies) Do(req *http.Request, opt Options) (*http.Response, error) {
+func (t *HttpWithCookies) Do(req *http.Request, opt Options) (*http.Response, error) {
 	jar, err := t.getJar(&opt)
 	if err != nil {
 		return nil, err
@@ -66,9 +76,17 @@ func (t HttpWithCookies) Do(req *http.Request, opt Options) (*http.Response, err
 		"cookies", jar.Cookies(req.URL),
 	).Msg("Sending request ...")
 
+	ctx := withSource(req.Context(), opt.Source)
+	ctx = withTransportKey(ctx, opt.transportKey())
+	req = req.WithContext(ctx)
+
 	client := *t.client
 	client.Jar = jar
 
+	if opt.Timeout > 0 {
+		client.Timeout = opt.Timeout
+	}
+
 	if !opt.FollowRedirects {
 		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
@@ -95,11 +113,17 @@ func (t HttpWithCookies) Do(req *http.Request, opt Options) (*http.Response, err
 // The returned jar is wrapped by the noSetWrapper
 // and/or noGetWrapper depending on the passed
 // options.
-func (t HttpWithCookies) getJar(opt *Options) (jar http.CookieJar, err error) {
-	key := opt.CookieJar
-	if key == nil {
-		key = "default"
+func (t *HttpWithCookies) getJar(opt *Options) (jar http.CookieJar, err error) {
+	key := cookieJarKey{
+		namespace: opt.CookieJarNamespace,
+		key:       opt.CookieJar,
 	}
+	if key.key == nil {
+		key.key = "default"
+	}
+
+	t.jarMtx.Lock()
+	defer t.jarMtx.Unlock()
 
 	jar, ok := t.cookieJars[key]
 	if !ok {
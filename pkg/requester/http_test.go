@@ -0,0 +1,47 @@
+package requester
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHttpWithCookies_timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	r := NewHttpWithCookies(func(client *http.Client) {
+		client.Timeout = 10 * time.Second
+	})
+
+	do := func(opt Options) error {
+		req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+		require.Nil(t, err, err)
+		res, err := r.Do(req, opt)
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+
+	opt, err := OptionsFromMap(map[string]any{"timeout": int64(50)})
+	require.Nil(t, err, err)
+	err = do(opt)
+	var netErr net.Error
+	require.ErrorAs(t, err, &netErr)
+	assert.True(t, netErr.Timeout())
+
+	opt, err = OptionsFromMap(map[string]any{"timeout": "1s"})
+	require.Nil(t, err, err)
+	err = do(opt)
+	assert.Nil(t, err, err)
+
+	assert.Zero(t, http.DefaultClient.Timeout)
+}
@@ -1,5 +1,13 @@
 package requester
 
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/studio-b12/goat/pkg/util"
+)
+
 // Options wraps request specific options.
 type Options struct {
 	CookieJar       any
@@ -7,12 +15,52 @@ type Options struct {
 	SendCookies     bool
 	ResponseType    string
 	FollowRedirects bool
+
+	// Timeout limits the duration of the request
+	// including reading the response body. When
+	// 0, the timeout of the client is used.
+	Timeout time.Duration
+
+	// CookieJarNamespace separates cookie jars
+	// with the same CookieJar key from each other.
+	CookieJarNamespace string
+
+	// Source is the position of the request in the
+	// Goatfile. It is passed on to the transport via
+	// the request context.
+	Source Source
+
+	// TLS, Proxy, HTTPVersion, DisableKeepAlives and
+	// Resolve override the respective transport options
+	// for the request. They are passed on to the
+	// transport via the request context.
+	TLS               TLSOptions
+	Proxy             string
+	HTTPVersion       string
+	DisableKeepAlives bool
+	Resolve           []string
+}
+
+// transportKey returns the transport
+// configuration specified by the options.
+func (t Options) transportKey() transportKey {
+	return transportKey{
+		tls:               t.TLS,
+		proxy:             t.Proxy,
+		httpVersion:       t.HTTPVersion,
+		disableKeepAlives: t.DisableKeepAlives,
+		resolve:           strings.Join(t.Resolve, ","),
+	}
 }
 
 // OptionsFromMap takes a map and builds an
 // Options instance from matching key-value
 // pairs.
-func OptionsFromMap(m map[string]any) Options {
+//
+// An error is returned when the value of a
+// certificate option is neither a string nor
+// a file descriptor.
+func OptionsFromMap(m map[string]any) (Options, error) {
 	opt := Options{
 		CookieJar:       "default",
 		StoreCookies:    true,
@@ -36,6 +84,38 @@ func OptionsFromMap(m map[string]any) Options {
 	if v, ok := m["followredirects"].(bool); ok {
 		opt.FollowRedirects = v
 	}
+	opt.Timeout = util.DurationFromValue(m["timeout"])
+	for key, pth := range map[string]*string{
+		"cacert":     &opt.TLS.CACert,
+		"clientcert": &opt.TLS.ClientCert,
+		"clientkey":  &opt.TLS.ClientKey,
+	} {
+		var err error
+		*pth, err = util.FilePathFromValue(key, m[key])
+		if err != nil {
+			return Options{}, err
+		}
+	}
+	if v, ok := m["clientcertpassword"].(string); ok {
+		opt.TLS.ClientCertPassword = v
+	}
+	if v, ok := m["proxy"].(string); ok {
+		opt.Proxy = v
+	}
+	if v, ok := m["httpversion"].(string); ok {
+		opt.HTTPVersion = v
+	}
+	if v, ok := m["keepalive"].(bool); ok {
+		opt.DisableKeepAlives = !v
+	}
+	switch v := m["resolve"].(type) {
+	case string:
+		opt.Resolve = []string{v}
+	case []any:
+		for _, entry := range v {
+			opt.Resolve = append(opt.Resolve, fmt.Sprint(entry))
+		}
+	}
 
-	return opt
+	return opt, nil
 }
@@ -0,0 +1,397 @@
+package requester
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/studio-b12/goat/pkg/errs"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// TLSOptions specifies the certificates used
+// to establish TLS connections.
+type TLSOptions struct {
+	// CACert is the path to a PEM file containing
+	// CA certificates which are trusted in addition
+	// to the system certificate pool.
+	CACert string
+
+	// ClientCert is the path to the client certificate
+	// presented to the server. Either a PEM file or a
+	// PKCS#12 bundle (.p12 or .pfx) is accepted.
+	ClientCert string
+
+	// ClientKey is the path to the PEM encoded private
+	// key of the client certificate. It can be omitted
+	// when ClientCert also contains the private key.
+	ClientKey string
+
+	// ClientCertPassword is the password used to
+	// decrypt a PKCS#12 bundle.
+	ClientCertPassword string
+}
+
+// merge returns a copy of t where the values set in
+// o override the values of t. The client certificate
+// and its key are always overridden together.
+func (t TLSOptions) merge(o TLSOptions) TLSOptions {
+	if o.CACert != "" {
+		t.CACert = o.CACert
+	}
+	if o.ClientCert != "" {
+		t.ClientCert = o.ClientCert
+		t.ClientKey = o.ClientKey
+		t.ClientCertPassword = o.ClientCertPassword
+	}
+	return t
+}
+
+// Config returns a tls.Config with the certificates
+// specified in the options. Server certificates are
+// verified when secure is true or when a CA certificate
+// is specified.
+func (t TLSOptions) Config(secure bool) (*tls.Config, error) {
+	cfg := &tls.Config{
+		InsecureSkipVerify: !secure && t.CACert == "",
+	}
+
+	if t.CACert != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		data, err := os.ReadFile(t.CACert)
+		if err != nil {
+			return nil, errs.WithPrefix("failed reading CA certificate:", err)
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no valid certificates found in CA certificate file %s", t.CACert)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if t.ClientCert != "" {
+		cert, err := t.loadClientCert()
+		if err != nil {
+			return nil, errs.WithPrefix("failed loading client certificate:", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+func (t TLSOptions) loadClientCert() (tls.Certificate, error) {
+	data, err := os.ReadFile(t.ClientCert)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	if isPKCS12(t.ClientCert, data) {
+		key, cert, caCerts, err := pkcs12.DecodeChain(data, t.ClientCertPassword)
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+		chain := [][]byte{cert.Raw}
+		for _, c := range caCerts {
+			chain = append(chain, c.Raw)
+		}
+		return tls.Certificate{Certificate: chain, PrivateKey: key, Leaf: cert}, nil
+	}
+
+	keyData := data
+	if t.ClientKey != "" {
+		keyData, err = os.ReadFile(t.ClientKey)
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+	}
+
+	return tls.X509KeyPair(data, keyData)
+}
+
+// isPKCS12 returns true when the certificate file at pth
+// is a PKCS#12 bundle, either by its file extension or
+// because it does not contain any PEM blocks.
+func isPKCS12(pth string, data []byte) bool {
+	switch strings.ToLower(filepath.Ext(pth)) {
+	case ".p12", ".pfx":
+		return true
+	}
+	block, _ := pem.Decode(data)
+	return block == nil
+}
+
+// HTTP versions which can be enforced
+// via the transport options.
+const (
+	HTTPVersion11 = "http1.1"
+	HTTPVersion2  = "http2"
+)
+
+// TransportOptions wraps the configuration
+// of the transports created by Transports.
+type TransportOptions struct {
+	// Secure enables the verification
+	// of server certificates.
+	Secure bool
+
+	// TLS contains the default TLS options which
+	// can be overridden by the request options.
+	TLS TLSOptions
+
+	// Proxy is the URL of the proxy used for all
+	// requests. Supported schemes are http, https
+	// and socks5. When empty, the proxy is taken
+	// from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	// environment variables.
+	Proxy string
+
+	// HTTPVersion enforces the given HTTP version,
+	// which is either HTTPVersion11 or HTTPVersion2.
+	HTTPVersion string
+
+	// DisableKeepAlives disables the reuse
+	// of connections between requests.
+	DisableKeepAlives bool
+
+	// Resolve contains entries in the format
+	// host:port:address which override the DNS
+	// resolution of the given host and port.
+	Resolve []string
+
+	ConnectTimeout        time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+}
+
+// transportKey identifies the configuration of
+// a transport. Resolve entries are joined by
+// commas so that the key is comparable.
+type transportKey struct {
+	tls               TLSOptions
+	proxy             string
+	httpVersion       string
+	disableKeepAlives bool
+	resolve           string
+}
+
+// merge returns a copy of t where the values set in
+// o override the values of t. Resolve entries of o
+// are appended, so that they take precedence.
+func (t transportKey) merge(o transportKey) transportKey {
+	t.tls = t.tls.merge(o.tls)
+	if o.proxy != "" {
+		t.proxy = o.proxy
+	}
+	if o.httpVersion != "" {
+		t.httpVersion = o.httpVersion
+	}
+	t.disableKeepAlives = t.disableKeepAlives || o.disableKeepAlives
+	if o.resolve != "" {
+		if t.resolve != "" {
+			t.resolve += ","
+		}
+		t.resolve += o.resolve
+	}
+	return t
+}
+
+type transportKeyKey struct{}
+
+// withTransportKey returns a copy of ctx carrying
+// the transport configuration of a request.
+func withTransportKey(ctx context.Context, key transportKey) context.Context {
+	return context.WithValue(ctx, transportKeyKey{}, key)
+}
+
+// Transports implements http.RoundTripper and
+// dispatches requests to a http.Transport for
+// each distinct transport configuration, so that
+// connections are only reused between requests
+// with the same certificates, proxy and protocol
+// settings.
+//
+// The transport configuration of a request is
+// passed via the request context by
+// HttpWithCookies.
+type Transports struct {
+	opts TransportOptions
+
+	mtx        sync.Mutex
+	transports map[transportKey]*http.Transport
+}
+
+var _ http.RoundTripper = (*Transports)(nil)
+
+// NewTransports returns a new instance of
+// Transports with the given options.
+func NewTransports(opts TransportOptions) *Transports {
+	return &Transports{
+		opts:       opts,
+		transports: make(map[transportKey]*http.Transport),
+	}
+}
+
+func (t *Transports) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := transportKey{
+		tls:               t.opts.TLS,
+		proxy:             t.opts.Proxy,
+		httpVersion:       t.opts.HTTPVersion,
+		disableKeepAlives: t.opts.DisableKeepAlives,
+		resolve:           strings.Join(t.opts.Resolve, ","),
+	}
+	if o, ok := req.Context().Value(transportKeyKey{}).(transportKey); ok {
+		key = key.merge(o)
+	}
+
+	transport, err := t.get(key)
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	res, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(key.httpVersion, HTTPVersion2) && res.ProtoMajor != 2 {
+		res.Body.Close()
+		return nil, fmt.Errorf("server responded with %s instead of HTTP/2", res.Proto)
+	}
+
+	return res, nil
+}
+
+// get returns the transport for the given configuration
+// or creates one if none exists yet.
+func (t *Transports) get(key transportKey) (*http.Transport, error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if transport, ok := t.transports[key]; ok {
+		return transport, nil
+	}
+
+	tlsConfig, err := key.tls.Config(t.opts.Secure)
+	if err != nil {
+		return nil, err
+	}
+
+	proxy, err := proxyFunc(key.proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: t.opts.ConnectTimeout}
+	dial := dialer.DialContext
+	if key.resolve != "" {
+		dial, err = resolvingDialer(dialer, strings.Split(key.resolve, ","))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		TLSClientConfig:       tlsConfig,
+		DialContext:           dial,
+		TLSHandshakeTimeout:   t.opts.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: t.opts.ResponseHeaderTimeout,
+		DisableKeepAlives:     key.disableKeepAlives,
+	}
+
+	switch strings.ToLower(key.httpVersion) {
+	case "":
+	case HTTPVersion11:
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	case HTTPVersion2:
+		transport.ForceAttemptHTTP2 = true
+	default:
+		return nil, fmt.Errorf("invalid HTTP version '%s' (%s or %s expected)",
+			key.httpVersion, HTTPVersion11, HTTPVersion2)
+	}
+
+	t.transports[key] = transport
+
+	return transport, nil
+}
+
+// proxyFunc returns the proxy function for the given
+// proxy URL. When proxy is empty, the proxy is taken
+// from the environment. When proxy is "none", no
+// proxy is used.
+func proxyFunc(proxy string) (func(*http.Request) (*url.URL, error), error) {
+	switch proxy {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case "none":
+		return nil, nil
+	}
+
+	if !strings.Contains(proxy, "://") {
+		proxy = "http://" + proxy
+	}
+
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, errs.WithPrefix("invalid proxy URL:", err)
+	}
+
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme '%s' (http, https or socks5 expected)", u.Scheme)
+	}
+
+	return http.ProxyURL(u), nil
+}
+
+// resolvingDialer returns a dial function which connects
+// to the addresses specified by the given resolve entries
+// in the format host:port:address instead of resolving the
+// host. Later entries override earlier entries for the
+// same host and port.
+func resolvingDialer(
+	dialer *net.Dialer,
+	entries []string,
+) (func(ctx context.Context, network, addr string) (net.Conn, error), error) {
+	overrides := make(map[string]string, len(entries))
+
+	for _, entry := range entries {
+		host, rest, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid resolve entry '%s' (format: host:port:address)", entry)
+		}
+		port, address, ok := strings.Cut(rest, ":")
+		if !ok || host == "" || port == "" || address == "" {
+			return nil, fmt.Errorf("invalid resolve entry '%s' (format: host:port:address)", entry)
+		}
+		address = strings.TrimSuffix(strings.TrimPrefix(address, "["), "]")
+		if net.ParseIP(address) == nil {
+			return nil, fmt.Errorf("invalid address in resolve entry '%s'", entry)
+		}
+		overrides[net.JoinHostPort(strings.ToLower(host), port)] = net.JoinHostPort(address, port)
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if override, ok := overrides[strings.ToLower(addr)]; ok {
+			addr = override
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}, nil
+}
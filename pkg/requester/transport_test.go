@@ -0,0 +1,332 @@
+package requester
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/studio-b12/goat/pkg/goatfile/ast"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCert(t *testing.T, cn string, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.Nil(t, err, err)
+	cert, err := x509.ParseCertificate(der)
+	require.Nil(t, err, err)
+
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) certPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+}
+
+func (c *testCert) keyPEM(t *testing.T) []byte {
+	der, err := x509.MarshalECPrivateKey(c.key)
+	require.Nil(t, err, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	pth := filepath.Join(dir, name)
+	require.Nil(t, os.WriteFile(pth, data, 0600))
+	return pth
+}
+
+func TestTransports(t *testing.T) {
+	ca := newTestCert(t, "ca", nil)
+	server := newTestCert(t, "server", ca)
+	client := newTestCert(t, "client", ca)
+	other := newTestCert(t, "other", ca)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{server.cert.Raw}, PrivateKey: server.key}},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    clientCAs,
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	dir := t.TempDir()
+	caPath := writeFile(t, dir, "ca.pem", ca.certPEM())
+	certPath := writeFile(t, dir, "client.pem", client.certPEM())
+	keyPath := writeFile(t, dir, "client-key.pem", client.keyPEM(t))
+	bundlePath := writeFile(t, dir, "other.pem", append(other.certPEM(), other.keyPEM(t)...))
+
+	p12, err := pkcs12.Modern.Encode(client.key, client.cert, []*x509.Certificate{ca.cert}, "secret")
+	require.Nil(t, err, err)
+	p12Path := writeFile(t, dir, "client.p12", p12)
+
+	do := func(transports *Transports, opt TLSOptions) (string, error) {
+		r := NewHttpWithCookies(func(c *http.Client) { c.Transport = transports })
+		req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+		require.Nil(t, err, err)
+		res, err := r.Do(req, Options{TLS: opt})
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		require.Nil(t, err, err)
+		return string(body), nil
+	}
+
+	t.Run("untrusted", func(t *testing.T) {
+		transports := NewTransports(TransportOptions{
+			Secure: true,
+			TLS:    TLSOptions{ClientCert: certPath, ClientKey: keyPath},
+		})
+		_, err := do(transports, TLSOptions{})
+		var certErr *tls.CertificateVerificationError
+		assert.ErrorAs(t, err, &certErr)
+	})
+
+	t.Run("pem", func(t *testing.T) {
+		transports := NewTransports(TransportOptions{
+			TLS: TLSOptions{CACert: caPath, ClientCert: certPath, ClientKey: keyPath},
+		})
+		cn, err := do(transports, TLSOptions{})
+		require.Nil(t, err, err)
+		assert.Equal(t, "client", cn)
+	})
+
+	t.Run("pkcs12", func(t *testing.T) {
+		transports := NewTransports(TransportOptions{
+			TLS: TLSOptions{CACert: caPath, ClientCert: p12Path, ClientCertPassword: "secret"},
+		})
+		cn, err := do(transports, TLSOptions{})
+		require.Nil(t, err, err)
+		assert.Equal(t, "client", cn)
+
+		_, err = do(transports, TLSOptions{ClientCert: p12Path, ClientCertPassword: "wrong"})
+		assert.ErrorContains(t, err, "failed loading client certificate")
+	})
+
+	t.Run("override", func(t *testing.T) {
+		transports := NewTransports(TransportOptions{
+			TLS: TLSOptions{CACert: caPath, ClientCert: certPath, ClientKey: keyPath},
+		})
+
+		cn, err := do(transports, TLSOptions{ClientCert: bundlePath})
+		require.Nil(t, err, err)
+		assert.Equal(t, "other", cn)
+
+		cn, err = do(transports, TLSOptions{})
+		require.Nil(t, err, err)
+		assert.Equal(t, "client", cn)
+
+		cn, err = do(transports, TLSOptions{ClientCert: bundlePath})
+		require.Nil(t, err, err)
+		assert.Equal(t, "other", cn)
+
+		assert.Len(t, transports.transports, 2)
+	})
+
+	t.Run("no-client-cert", func(t *testing.T) {
+		transports := NewTransports(TransportOptions{TLS: TLSOptions{CACert: caPath}})
+		_, err := do(transports, TLSOptions{})
+		assert.Error(t, err)
+	})
+}
+
+func TestOptionsFromMap_transport(t *testing.T) {
+	opt, err := OptionsFromMap(map[string]any{
+		"cacert":             "ca.pem",
+		"clientcert":         "client.p12",
+		"clientkey":          "client-key.pem",
+		"clientcertpassword": "secret",
+		"proxy":              "socks5://proxy.local:1080",
+		"httpversion":        "http2",
+		"keepalive":          false,
+		"resolve":            []any{"api.local:443:127.0.0.1", "api.local:80:127.0.0.1"},
+	})
+	require.Nil(t, err, err)
+	assert.Equal(t, TLSOptions{
+		CACert:             "ca.pem",
+		ClientCert:         "client.p12",
+		ClientKey:          "client-key.pem",
+		ClientCertPassword: "secret",
+	}, opt.TLS)
+	assert.Equal(t, "socks5://proxy.local:1080", opt.Proxy)
+	assert.Equal(t, HTTPVersion2, opt.HTTPVersion)
+	assert.True(t, opt.DisableKeepAlives)
+	assert.Equal(t, []string{"api.local:443:127.0.0.1", "api.local:80:127.0.0.1"}, opt.Resolve)
+
+	opt, err = OptionsFromMap(map[string]any{"resolve": "api.local:443:::1"})
+	require.Nil(t, err, err)
+	assert.Equal(t, []string{"api.local:443:::1"}, opt.Resolve)
+
+	opt, err = OptionsFromMap(map[string]any{
+		"cacert":     ast.FileDescriptor{Path: "ca.pem"},
+		"clientcert": ast.FileDescriptor{Path: "client.pem"},
+		"clientkey":  ast.FileDescriptor{Path: "client-key.pem"},
+	})
+	require.Nil(t, err, err)
+	assert.Equal(t, TLSOptions{
+		CACert:     "ca.pem",
+		ClientCert: "client.pem",
+		ClientKey:  "client-key.pem",
+	}, opt.TLS)
+
+	_, err = OptionsFromMap(map[string]any{"cacert": int64(1)})
+	assert.ErrorContains(t, err, "invalid cacert value type int64")
+
+	_, err = OptionsFromMap(map[string]any{"clientkey": []any{"key.pem"}})
+	assert.ErrorContains(t, err, "invalid clientkey value type []interface {}")
+}
+
+func TestTransports_connection(t *testing.T) {
+	var newConns atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Proto)
+	}))
+	srv.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			newConns.Add(1)
+		}
+	}
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Host)
+	}))
+	defer plain.Close()
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "proxied "+r.URL.String())
+	}))
+	defer proxy.Close()
+
+	do := func(transports *Transports, url string, opt Options) (string, error) {
+		r := NewHttpWithCookies(func(c *http.Client) { c.Transport = transports })
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		require.Nil(t, err, err)
+		res, err := r.Do(req, opt)
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		require.Nil(t, err, err)
+		return string(body), nil
+	}
+
+	t.Run("http-version", func(t *testing.T) {
+		transports := NewTransports(TransportOptions{HTTPVersion: HTTPVersion2})
+
+		proto, err := do(transports, srv.URL, Options{})
+		require.Nil(t, err, err)
+		assert.Equal(t, "HTTP/2.0", proto)
+
+		proto, err = do(transports, srv.URL, Options{HTTPVersion: HTTPVersion11})
+		require.Nil(t, err, err)
+		assert.Equal(t, "HTTP/1.1", proto)
+
+		_, err = do(transports, plain.URL, Options{})
+		assert.ErrorContains(t, err, "server responded with HTTP/1.1 instead of HTTP/2")
+
+		_, err = do(transports, srv.URL, Options{HTTPVersion: "http3"})
+		assert.ErrorContains(t, err, "invalid HTTP version 'http3'")
+	})
+
+	t.Run("keepalive", func(t *testing.T) {
+		transports := NewTransports(TransportOptions{})
+
+		newConns.Store(0)
+		for range 3 {
+			_, err := do(transports, srv.URL, Options{})
+			require.Nil(t, err, err)
+		}
+		assert.Equal(t, int32(1), newConns.Load())
+
+		newConns.Store(0)
+		for range 3 {
+			_, err := do(transports, srv.URL, Options{DisableKeepAlives: true})
+			require.Nil(t, err, err)
+		}
+		assert.Equal(t, int32(3), newConns.Load())
+	})
+
+	t.Run("resolve", func(t *testing.T) {
+		_, port, err := net.SplitHostPort(plain.Listener.Addr().String())
+		require.Nil(t, err, err)
+
+		transports := NewTransports(TransportOptions{Resolve: []string{"api.local:" + port + ":10.255.255.1"}})
+
+		host, err := do(transports, "http://api.local:"+port, Options{Resolve: []string{"api.local:" + port + ":127.0.0.1"}})
+		require.Nil(t, err, err)
+		assert.Equal(t, "api.local:"+port, host)
+
+		_, err = do(transports, "http://api.local:"+port, Options{Resolve: []string{"api.local:127.0.0.1"}})
+		assert.ErrorContains(t, err, "invalid resolve entry 'api.local:127.0.0.1'")
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		transports := NewTransports(TransportOptions{Proxy: proxy.URL})
+
+		body, err := do(transports, "http://example.com/foo", Options{})
+		require.Nil(t, err, err)
+		assert.Equal(t, "proxied http://example.com/foo", body)
+
+		host, err := do(transports, plain.URL, Options{Proxy: "none"})
+		require.Nil(t, err, err)
+		assert.Equal(t, plain.Listener.Addr().String(), host)
+
+		_, err = do(transports, plain.URL, Options{Proxy: "ftp://proxy.local"})
+		assert.ErrorContains(t, err, "unsupported proxy scheme 'ftp'")
+	})
+}
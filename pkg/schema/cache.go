@@ -0,0 +1,124 @@
+package schema
+
+import (
+	"path/filepath"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// Cache holds loaded schema documents and compiled
+// schemas by the absolute path of their document, so
+// that each document is only read and compiled once
+// when many values are validated against it.
+//
+// The zero value is an empty cache ready to use. A
+// Cache is safe for concurrent use.
+type Cache struct {
+	mtx     sync.Mutex
+	docs    map[docKey]document
+	schemas map[schemaKey]*jsonschema.Schema
+}
+
+// docKey identifies a document by its absolute
+// path and whether it is an OpenAPI document.
+type docKey struct {
+	path    string
+	openapi bool
+}
+
+func newDocKey(pth string, openapi bool) (docKey, error) {
+	abs, err := filepath.Abs(pth)
+	if err != nil {
+		return docKey{}, err
+	}
+	return docKey{path: abs, openapi: openapi}, nil
+}
+
+// schemaKey identifies a schema by its document and
+// the JSON pointer to the schema in the document.
+type schemaKey struct {
+	docKey
+	fragment string
+}
+
+// document is a loaded document together with the
+// default dialect its schemas are compiled with.
+type document struct {
+	doc   any
+	loc   string
+	draft *jsonschema.Draft
+}
+
+// Validate validates the given value like the package
+// level Validate function, but loads and compiles the
+// document at schemaPath only once.
+func (t *Cache) Validate(v any, schemaPath string) error {
+	key, err := newDocKey(schemaPath, false)
+	if err != nil {
+		return err
+	}
+
+	t.mtx.Lock()
+	sch, err := t.schema(schemaKey{docKey: key})
+	t.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return validate(sch, v)
+}
+
+// document returns the document with the given key and
+// loads it on first use. Must be called with t.mtx held.
+func (t *Cache) document(key docKey) (document, error) {
+	if d, ok := t.docs[key]; ok {
+		return d, nil
+	}
+
+	doc, loc, err := loadDocument(key.path)
+	if err != nil {
+		return document{}, err
+	}
+
+	d := document{doc: doc, loc: loc, draft: jsonschema.Draft2020}
+	if key.openapi {
+		d.draft, err = prepareOpenAPI(doc)
+		if err != nil {
+			return document{}, err
+		}
+	}
+
+	if t.docs == nil {
+		t.docs = make(map[docKey]document)
+	}
+	t.docs[key] = d
+
+	return d, nil
+}
+
+// schema returns the schema with the given key and
+// compiles it on first use. Must be called with t.mtx
+// held.
+func (t *Cache) schema(key schemaKey) (*jsonschema.Schema, error) {
+	if sch, ok := t.schemas[key]; ok {
+		return sch, nil
+	}
+
+	d, err := t.document(key.docKey)
+	if err != nil {
+		return nil, err
+	}
+
+	sch, err := compileDocument(d.doc, d.loc, key.fragment, d.draft)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.schemas == nil {
+		t.schemas = make(map[schemaKey]*jsonschema.Schema)
+	}
+	t.schemas[key] = sch
+
+	return sch, nil
+}
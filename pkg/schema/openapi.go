@@ -0,0 +1,263 @@
+package schema
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+var pathParamPattern = regexp.MustCompile(`\\\{[^/]+?\\\}`)
+
+// ValidateOpenAPI validates the given response body against
+// the response schema of the operation in the OpenAPI 3
+// document at specPath matching the given method, URL path
+// and response status code.
+//
+// The operation is looked up by matching the URL path against
+// the path templates of the document, with and without the
+// base paths of the specified servers. An error is returned
+// when no operation or response matches. Responses without
+// JSON content schema are not validated.
+//
+// Schemas of OpenAPI 3.1 documents are handled as JSON Schema
+// draft 2020-12 and schemas of OpenAPI 3.0 documents as draft 4,
+// where `nullable: true` is translated into an additional "null"
+// type.
+//
+// If the body does not match the schema, a *ValidationError
+// is returned.
+//
+// The document is loaded and compiled on each call. Use a
+// Cache to validate many responses against the same document.
+func ValidateOpenAPI(body any, specPath, method, urlPath string, statusCode int) error {
+	return new(Cache).ValidateOpenAPI(body, specPath, method, urlPath, statusCode)
+}
+
+// ValidateOpenAPI validates the given response body like the
+// package level ValidateOpenAPI function, but loads and
+// compiles the schemas of the document only once.
+func (t *Cache) ValidateOpenAPI(body any, specPath, method, urlPath string, statusCode int) error {
+	key, err := newDocKey(specPath, true)
+	if err != nil {
+		return err
+	}
+
+	t.mtx.Lock()
+	doc, err := t.document(key)
+	t.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	spec := doc.doc.(map[string]any)
+	paths, _ := spec["paths"].(map[string]any)
+
+	tmpl, ok := matchPath(paths, serverBasePaths(spec), urlPath)
+	if !ok {
+		return fmt.Errorf("no operation found for path %s", urlPath)
+	}
+
+	method = strings.ToLower(method)
+	operation, ok := lookup(paths, tmpl, method).(map[string]any)
+	if !ok {
+		return fmt.Errorf("no operation found for %s %s", strings.ToUpper(method), tmpl)
+	}
+
+	responses, _ := operation["responses"].(map[string]any)
+	code, ok := matchStatusCode(responses, statusCode)
+	if !ok {
+		return fmt.Errorf("response status code %d is not specified for %s %s",
+			statusCode, strings.ToUpper(method), tmpl)
+	}
+
+	content, _ := lookup(responses, code, "content").(map[string]any)
+	mediaType, ok := matchMediaType(content)
+	if !ok || lookup(content, mediaType, "schema") == nil {
+		return nil
+	}
+
+	fragment := pointer([]string{"paths", tmpl, method, "responses", code, "content", mediaType, "schema"})
+
+	t.mtx.Lock()
+	sch, err := t.schema(schemaKey{docKey: key, fragment: fragment})
+	t.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return validate(sch, body)
+}
+
+// prepareOpenAPI checks the version of the given OpenAPI
+// document and returns the default dialect of its schemas.
+// The schemas of OpenAPI 3.0 documents are rewritten to
+// JSON Schema draft 4 in place.
+func prepareOpenAPI(doc any) (*jsonschema.Draft, error) {
+	spec, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid OpenAPI document")
+	}
+
+	version, _ := spec["openapi"].(string)
+	switch {
+	case strings.HasPrefix(version, "3.1"):
+		return jsonschema.Draft2020, nil
+	case strings.HasPrefix(version, "3."):
+		rewriteNullable(doc)
+		return jsonschema.Draft4, nil
+	default:
+		return nil, fmt.Errorf("unsupported OpenAPI version '%s' (3.x expected)", version)
+	}
+}
+
+// rewriteNullable translates the OpenAPI 3.0 `nullable`
+// keyword, which is unknown to JSON Schema draft 4, in all
+// schemas of the given document by adding "null" to the
+// type and, if specified, to the enum values of schemas
+// marked as nullable. Example values are left untouched.
+func rewriteNullable(v any) {
+	switch vt := v.(type) {
+	case map[string]any:
+		if nullable, _ := vt["nullable"].(bool); nullable {
+			switch typ := vt["type"].(type) {
+			case string:
+				vt["type"] = []any{typ, "null"}
+			case []any:
+				if !slices.Contains(typ, "null") {
+					vt["type"] = append(typ, "null")
+				}
+			}
+			if enum, ok := vt["enum"].([]any); ok && !slices.Contains(enum, nil) {
+				vt["enum"] = append(enum, nil)
+			}
+		}
+		for key, e := range vt {
+			if key == "example" || key == "examples" {
+				continue
+			}
+			rewriteNullable(e)
+		}
+	case []any:
+		for _, e := range vt {
+			rewriteNullable(e)
+		}
+	}
+}
+
+// lookup returns the value at the given keys
+// in the nested maps of m.
+func lookup(m map[string]any, keys ...string) any {
+	var v any = m
+	for _, key := range keys {
+		vm, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = vm[key]
+	}
+	return v
+}
+
+// serverBasePaths returns the paths of the
+// server URLs specified in the given spec.
+func serverBasePaths(spec map[string]any) []string {
+	var basePaths []string
+
+	servers, _ := spec["servers"].([]any)
+	for _, server := range servers {
+		s, _ := server.(map[string]any)
+		rawURL, _ := s["url"].(string)
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			continue
+		}
+		if basePath := strings.TrimSuffix(u.Path, "/"); basePath != "" {
+			basePaths = append(basePaths, basePath)
+		}
+	}
+
+	return basePaths
+}
+
+// matchPath returns the path template of the given paths
+// matching urlPath or urlPath without one of the given
+// base paths. When multiple templates match, the one with
+// the least path parameters is returned.
+func matchPath(paths map[string]any, basePaths []string, urlPath string) (string, bool) {
+	candidates := []string{urlPath}
+	for _, basePath := range basePaths {
+		if rest, ok := strings.CutPrefix(urlPath, basePath); ok && strings.HasPrefix(rest, "/") {
+			candidates = append(candidates, rest)
+		}
+	}
+
+	var matches []string
+	for tmpl := range paths {
+		rx, err := regexp.Compile("^" +
+			pathParamPattern.ReplaceAllString(regexp.QuoteMeta(tmpl), "[^/]+") + "/?$")
+		if err != nil {
+			continue
+		}
+		for _, c := range candidates {
+			if rx.MatchString(c) {
+				matches = append(matches, tmpl)
+				break
+			}
+		}
+	}
+
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		ci, cj := strings.Count(matches[i], "{"), strings.Count(matches[j], "{")
+		if ci != cj {
+			return ci < cj
+		}
+		return matches[i] < matches[j]
+	})
+
+	return matches[0], true
+}
+
+// matchStatusCode returns the key of the given responses
+// matching the status code, its range (e.g. 2XX) or the
+// default response.
+func matchStatusCode(responses map[string]any, statusCode int) (string, bool) {
+	code := strconv.Itoa(statusCode)
+	for _, key := range []string{code, code[:1] + "XX", code[:1] + "xx", "default"} {
+		if _, ok := responses[key]; ok {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// matchMediaType returns the key of the given content
+// map describing JSON content.
+func matchMediaType(content map[string]any) (string, bool) {
+	if _, ok := content["application/json"]; ok {
+		return "application/json", true
+	}
+
+	keys := make([]string, 0, len(content))
+	for key := range content {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if strings.Contains(strings.ToLower(key), "json") || key == "*/*" {
+			return key, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,190 @@
+// Package schema implements the validation of
+// values against JSON Schema documents and the
+// response schemas of OpenAPI documents.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/studio-b12/goat/pkg/errs"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"gopkg.in/yaml.v3"
+)
+
+var printer = message.NewPrinter(language.English)
+
+// Violation describes a single part of a value
+// which does not match the schema.
+type Violation struct {
+	// Pointer is the JSON pointer to the
+	// violating part of the value.
+	Pointer string
+	Message string
+}
+
+func (t Violation) String() string {
+	return fmt.Sprintf("'%s': %s", t.Pointer, t.Message)
+}
+
+// ValidationError is returned when a value does
+// not match a schema and contains all violations.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (t *ValidationError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "value does not match schema (%d violation(s))", len(t.Violations))
+	for _, v := range t.Violations {
+		sb.WriteString("\n  - ")
+		sb.WriteString(v.String())
+	}
+	return sb.String()
+}
+
+// Validate validates the given value against the
+// JSON Schema document at schemaPath. The document
+// can be formatted as JSON or YAML. Documents which
+// do not specify a dialect via $schema are handled
+// as draft 2020-12.
+//
+// If the value does not match the schema, a
+// *ValidationError is returned.
+//
+// The document is loaded and compiled on each call.
+// Use a Cache to validate many values against the
+// same document.
+func Validate(v any, schemaPath string) error {
+	return new(Cache).Validate(v, schemaPath)
+}
+
+func compileDocument(doc any, loc, fragment string, draft *jsonschema.Draft) (*jsonschema.Schema, error) {
+	c := jsonschema.NewCompiler()
+	c.DefaultDraft(draft)
+
+	err := c.AddResource(loc, doc)
+	if err != nil {
+		return nil, errs.WithPrefix("failed adding schema document:", err)
+	}
+
+	sch, err := c.Compile(loc + "#" + fragment)
+	if err != nil {
+		return nil, errs.WithPrefix("failed compiling schema:", err)
+	}
+
+	return sch, nil
+}
+
+// loadDocument reads and parses the JSON or YAML document
+// at pth and returns it together with its location as
+// file URL.
+func loadDocument(pth string) (doc any, loc string, err error) {
+	abs, err := filepath.Abs(pth)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, "", errs.WithPrefix("failed reading schema document:", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(abs)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+		if err == nil {
+			doc, err = normalize(doc)
+		}
+	default:
+		doc, err = jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, "", errs.WithPrefix("failed parsing schema document:", err)
+	}
+
+	slashed := filepath.ToSlash(abs)
+	if !strings.HasPrefix(slashed, "/") {
+		slashed = "/" + slashed
+	}
+	loc = (&url.URL{Scheme: "file", Path: slashed}).String()
+
+	return doc, loc, nil
+}
+
+func validate(sch *jsonschema.Schema, v any) error {
+	v, err := normalize(v)
+	if err != nil {
+		return errs.WithPrefix("failed encoding value:", err)
+	}
+
+	err = sch.Validate(v)
+	if err == nil {
+		return nil
+	}
+
+	vErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return err
+	}
+
+	var res ValidationError
+	collectViolations(vErr, &res.Violations)
+	sort.SliceStable(res.Violations, func(i, j int) bool {
+		return res.Violations[i].Pointer < res.Violations[j].Pointer
+	})
+
+	return &res
+}
+
+// collectViolations appends the leaf errors of the
+// given validation error tree to violations.
+func collectViolations(err *jsonschema.ValidationError, violations *[]Violation) {
+	if len(err.Causes) == 0 {
+		*violations = append(*violations, Violation{
+			Pointer: pointer(err.InstanceLocation),
+			Message: err.ErrorKind.LocalizedString(printer),
+		})
+		return
+	}
+
+	for _, cause := range err.Causes {
+		collectViolations(cause, violations)
+	}
+}
+
+// pointer returns the JSON pointer
+// of the given reference tokens.
+func pointer(tokens []string) string {
+	var sb strings.Builder
+	for _, tok := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(escape(tok))
+	}
+	return sb.String()
+}
+
+// escape escapes the given reference
+// token for use in a JSON pointer.
+func escape(tok string) string {
+	tok = strings.ReplaceAll(tok, "~", "~0")
+	return strings.ReplaceAll(tok, "/", "~1")
+}
+
+// normalize converts v into the representation
+// of JSON values expected by the validator.
+func normalize(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return jsonschema.UnmarshalJSON(bytes.NewReader(data))
+}
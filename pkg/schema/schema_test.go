@@ -0,0 +1,216 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	pth := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(pth, []byte(content), 0644)
+	require.Nil(t, err, err)
+	return pth
+}
+
+const userSchema = `{
+	"type": "object",
+	"required": ["id", "name"],
+	"properties": {
+		"id": {"type": "integer"},
+		"name": {"type": "string"},
+		"tags": {"type": "array", "items": {"type": "string"}},
+		"a/b": {"type": "string"}
+	}
+}`
+
+func TestValidate(t *testing.T) {
+	pth := writeFile(t, "user.json", userSchema)
+
+	err := Validate(map[string]any{"id": 1, "name": "foo", "tags": []any{"a"}}, pth)
+	assert.Nil(t, err, err)
+
+	err = Validate(map[string]any{"id": "1", "tags": []any{"a", 2}, "a/b": 1}, pth)
+	vErr, ok := err.(*ValidationError)
+	require.True(t, ok, err)
+	require.Len(t, vErr.Violations, 4)
+
+	pointers := make([]string, 0, len(vErr.Violations))
+	for _, v := range vErr.Violations {
+		pointers = append(pointers, v.Pointer)
+	}
+	assert.Equal(t, []string{"", "/a~1b", "/id", "/tags/1"}, pointers)
+	assert.Contains(t, vErr.Violations[0].Message, "name")
+	assert.Contains(t, err.Error(), "4 violation(s)")
+	assert.Contains(t, err.Error(), "'/tags/1'")
+}
+
+func TestValidate_yaml(t *testing.T) {
+	pth := writeFile(t, "user.yaml", "type: object\nrequired: [id]\nproperties:\n  id:\n    type: integer\n    minimum: 1\n")
+
+	assert.Nil(t, Validate(map[string]any{"id": 2}, pth))
+
+	err := Validate(map[string]any{"id": 0}, pth)
+	vErr, ok := err.(*ValidationError)
+	require.True(t, ok, err)
+	require.Len(t, vErr.Violations, 1)
+	assert.Equal(t, "/id", vErr.Violations[0].Pointer)
+}
+
+func TestValidate_invalidSchema(t *testing.T) {
+	_, ok := Validate(nil, writeFile(t, "s.json", `{"type": 1}`)).(*ValidationError)
+	assert.False(t, ok)
+
+	assert.NotNil(t, Validate(nil, filepath.Join(t.TempDir(), "missing.json")))
+}
+
+const spec = `
+openapi: 3.1.0
+servers:
+  - url: https://example.com/api/v1
+paths:
+  /users/{id}:
+    get:
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/User"
+        4XX:
+          content:
+            application/problem+json:
+              schema:
+                type: object
+                required: [title]
+  /users/me:
+    get:
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [me]
+    delete:
+      responses:
+        "204":
+          description: deleted
+components:
+  schemas:
+    User:
+      type: object
+      required: [id, name]
+      properties:
+        id:
+          type: integer
+        name:
+          type: string
+`
+
+func TestValidateOpenAPI(t *testing.T) {
+	pth := writeFile(t, "openapi.yaml", spec)
+
+	err := ValidateOpenAPI(map[string]any{"id": 1, "name": "foo"}, pth, "GET", "/api/v1/users/1", 200)
+	assert.Nil(t, err, err)
+
+	err = ValidateOpenAPI(map[string]any{"id": "1"}, pth, "GET", "/users/1", 200)
+	vErr, ok := err.(*ValidationError)
+	require.True(t, ok, err)
+	assert.Len(t, vErr.Violations, 2)
+
+	err = ValidateOpenAPI(map[string]any{}, pth, "GET", "/api/v1/users/1", 404)
+	vErr, ok = err.(*ValidationError)
+	require.True(t, ok, err)
+	assert.Len(t, vErr.Violations, 1)
+
+	// The literal path is preferred over the templated one.
+	err = ValidateOpenAPI(map[string]any{"id": 1, "name": "foo"}, pth, "GET", "/api/v1/users/me", 200)
+	_, ok = err.(*ValidationError)
+	assert.True(t, ok, err)
+
+	assert.Nil(t, ValidateOpenAPI(nil, pth, "DELETE", "/users/me", 204))
+
+	err = ValidateOpenAPI(nil, pth, "DELETE", "/users/me", 500)
+	assert.ErrorContains(t, err, "response status code 500 is not specified for DELETE /users/me")
+
+	err = ValidateOpenAPI(nil, pth, "POST", "/users/me", 200)
+	assert.ErrorContains(t, err, "no operation found for POST /users/me")
+
+	err = ValidateOpenAPI(nil, pth, "GET", "/posts", 200)
+	assert.ErrorContains(t, err, "no operation found for path /posts")
+}
+
+const specV30 = `
+openapi: 3.0.3
+paths:
+  /users/{id}:
+    get:
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/User"
+components:
+  schemas:
+    User:
+      type: object
+      required: [id, name]
+      properties:
+        id:
+          type: integer
+        name:
+          type: string
+          nullable: true
+        role:
+          type: string
+          enum: [admin, user]
+          nullable: true
+        email:
+          type: string
+`
+
+func TestValidateOpenAPI_v30(t *testing.T) {
+	pth := writeFile(t, "openapi.yaml", specV30)
+
+	err := ValidateOpenAPI(map[string]any{"id": 1, "name": "foo"}, pth, "GET", "/users/1", 200)
+	assert.Nil(t, err, err)
+
+	err = ValidateOpenAPI(map[string]any{"id": 1, "name": nil, "role": nil}, pth, "GET", "/users/1", 200)
+	assert.Nil(t, err, err)
+
+	err = ValidateOpenAPI(map[string]any{"id": nil, "name": nil, "email": nil}, pth, "GET", "/users/1", 200)
+	vErr, ok := err.(*ValidationError)
+	require.True(t, ok, err)
+	assert.Len(t, vErr.Violations, 2)
+}
+
+func TestCache(t *testing.T) {
+	var c Cache
+
+	schemaPath := writeFile(t, "user.json", userSchema)
+	specPath := writeFile(t, "openapi.yaml", spec)
+
+	assert.Nil(t, c.Validate(map[string]any{"id": 1, "name": "foo"}, schemaPath))
+	assert.Nil(t, c.ValidateOpenAPI(map[string]any{"id": 1, "name": "foo"}, specPath, "GET", "/users/1", 200))
+
+	// The documents are not read again after
+	// they have been loaded once.
+	require.Nil(t, os.Remove(schemaPath))
+	require.Nil(t, os.Remove(specPath))
+
+	assert.Nil(t, c.Validate(map[string]any{"id": 2, "name": "bar"}, schemaPath))
+	_, ok := c.Validate(map[string]any{"id": "2"}, schemaPath).(*ValidationError)
+	assert.True(t, ok)
+
+	assert.Nil(t, c.ValidateOpenAPI(map[string]any{"id": 2, "name": "bar"}, specPath, "GET", "/users/2", 200))
+	_, ok = c.ValidateOpenAPI(map[string]any{}, specPath, "GET", "/users/me", 200).(*ValidationError)
+	assert.True(t, ok)
+
+	assert.NotNil(t, Validate(nil, schemaPath))
+}
@@ -0,0 +1,259 @@
+package signing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// digestAlgorithms contains the supported digest
+// algorithms ordered by preference.
+var digestAlgorithms = []string{"SHA-512-256", "SHA-256", "MD5"}
+
+// DigestChallenge holds the parameters of a
+// HTTP Digest authentication challenge as
+// specified in RFC 7616.
+type DigestChallenge struct {
+	Realm     string
+	Nonce     string
+	Opaque    string
+	Algorithm string
+	Sess      bool
+	QOP       string
+	UserHash  bool
+}
+
+// ParseDigestChallenge returns the Digest challenge with the
+// most preferred supported algorithm from the given values of
+// WWW-Authenticate headers. When no supported challenge is
+// found, false is returned.
+func ParseDigestChallenge(headers []string) (DigestChallenge, bool) {
+	var (
+		best DigestChallenge
+		rank = len(digestAlgorithms)
+	)
+
+	for _, header := range headers {
+		for _, params := range digestChallengeParams(header) {
+			c, ok := newDigestChallenge(params)
+			if !ok {
+				continue
+			}
+			if r := slices.Index(digestAlgorithms, c.Algorithm); r < rank {
+				best, rank = c, r
+			}
+		}
+	}
+
+	return best, rank < len(digestAlgorithms)
+}
+
+func newDigestChallenge(params map[string]string) (DigestChallenge, bool) {
+	c := DigestChallenge{
+		Realm:     params["realm"],
+		Nonce:     params["nonce"],
+		Opaque:    params["opaque"],
+		Algorithm: strings.ToUpper(params["algorithm"]),
+		UserHash:  strings.EqualFold(params["userhash"], "true"),
+	}
+
+	if c.Nonce == "" {
+		return DigestChallenge{}, false
+	}
+
+	if c.Algorithm == "" {
+		c.Algorithm = "MD5"
+	}
+	if alg, ok := strings.CutSuffix(c.Algorithm, "-SESS"); ok {
+		c.Algorithm = alg
+		c.Sess = true
+	}
+	if !slices.Contains(digestAlgorithms, c.Algorithm) {
+		return DigestChallenge{}, false
+	}
+
+	qops := strings.Split(params["qop"], ",")
+	for i := range qops {
+		qops[i] = strings.TrimSpace(qops[i])
+	}
+	switch {
+	case slices.Contains(qops, "auth"):
+		c.QOP = "auth"
+	case slices.Contains(qops, "auth-int"):
+		c.QOP = "auth-int"
+	}
+
+	return c, true
+}
+
+// Digest returns the value of the Authorization header
+// answering the given challenge for the given request with
+// the given body, credentials and nonce count, starting
+// with 1.
+func Digest(req *http.Request, body []byte, c DigestChallenge, username, password string, nc int) (string, error) {
+	h, err := hashFunc(c.Algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	cnonce, err := clientNonce()
+	if err != nil {
+		return "", err
+	}
+
+	return digest(req, body, c, username, password, nc, cnonce, h), nil
+}
+
+func digest(
+	req *http.Request,
+	body []byte,
+	c DigestChallenge,
+	username, password string,
+	nc int,
+	cnonce string,
+	h func() hash.Hash,
+) string {
+	H := func(parts ...string) string {
+		return hashHex(h, []byte(strings.Join(parts, ":")))
+	}
+
+	uri := req.URL.RequestURI()
+	ncValue := fmt.Sprintf("%08x", nc)
+
+	ha1 := H(username, c.Realm, password)
+	if c.Sess {
+		ha1 = H(ha1, c.Nonce, cnonce)
+	}
+
+	ha2 := H(req.Method, uri)
+	if c.QOP == "auth-int" {
+		ha2 = H(req.Method, uri, hashHex(h, body))
+	}
+
+	var response string
+	if c.QOP != "" {
+		response = H(ha1, c.Nonce, ncValue, cnonce, c.QOP, ha2)
+	} else {
+		response = H(ha1, c.Nonce, ha2)
+	}
+
+	algorithm := c.Algorithm
+	if c.Sess {
+		algorithm += "-sess"
+	}
+
+	user := username
+	if c.UserHash {
+		user = H(username, c.Realm)
+	}
+
+	params := []string{
+		"username=" + strconv.Quote(user),
+		"realm=" + strconv.Quote(c.Realm),
+		"uri=" + strconv.Quote(uri),
+		"algorithm=" + algorithm,
+		"nonce=" + strconv.Quote(c.Nonce),
+	}
+	if c.QOP != "" {
+		params = append(params,
+			"nc="+ncValue,
+			"cnonce="+strconv.Quote(cnonce),
+			"qop="+c.QOP)
+	}
+	params = append(params, "response="+strconv.Quote(response))
+	if c.Opaque != "" {
+		params = append(params, "opaque="+strconv.Quote(c.Opaque))
+	}
+	if c.UserHash {
+		params = append(params, "userhash=true")
+	}
+
+	return "Digest " + strings.Join(params, ", ")
+}
+
+func clientNonce() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// digestChallengeParams returns the parameters of all
+// Digest challenges in the given WWW-Authenticate
+// header value.
+func digestChallengeParams(header string) []map[string]string {
+	var (
+		challenges []map[string]string
+		current    map[string]string
+	)
+
+	s := header
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			break
+		}
+
+		i := strings.IndexAny(s, " \t,=")
+		if i < 0 {
+			i = len(s)
+		}
+		token := s[:i]
+		s = strings.TrimLeft(s[i:], " \t")
+
+		if !strings.HasPrefix(s, "=") {
+			// A token not followed by '=' starts a new challenge.
+			current = nil
+			if strings.EqualFold(token, "Digest") {
+				current = make(map[string]string)
+				challenges = append(challenges, current)
+			}
+			continue
+		}
+
+		s = strings.TrimLeft(s[1:], " \t")
+		var value string
+		value, s = parseParamValue(s)
+		if current != nil {
+			current[strings.ToLower(token)] = value
+		}
+	}
+
+	return challenges
+}
+
+// parseParamValue parses a token or quoted string
+// at the start of s and returns it and the rest
+// of s.
+func parseParamValue(s string) (string, string) {
+	if !strings.HasPrefix(s, `"`) {
+		i := strings.IndexByte(s, ',')
+		if i < 0 {
+			return strings.TrimSpace(s), ""
+		}
+		return strings.TrimSpace(s[:i]), s[i:]
+	}
+
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				sb.WriteByte(s[i])
+			}
+		case '"':
+			return sb.String(), s[i+1:]
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String(), ""
+}
@@ -0,0 +1,132 @@
+package signing
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// DefaultHMACTemplate is the canonical string template
+// used when no template is specified.
+const DefaultHMACTemplate = "{method}\n{path}\n{query}\n{bodyhash}"
+
+var placeholderPattern = regexp.MustCompile(`\{([a-z]+)(?:\.([^}]+))?\}`)
+
+// HMACOptions wraps the parameters of HMAC request signing.
+type HMACOptions struct {
+	// Secret is the key used to compute the HMAC.
+	Secret string
+
+	// Algorithm is the name of the hash algorithm, which
+	// is either "sha1", "sha256" or "sha512". Defaults to
+	// "sha256".
+	Algorithm string
+
+	// Header is the name of the header the signature is
+	// set to. Defaults to "Authorization".
+	Header string
+
+	// Template is the template of the signed canonical
+	// string. Defaults to DefaultHMACTemplate.
+	Template string
+
+	// Encoding of the signature, which is either "hex"
+	// or "base64". Defaults to "hex".
+	Encoding string
+
+	// Prefix is prepended to the signature
+	// in the header value.
+	Prefix string
+}
+
+// HMAC signs the given request with the given body by
+// computing the HMAC of the canonical string built from
+// the template of the given options. The encoded signature
+// is set to the specified header.
+//
+// The following placeholders are replaced in the template.
+//
+//	{method}       the request method
+//	{host}         the host of the request URL
+//	{path}         the escaped path of the request URL
+//	{query}        the raw query of the request URL
+//	{body}         the request body
+//	{bodyhash}     the hex encoded hash of the body
+//	{header.Name}  the value of the request header Name
+func HMAC(req *http.Request, body []byte, opts HMACOptions) error {
+	if opts.Secret == "" {
+		return fmt.Errorf("secret must be specified")
+	}
+
+	algorithm := opts.Algorithm
+	if algorithm == "" {
+		algorithm = "sha256"
+	}
+	h, err := hashFunc(algorithm)
+	if err != nil {
+		return err
+	}
+
+	tmpl := opts.Template
+	if tmpl == "" {
+		tmpl = DefaultHMACTemplate
+	}
+
+	canonical, err := canonicalString(req, body, tmpl, hashHex(h, body))
+	if err != nil {
+		return err
+	}
+
+	sum := hmacSum(h, []byte(opts.Secret), []byte(canonical))
+
+	var signature string
+	switch strings.ToLower(opts.Encoding) {
+	case "", "hex":
+		signature = hex.EncodeToString(sum)
+	case "base64":
+		signature = base64.StdEncoding.EncodeToString(sum)
+	default:
+		return fmt.Errorf("unsupported signature encoding '%s' (hex or base64 expected)", opts.Encoding)
+	}
+
+	header := opts.Header
+	if header == "" {
+		header = "Authorization"
+	}
+	req.Header.Set(header, opts.Prefix+signature)
+
+	return nil
+}
+
+// canonicalString replaces the placeholders in
+// tmpl with the values of the given request.
+func canonicalString(req *http.Request, body []byte, tmpl, bodyHash string) (string, error) {
+	var err error
+
+	res := placeholderPattern.ReplaceAllStringFunc(tmpl, func(m string) string {
+		sub := placeholderPattern.FindStringSubmatch(m)
+		switch {
+		case sub[1] == "method" && sub[2] == "":
+			return req.Method
+		case sub[1] == "host" && sub[2] == "":
+			return req.URL.Host
+		case sub[1] == "path" && sub[2] == "":
+			return req.URL.EscapedPath()
+		case sub[1] == "query" && sub[2] == "":
+			return req.URL.RawQuery
+		case sub[1] == "body" && sub[2] == "":
+			return string(body)
+		case sub[1] == "bodyhash" && sub[2] == "":
+			return bodyHash
+		case sub[1] == "header" && sub[2] != "":
+			return req.Header.Get(sub[2])
+		}
+		err = fmt.Errorf("invalid template placeholder %s", m)
+		return m
+	})
+
+	return res, err
+}
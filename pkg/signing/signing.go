@@ -0,0 +1,48 @@
+// Package signing implements the signing and
+// authentication of HTTP requests using AWS
+// Signature Version 4, HMAC signatures and
+// HTTP Digest authentication.
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// hashFunc returns the constructor of the hash
+// algorithm with the given name.
+func hashFunc(name string) (func() hash.Hash, error) {
+	switch strings.ToLower(strings.ReplaceAll(name, "-", "")) {
+	case "md5":
+		return md5.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "sha256":
+		return sha256.New, nil
+	case "sha512":
+		return sha512.New, nil
+	case "sha512256":
+		return sha512.New512_256, nil
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm '%s'", name)
+	}
+}
+
+func hashHex(h func() hash.Hash, data []byte) string {
+	w := h()
+	w.Write(data)
+	return hex.EncodeToString(w.Sum(nil))
+}
+
+func hmacSum(h func() hash.Hash, key, data []byte) []byte {
+	mac := hmac.New(h, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
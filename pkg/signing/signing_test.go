@@ -0,0 +1,179 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigV4(t *testing.T) {
+	t.Run("example", func(t *testing.T) {
+		// Example request from the AWS Signature Version 4 documentation.
+		req, err := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+		require.Nil(t, err, err)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+		err = SigV4(req, nil, SigV4Credentials{
+			Region:    "us-east-1",
+			Service:   "iam",
+			AccessKey: "AKIDEXAMPLE",
+			SecretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		}, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+		require.Nil(t, err, err)
+
+		assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+		assert.Equal(t,
+			"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, "+
+				"SignedHeaders=content-type;host;x-amz-date, "+
+				"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7",
+			req.Header.Get("Authorization"))
+	})
+
+	t.Run("session-token", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPut, "https://bucket.s3.amazonaws.com/my%20file.txt", nil)
+		require.Nil(t, err, err)
+
+		err = SigV4(req, []byte("hello"), SigV4Credentials{
+			Region:       "eu-central-1",
+			Service:      "s3",
+			AccessKey:    "AKID",
+			SecretKey:    "secret",
+			SessionToken: "session",
+		}, time.Now())
+		require.Nil(t, err, err)
+
+		assert.Equal(t, "session", req.Header.Get("X-Amz-Security-Token"))
+		assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+			req.Header.Get("X-Amz-Content-Sha256"))
+		assert.Contains(t, req.Header.Get("Authorization"),
+			"SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token,")
+	})
+
+	t.Run("canonical", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "https://example.com/a%20b/c?b=2&a=2&a=1&c=x%20y", nil)
+		require.Nil(t, err, err)
+
+		assert.Equal(t, "/a%2520b/c", sigV4CanonicalURI(req.URL, "execute-api"))
+		assert.Equal(t, "/a%20b/c", sigV4CanonicalURI(req.URL, "s3"))
+		assert.Equal(t, "a=1&a=2&b=2&c=x%20y", sigV4CanonicalQuery(req.URL))
+	})
+
+	t.Run("missing-credentials", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "https://example.com", nil)
+		require.Nil(t, err, err)
+
+		err = SigV4(req, nil, SigV4Credentials{Region: "us-east-1", Service: "iam"}, time.Now())
+		assert.ErrorContains(t, err, "access key and secret key must be specified")
+	})
+}
+
+func TestHMAC(t *testing.T) {
+	sign := func(secret, s string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(s))
+		return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	}
+
+	t.Run("template", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodPost, "https://example.com/api/users?page=2", nil)
+		require.Nil(t, err, err)
+		req.Header.Set("X-Timestamp", "1700000000")
+
+		err = HMAC(req, []byte(`{"name":"foo"}`), HMACOptions{
+			Secret:   "secret",
+			Header:   "X-Signature",
+			Template: "{method} {host}{path}?{query}\n{header.X-Timestamp}\n{body}",
+			Encoding: "base64",
+			Prefix:   "v1=",
+		})
+		require.Nil(t, err, err)
+
+		assert.Equal(t,
+			"v1="+sign("secret", "POST example.com/api/users?page=2\n1700000000\n{\"name\":\"foo\"}"),
+			req.Header.Get("X-Signature"))
+	})
+
+	t.Run("default", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "https://example.com/api", nil)
+		require.Nil(t, err, err)
+
+		err = HMAC(req, nil, HMACOptions{Secret: "secret"})
+		require.Nil(t, err, err)
+
+		canonical := "GET\n/api\n\ne3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(canonical))
+		assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), req.Header.Get("Authorization"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "https://example.com/api", nil)
+		require.Nil(t, err, err)
+
+		err = HMAC(req, nil, HMACOptions{Secret: "secret", Template: "{method}{foo}"})
+		assert.ErrorContains(t, err, "invalid template placeholder {foo}")
+
+		err = HMAC(req, nil, HMACOptions{Secret: "secret", Algorithm: "crc32"})
+		assert.ErrorContains(t, err, "unsupported hash algorithm 'crc32'")
+
+		err = HMAC(req, nil, HMACOptions{})
+		assert.ErrorContains(t, err, "secret must be specified")
+	})
+}
+
+func TestDigest(t *testing.T) {
+	// Examples from RFC 7616 section 3.9.1.
+	const (
+		nonce  = "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v"
+		opaque = "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"
+		cnonce = "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"
+	)
+
+	headers := []string{
+		`Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=MD5, ` +
+			`nonce="` + nonce + `", opaque="` + opaque + `"`,
+		`Basic realm="basic", Digest realm="http-auth@example.org", qop="auth, auth-int", ` +
+			`algorithm=SHA-256, nonce="` + nonce + `", opaque="` + opaque + `"`,
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "http://www.example.org/dir/index.html", nil)
+	require.Nil(t, err, err)
+
+	t.Run("parse", func(t *testing.T) {
+		c, ok := ParseDigestChallenge(headers)
+		require.True(t, ok)
+		assert.Equal(t, DigestChallenge{
+			Realm:     "http-auth@example.org",
+			Nonce:     nonce,
+			Opaque:    opaque,
+			Algorithm: "SHA-256",
+			QOP:       "auth",
+		}, c)
+
+		_, ok = ParseDigestChallenge([]string{`Basic realm="basic"`})
+		assert.False(t, ok)
+	})
+
+	t.Run("sha256", func(t *testing.T) {
+		c, _ := ParseDigestChallenge(headers)
+		res := digest(req, nil, c, "Mufasa", "Circle of Life", 1, cnonce, sha256.New)
+		assert.Equal(t, `Digest username="Mufasa", realm="http-auth@example.org", uri="/dir/index.html", `+
+			`algorithm=SHA-256, nonce="`+nonce+`", nc=00000001, cnonce="`+cnonce+`", qop=auth, `+
+			`response="753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1", opaque="`+opaque+`"`,
+			res)
+	})
+
+	t.Run("md5", func(t *testing.T) {
+		c, _ := ParseDigestChallenge(headers[:1])
+		res := digest(req, nil, c, "Mufasa", "Circle of Life", 1, cnonce, md5.New)
+		assert.Contains(t, res, `response="8ca523f5e9506fed4657c9700eebdbec"`)
+	})
+}
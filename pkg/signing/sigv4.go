@@ -0,0 +1,182 @@
+package signing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4TimeFormat = "20060102T150405Z"
+	sigV4DateFormat = "20060102"
+)
+
+// SigV4Credentials wraps the parameters required
+// to sign requests using AWS Signature Version 4.
+type SigV4Credentials struct {
+	Region       string
+	Service      string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+}
+
+// SigV4 signs the given request with the given body using
+// AWS Signature Version 4 at the given time. The signature
+// is set as Authorization header and the required X-Amz-*
+// headers are added to the request.
+//
+// The Host, Content-Type and all X-Amz-* headers are
+// included in the signature.
+func SigV4(req *http.Request, body []byte, creds SigV4Credentials, now time.Time) error {
+	if creds.Region == "" || creds.Service == "" {
+		return fmt.Errorf("region and service must be specified")
+	}
+	if creds.AccessKey == "" || creds.SecretKey == "" {
+		return fmt.Errorf("access key and secret key must be specified")
+	}
+
+	now = now.UTC()
+	amzDate := now.Format(sigV4TimeFormat)
+	date := now.Format(sigV4DateFormat)
+
+	payloadHash := hashHex(sha256.New, body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	// S3 requires the payload hash to be passed as header.
+	if creds.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	headers, signedHeaders := sigV4CanonicalHeaders(req)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		sigV4CanonicalURI(req.URL, creds.Service),
+		sigV4CanonicalQuery(req.URL),
+		headers,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, creds.Region, creds.Service, "aws4_request"}, "/")
+
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		amzDate,
+		scope,
+		hashHex(sha256.New, []byte(canonicalRequest)),
+	}, "\n")
+
+	key := []byte("AWS4" + creds.SecretKey)
+	for _, part := range []string{date, creds.Region, creds.Service, "aws4_request"} {
+		key = hmacSum(sha256.New, key, []byte(part))
+	}
+	signature := hex.EncodeToString(hmacSum(sha256.New, key, []byte(stringToSign)))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, creds.AccessKey, scope, signedHeaders, signature))
+
+	return nil
+}
+
+// sigV4CanonicalURI returns the URI encoded path of u. Except
+// for S3, the already escaped path is encoded once more.
+func sigV4CanonicalURI(u *url.URL, service string) string {
+	pth := u.EscapedPath()
+	if pth == "" {
+		return "/"
+	}
+	if service == "s3" {
+		return pth
+	}
+	return uriEncode(pth, false)
+}
+
+// sigV4CanonicalQuery returns the query parameters of u
+// URI encoded and sorted by name and value.
+func sigV4CanonicalQuery(u *url.URL) string {
+	query := u.Query()
+	params := make([][2]string, 0, len(query))
+	for k, vals := range query {
+		for _, v := range vals {
+			params = append(params, [2]string{uriEncode(k, true), uriEncode(v, true)})
+		}
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i][0] != params[j][0] {
+			return params[i][0] < params[j][0]
+		}
+		return params[i][1] < params[j][1]
+	})
+
+	encoded := make([]string, 0, len(params))
+	for _, p := range params {
+		encoded = append(encoded, p[0]+"="+p[1])
+	}
+	return strings.Join(encoded, "&")
+}
+
+// sigV4CanonicalHeaders returns the canonical headers
+// block and the list of signed header names.
+func sigV4CanonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	values := map[string]string{"host": host}
+	for k, vals := range req.Header {
+		name := strings.ToLower(k)
+		if name != "content-type" && !strings.HasPrefix(name, "x-amz-") {
+			continue
+		}
+		trimmed := make([]string, 0, len(vals))
+		for _, v := range vals {
+			trimmed = append(trimmed, strings.Join(strings.Fields(v), " "))
+		}
+		values[name] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&sb, "%s:%s\n", name, values[name])
+	}
+
+	return sb.String(), strings.Join(names, ";")
+}
+
+// uriEncode encodes all characters of s except unreserved
+// characters as specified by RFC 3986. When encodeSlash is
+// false, slashes are not encoded.
+func uriEncode(s string, encodeSlash bool) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			sb.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			sb.WriteByte(c)
+		default:
+			fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,136 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+var identPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ChangeKind specifies how a value
+// differs from the snapshot.
+type ChangeKind string
+
+const (
+	ChangeAdded   ChangeKind = "+"
+	ChangeRemoved ChangeKind = "-"
+	ChangeChanged ChangeKind = "~"
+)
+
+// Change describes a single difference
+// between a snapshot and a value.
+type Change struct {
+	Kind ChangeKind
+	// Path is the jq path to the differing part.
+	Path     string
+	Expected any
+	Actual   any
+}
+
+func (t Change) String() string {
+	switch t.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %s", t.Path, encode(t.Actual))
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %s", t.Path, encode(t.Expected))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", t.Path, encode(t.Expected), encode(t.Actual))
+	}
+}
+
+// Diff returns the differences between the given
+// expected and actual JSON values ordered by path.
+func Diff(expected, actual any) []Change {
+	var changes []Change
+	diff("", expected, actual, &changes)
+	return changes
+}
+
+func diff(path string, expected, actual any, changes *[]Change) {
+	switch e := expected.(type) {
+	case map[string]any:
+		a, ok := actual.(map[string]any)
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(e)+len(a))
+		for k := range e {
+			keys = append(keys, k)
+		}
+		for k := range a {
+			if _, ok := e[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			ev, eok := e[k]
+			av, aok := a[k]
+			keyPath := path + key(k)
+			if path == "" && !identPattern.MatchString(k) {
+				keyPath = "." + keyPath
+			}
+			switch {
+			case !aok:
+				*changes = append(*changes, Change{Kind: ChangeRemoved, Path: keyPath, Expected: ev})
+			case !eok:
+				*changes = append(*changes, Change{Kind: ChangeAdded, Path: keyPath, Actual: av})
+			default:
+				diff(keyPath, ev, av, changes)
+			}
+		}
+		return
+
+	case []any:
+		a, ok := actual.([]any)
+		if !ok {
+			break
+		}
+
+		for i := 0; i < max(len(e), len(a)); i++ {
+			indexPath := fmt.Sprintf("%s[%d]", path, i)
+			if path == "" {
+				indexPath = "." + indexPath
+			}
+			switch {
+			case i >= len(a):
+				*changes = append(*changes, Change{Kind: ChangeRemoved, Path: indexPath, Expected: e[i]})
+			case i >= len(e):
+				*changes = append(*changes, Change{Kind: ChangeAdded, Path: indexPath, Actual: a[i]})
+			default:
+				diff(indexPath, e[i], a[i], changes)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		if path == "" {
+			path = "."
+		}
+		*changes = append(*changes, Change{Kind: ChangeChanged, Path: path, Expected: expected, Actual: actual})
+	}
+}
+
+// key returns the jq path segment
+// selecting the given object key.
+func key(k string) string {
+	if identPattern.MatchString(k) {
+		return "." + k
+	}
+	return fmt.Sprintf("[%s]", strconv.Quote(k))
+}
+
+func encode(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
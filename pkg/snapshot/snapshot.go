@@ -0,0 +1,179 @@
+// Package snapshot implements storing values as
+// snapshot files and comparing values against
+// previously stored snapshots.
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/itchyny/gojq"
+	"github.com/studio-b12/goat/pkg/errs"
+)
+
+// Dir is the name of the directory, relative to the
+// directory of the Goatfile, snapshots are stored in.
+// Because of the leading underscore, the directory is
+// skipped when searching for Goatfiles to execute.
+const Dir = "__snapshots__"
+
+// Result describes the outcome of a
+// successful snapshot comparison.
+type Result int
+
+const (
+	// Matched indicates that the value matches
+	// the stored snapshot.
+	Matched Result = iota
+	// Created indicates that no snapshot has been
+	// stored yet and the value has been stored.
+	Created
+	// Updated indicates that the stored snapshot
+	// has been overwritten by the value.
+	Updated
+)
+
+// MismatchError is returned when a value does
+// not match the stored snapshot.
+type MismatchError struct {
+	Name    string
+	Path    string
+	Changes []Change
+}
+
+func (t *MismatchError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "value does not match snapshot '%s' (%d change(s))", t.Name, len(t.Changes))
+	for _, c := range t.Changes {
+		sb.WriteString("\n  ")
+		sb.WriteString(c.String())
+	}
+	return sb.String()
+}
+
+// Match compares the given value against the snapshot with
+// the given name stored in the snapshot directory in dir.
+// Before, the value is normalized to its JSON representation
+// and the parts selected by the given jq path expressions
+// are removed.
+//
+// When no snapshot with the given name exists or when update
+// is true, the normalized value is stored as snapshot instead.
+//
+// If the value does not match the snapshot, a *MismatchError
+// listing the differences is returned.
+func Match(dir, name string, v any, ignore []string, update bool) (Result, error) {
+	if name == "" {
+		return 0, errors.New("snapshot name must not be empty")
+	}
+
+	pth := filepath.Join(dir, Dir, filepath.FromSlash(name)+".json")
+
+	actual, err := Normalize(v, ignore)
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := os.ReadFile(pth)
+	if os.IsNotExist(err) {
+		return Created, store(pth, actual)
+	}
+	if err != nil {
+		return 0, errs.WithPrefix("failed reading snapshot:", err)
+	}
+
+	if update {
+		return Updated, store(pth, actual)
+	}
+
+	var stored any
+	err = json.Unmarshal(data, &stored)
+	if err != nil {
+		return 0, errs.WithPrefix("failed parsing snapshot:", err)
+	}
+
+	expected, err := Normalize(stored, ignore)
+	if err != nil {
+		return 0, err
+	}
+
+	changes := Diff(expected, actual)
+	if len(changes) != 0 {
+		return 0, &MismatchError{Name: name, Path: pth, Changes: changes}
+	}
+
+	return Matched, nil
+}
+
+// Normalize returns the JSON representation of v with
+// the parts selected by the given jq path expressions
+// removed.
+func Normalize(v any, ignore []string) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, errs.WithPrefix("failed encoding value:", err)
+	}
+
+	var res any
+	err = json.Unmarshal(data, &res)
+	if err != nil {
+		return nil, errs.WithPrefix("failed decoding value:", err)
+	}
+
+	for _, expr := range ignore {
+		res, err = remove(res, expr)
+		if err != nil {
+			return nil, errs.WithPrefix(fmt.Sprintf("invalid ignore path '%s':", expr), err)
+		}
+	}
+
+	return res, nil
+}
+
+// remove deletes the parts of v selected by
+// the given jq path expression.
+func remove(v any, expr string) (any, error) {
+	query, err := gojq.Parse(fmt.Sprintf("del(%s)", expr))
+	if err != nil {
+		return nil, err
+	}
+
+	iter := query.Run(v)
+	res, ok := iter.Next()
+	if !ok {
+		return v, nil
+	}
+	if err, ok := res.(error); ok {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func store(pth string, v any) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(v)
+	if err != nil {
+		return errs.WithPrefix("failed encoding snapshot:", err)
+	}
+
+	err = os.MkdirAll(filepath.Dir(pth), os.ModePerm)
+	if err != nil {
+		return errs.WithPrefix("failed creating snapshot directory:", err)
+	}
+
+	err = os.WriteFile(pth, buf.Bytes(), 0644)
+	if err != nil {
+		return errs.WithPrefix("failed writing snapshot:", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	dir := t.TempDir()
+	ignore := []string{".createdAt", ".items[].id"}
+
+	value := map[string]any{
+		"name":      "foo",
+		"createdAt": "2024-01-01T00:00:00Z",
+		"items":     []any{map[string]any{"id": 1, "n": 1}, map[string]any{"id": 2, "n": 2}},
+	}
+
+	res, err := Match(dir, "users/foo", value, ignore, false)
+	require.Nil(t, err, err)
+	assert.Equal(t, Created, res)
+
+	data, err := os.ReadFile(filepath.Join(dir, Dir, "users", "foo.json"))
+	require.Nil(t, err, err)
+	assert.Equal(t, "{\n  \"items\": [\n    {\n      \"n\": 1\n    },\n    {\n      \"n\": 2\n    }\n  ],\n  \"name\": \"foo\"\n}\n",
+		string(data))
+
+	value["createdAt"] = "2024-02-02T00:00:00Z"
+	value["items"] = []any{map[string]any{"id": 3, "n": 1}, map[string]any{"id": 4, "n": 2}}
+	res, err = Match(dir, "users/foo", value, ignore, false)
+	require.Nil(t, err, err)
+	assert.Equal(t, Matched, res)
+
+	value["name"] = "bar"
+	_, err = Match(dir, "users/foo", value, ignore, false)
+	mErr, ok := err.(*MismatchError)
+	require.True(t, ok, err)
+	assert.Equal(t, []Change{{Kind: ChangeChanged, Path: ".name", Expected: "foo", Actual: "bar"}}, mErr.Changes)
+	assert.Contains(t, err.Error(), `~ .name: "foo" -> "bar"`)
+
+	res, err = Match(dir, "users/foo", value, ignore, true)
+	require.Nil(t, err, err)
+	assert.Equal(t, Updated, res)
+
+	res, err = Match(dir, "users/foo", value, ignore, false)
+	require.Nil(t, err, err)
+	assert.Equal(t, Matched, res)
+
+	_, err = Match(dir, "users/foo", value, []string{".["}, false)
+	assert.ErrorContains(t, err, "invalid ignore path")
+
+	_, err = Match(dir, "", value, nil, false)
+	assert.NotNil(t, err)
+}
+
+func TestDiff(t *testing.T) {
+	expected := map[string]any{
+		"a":     1.0,
+		"b":     []any{1.0, 2.0},
+		"c d":   true,
+		"e":     map[string]any{"f": "g"},
+		"gone":  nil,
+		"typed": []any{},
+	}
+	actual := map[string]any{
+		"a":     2.0,
+		"b":     []any{1.0, 2.0, 3.0},
+		"c d":   true,
+		"e":     map[string]any{"f": "h"},
+		"new":   "x",
+		"typed": map[string]any{},
+	}
+
+	changes := Diff(expected, actual)
+	strs := make([]string, 0, len(changes))
+	for _, c := range changes {
+		strs = append(strs, c.String())
+	}
+
+	assert.Equal(t, []string{
+		"~ .a: 1 -> 2",
+		"+ .b[2]: 3",
+		`~ .e.f: "g" -> "h"`,
+		"- .gone: null",
+		`+ .new: "x"`,
+		"~ .typed: [] -> {}",
+	}, strs)
+
+	assert.Empty(t, Diff([]any{1.0}, []any{1.0}))
+	assert.Equal(t, ".[1]", Diff([]any{1.0}, []any{1.0, 2.0})[0].Path)
+	assert.Equal(t, `.["c d"]`, Diff(map[string]any{"c d": 1.0}, map[string]any{})[0].Path)
+	assert.Equal(t, ".", Diff(1.0, 2.0)[0].Path)
+}
@@ -0,0 +1,52 @@
+package util
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/studio-b12/goat/pkg/goatfile/ast"
+)
+
+// IntFromValue returns the integer value of v when v
+// is either an int or an int64 as parsed from a
+// Goatfile.
+func IntFromValue(v any) (int, bool) {
+	switch vt := v.(type) {
+	case int:
+		return vt, true
+	case int64:
+		return int(vt), true
+	}
+	return 0, false
+}
+
+// DurationFromValue returns the duration represented by v,
+// which is either a number of milliseconds or a string
+// compatible to time.ParseDuration. Otherwise, 0 is returned.
+func DurationFromValue(v any) time.Duration {
+	if ms, ok := IntFromValue(v); ok {
+		return time.Duration(ms) * time.Millisecond
+	}
+	if vt, ok := v.(string); ok {
+		d, _ := time.ParseDuration(vt)
+		return d
+	}
+	return 0
+}
+
+// FilePathFromValue returns the file path specified by the
+// value v of the option key, which can either be passed as
+// string or as file descriptor. When v is nil, an empty
+// string is returned.
+func FilePathFromValue(key string, v any) (string, error) {
+	switch vt := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return vt, nil
+	case ast.FileDescriptor:
+		return vt.Path, nil
+	default:
+		return "", fmt.Errorf("invalid %s value type %T (file path expected)", key, v)
+	}
+}
@@ -0,0 +1,226 @@
+// Package watch implements detecting changes of
+// Goatfiles and the files they depend on.
+package watch
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/studio-b12/goat/pkg/engine"
+	"github.com/studio-b12/goat/pkg/goatfile"
+	"github.com/studio-b12/goat/pkg/goatfile/ast"
+	"github.com/studio-b12/goat/pkg/set"
+)
+
+// Goatfiles returns the paths of all Goatfiles which
+// are executed as batches when executing the given
+// locations. Directories are searched recursively
+// using the same rules as on execution. Locations
+// which do not exist are skipped.
+func Goatfiles(locations []string) ([]string, error) {
+	var files []string
+
+	for _, location := range locations {
+		if _, err := os.Stat(location); os.IsNotExist(err) {
+			continue
+		}
+		err := filepath.WalkDir(location, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if p == location && !d.IsDir() {
+				files = append(files, p)
+				return nil
+			}
+			if d.IsDir() && strings.HasPrefix(d.Name(), "_") {
+				return fs.SkipDir
+			}
+			if d.IsDir() ||
+				filepath.Ext(d.Name()) != "."+goatfile.FileExtension ||
+				strings.HasPrefix(d.Name(), "_") {
+				return nil
+			}
+			files = append(files, p)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// Dependencies returns the path of the Goatfile at pth
+// and the paths of all files it depends on. These are
+// the Goatfiles imported via use statements and executed
+// via execute statements as well as files referenced
+// in request bodies, scripts and form data and the
+// modules imported by scripts, including the
+// dependencies of the imported and executed Goatfiles.
+//
+// Goatfiles which can not be read or parsed are
+// included without their dependencies.
+func Dependencies(fSys fs.FS, pth string) []string {
+	c := collector{fs: fSys, deps: make(set.Set[string])}
+	c.goatfile(pth)
+
+	deps := make([]string, 0, len(c.deps))
+	for dep := range c.deps {
+		deps = append(deps, dep)
+	}
+	return deps
+}
+
+type collector struct {
+	fs   fs.FS
+	deps set.Set[string]
+}
+
+func (t *collector) goatfile(pth string) {
+	if !t.deps.Add(pth) {
+		return
+	}
+
+	raw, err := fs.ReadFile(t.fs, pth)
+	if err != nil {
+		return
+	}
+
+	gf, err := goatfile.NewParser(strings.NewReader(string(raw)), pth).Parse()
+	if err != nil {
+		return
+	}
+
+	for _, imp := range gf.Imports {
+		t.goatfile(resolve(pth, imp.Path))
+	}
+
+	t.actions(pth, gf.Actions)
+
+	for _, sect := range gf.Sections {
+		switch s := sect.(type) {
+		case ast.SectionDefaults:
+			t.blocks(pth, s.Request.Blocks)
+		case ast.SectionSetup:
+			t.actions(pth, s.Actions)
+		case ast.SectionTests:
+			t.actions(pth, s.Actions)
+		case ast.SectionTeardown:
+			t.actions(pth, s.Actions)
+		}
+	}
+}
+
+func (t *collector) actions(pth string, actions []ast.Action) {
+	for _, act := range actions {
+		switch a := act.(type) {
+		case *ast.Request:
+			t.blocks(pth, a.Blocks)
+		case *ast.Execute:
+			t.goatfile(resolve(pth, a.Path))
+		}
+	}
+}
+
+func (t *collector) blocks(pth string, blocks []ast.RequestBlock) {
+	for _, block := range blocks {
+		switch b := block.(type) {
+		case ast.RequestBody:
+			t.data(pth, b.DataContent)
+		case ast.RequestPreScript:
+			t.script(pth, b.DataContent)
+		case ast.RequestScript:
+			t.script(pth, b.DataContent)
+		case ast.FormData:
+			for _, kv := range b.KVList {
+				t.data(pth, kv.Value)
+			}
+		}
+	}
+}
+
+// script adds the script file referenced by v as well
+// as the modules imported by the script to the
+// dependencies. Imports in scripts are resolved
+// relative to the Goatfile at pth.
+func (t *collector) script(pth string, v any) {
+	switch c := v.(type) {
+	case ast.TextBlock:
+		t.imports(path.Dir(pth), c.Content)
+	case ast.FileDescriptor:
+		filePath, ok := t.data(pth, c)
+		if !ok {
+			return
+		}
+		raw, err := fs.ReadFile(t.fs, filePath)
+		if err != nil {
+			return
+		}
+		t.imports(path.Dir(pth), string(raw))
+	}
+}
+
+// imports adds the modules imported by the given script
+// or module source relative to dir to the dependencies,
+// including the modules these import.
+func (t *collector) imports(dir, src string) {
+	imports, _ := engine.ParseImports(src)
+	for _, imp := range imports {
+		spec := imp.Specifier
+		if !strings.HasPrefix(spec, "./") && !strings.HasPrefix(spec, "../") && !path.IsAbs(spec) {
+			continue
+		}
+
+		modPath := spec
+		if !path.IsAbs(modPath) {
+			modPath = path.Join(dir, modPath)
+		}
+		if _, err := fs.Stat(t.fs, modPath); err != nil && path.Ext(modPath) == "" {
+			modPath += ".js"
+		}
+
+		if !t.deps.Add(modPath) || strings.ToLower(path.Ext(modPath)) == ".json" {
+			continue
+		}
+
+		raw, err := fs.ReadFile(t.fs, modPath)
+		if err != nil {
+			continue
+		}
+		t.imports(path.Dir(modPath), string(raw))
+	}
+}
+
+// data adds the file referenced by v to the dependencies
+// and returns its path.
+func (t *collector) data(pth string, v any) (string, bool) {
+	fd, ok := v.(ast.FileDescriptor)
+	if !ok {
+		return "", false
+	}
+
+	filePath := fd.Path
+	switch {
+	case strings.HasPrefix(filePath, "~/"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", false
+		}
+		filePath = path.Join(home, filePath[2:])
+	case !filepath.IsAbs(filePath):
+		filePath = filepath.Join(path.Dir(pth), filePath)
+	}
+
+	t.deps.Add(filePath)
+	return filePath, true
+}
+
+// resolve returns the path of the Goatfile referenced
+// by ref relative to the Goatfile at pth.
+func resolve(pth, ref string) string {
+	return goatfile.Extend(path.Join(path.Dir(pth), ref), goatfile.FileExtension)
+}
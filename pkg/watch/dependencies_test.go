@@ -0,0 +1,55 @@
+package watch
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDependencies(t *testing.T) {
+	fSys := fstest.MapFS{
+		"a.goat": {Data: []byte("use _lib/b\n\n" +
+			"### Setup\n\n" +
+			"POST https://example.com\n\n" +
+			"[Body]\n@data/body.json\n\n" +
+			"---\n\n" +
+			"execute _lib/c (a = 1) return (b as b)\n\n" +
+			"### Tests\n\n" +
+			"POST https://example.com\n\n" +
+			"[FormData]\nfile = @data/file.txt:text/plain\nname = \"foo\"\n\n" +
+			"[Script]\n@scripts/check.js\n")},
+		"_lib/b.goat": {Data: []byte("GET https://example.com\n\n[Body]\n@b.json\n")},
+		"_lib/c.goat": {Data: []byte("use b\n\nGET https://example.com\n\n" +
+			"[PreScript]\nimport { sign } from \"./_js/sign\";\n")},
+		"_lib/_js/sign.js": {Data: []byte("import key from \"./key.json\";\n" +
+			"export { hash } from \"../../scripts/hash.js\";\n" +
+			"export function sign() {}\n")},
+		"scripts/check.js": {Data: []byte("import * as util from \"./_lib/_js/sign.js\";\n" +
+			"import \"./missing.js\";\n")},
+	}
+
+	deps := Dependencies(fSys, "a.goat")
+	assert.ElementsMatch(t, []string{
+		"a.goat",
+		"_lib/b.goat",
+		"_lib/b.json",
+		"_lib/c.goat",
+		"data/body.json",
+		"data/file.txt",
+		"scripts/check.js",
+		"missing.js",
+		"_lib/_js/sign.js",
+		"_lib/_js/key.json",
+		"scripts/hash.js",
+	}, deps)
+}
+
+func TestDependencies_invalid(t *testing.T) {
+	fSys := fstest.MapFS{
+		"a.goat": {Data: []byte("use b\n\n[Foo]\n")},
+	}
+
+	assert.Equal(t, []string{"a.goat"}, Dependencies(fSys, "a.goat"))
+	assert.Equal(t, []string{"missing.goat"}, Dependencies(fSys, "missing.goat"))
+}
@@ -0,0 +1,65 @@
+package watch
+
+import (
+	"os"
+	"time"
+)
+
+// fileState is the state of a file used to
+// detect changes.
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func (t fileState) equal(other fileState) bool {
+	return t.exists == other.exists && t.size == other.size && t.modTime.Equal(other.modTime)
+}
+
+// Poller detects changes of files by comparing their
+// modification times and sizes between subsequent
+// calls of Update.
+type Poller struct {
+	states      map[string]fileState
+	initialized bool
+}
+
+// NewPoller returns a new Poller without any
+// tracked files.
+func NewPoller() *Poller {
+	return &Poller{
+		states: make(map[string]fileState),
+	}
+}
+
+// Update records the current state of the given files
+// and returns the files which have been changed, created
+// or deleted since the previous call. Files which have
+// not been passed in the previous call are reported as
+// changed, except on the first call.
+//
+// Files which are not passed anymore are no longer
+// tracked.
+func (t *Poller) Update(files []string) (changed []string) {
+	states := make(map[string]fileState, len(files))
+
+	for _, f := range files {
+		var state fileState
+		if info, err := os.Stat(f); err == nil {
+			state = fileState{exists: true, size: info.Size(), modTime: info.ModTime()}
+		}
+
+		prev, tracked := t.states[f]
+		if t.initialized && (!tracked || !prev.equal(state)) {
+			changed = append(changed, f)
+		}
+
+		states[f] = state
+	}
+
+	t.states = states
+	t.initialized = true
+
+	return changed
+}
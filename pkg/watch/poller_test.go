@@ -0,0 +1,44 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoller(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.goat")
+	b := filepath.Join(dir, "b.goat")
+
+	require.Nil(t, os.WriteFile(a, []byte("a"), 0644))
+
+	p := NewPoller()
+	assert.Empty(t, p.Update([]string{a, b}))
+	assert.Empty(t, p.Update([]string{a, b}))
+
+	// Changed size
+	require.Nil(t, os.WriteFile(a, []byte("aa"), 0644))
+	assert.Equal(t, []string{a}, p.Update([]string{a, b}))
+
+	// Changed modification time
+	require.Nil(t, os.Chtimes(a, time.Now(), time.Now().Add(time.Hour)))
+	assert.Equal(t, []string{a}, p.Update([]string{a, b}))
+
+	// Created
+	require.Nil(t, os.WriteFile(b, []byte("b"), 0644))
+	assert.Equal(t, []string{b}, p.Update([]string{a, b}))
+
+	// Deleted
+	require.Nil(t, os.Remove(a))
+	assert.Equal(t, []string{a}, p.Update([]string{a, b}))
+
+	// Newly tracked
+	c := filepath.Join(dir, "c.goat")
+	assert.Equal(t, []string{c}, p.Update([]string{b, c}))
+	assert.Empty(t, p.Update([]string{b, c}))
+}